@@ -21,7 +21,9 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	"github.com/labring/image-cri-shim/pkg/server"
 	"github.com/labring/image-cri-shim/pkg/shim"
 	"github.com/labring/image-cri-shim/pkg/types"
 	"github.com/spf13/cobra"
@@ -34,6 +36,9 @@ var cfg *types.Config
 var shimAuth *types.ShimAuthConfig
 var cfgFile string
 
+// configCheckInterval is the interval to check whether the config file is changed.
+const configCheckInterval = 10 * time.Second
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "image-cri-shim",
@@ -88,15 +93,72 @@ func run(cfg *types.Config, auth *types.ShimAuthConfig) {
 		logger.Fatal(fmt.Sprintf("failed to start image_shim, %s", err))
 	}
 
+	if cfg.Metrics != "" {
+		go func() {
+			if err := server.ServeMetrics(cfg.Metrics); err != nil {
+				logger.Error("failed to serve metrics: %v", err)
+			}
+		}()
+	}
+
 	signalCh := make(chan os.Signal, 1)
 	signal.Notify(signalCh, syscall.SIGINT, syscall.SIGTERM)
+	reloadCh := make(chan os.Signal, 1)
+	signal.Notify(reloadCh, syscall.SIGHUP)
+	ticker := time.NewTicker(configCheckInterval)
+	defer ticker.Stop()
+	modTime := configModTime()
 
 	stopCh := make(chan struct{}, 1)
-	select {
-	case <-signalCh:
-		close(stopCh)
-	case <-stopCh:
+loop:
+	for {
+		select {
+		case <-signalCh:
+			close(stopCh)
+			break loop
+		case <-stopCh:
+			break loop
+		case <-reloadCh:
+			logger.Info("received SIGHUP, reloading config %s", cfgFile)
+			modTime = configModTime()
+			reload(imgShim)
+		case <-ticker.C:
+			if t := configModTime(); !t.Equal(modTime) {
+				logger.Info("config %s is changed, reloading", cfgFile)
+				modTime = t
+				reload(imgShim)
+			}
+		}
 	}
 	_ = os.Remove(cfg.ImageShimSocket)
 	logger.Info("shutting down the image_shim")
 }
+
+func configModTime() time.Time {
+	fi, err := os.Stat(cfgFile)
+	if err != nil {
+		return time.Time{}
+	}
+	return fi.ModTime()
+}
+
+// reload applies the config file to the running shim, the current config is kept if it fails.
+func reload(imgShim shim.Shim) {
+	err := func() error {
+		newCfg, err := types.Unmarshal(cfgFile)
+		if err != nil {
+			return fmt.Errorf("image shim config load error: %w", err)
+		}
+		newAuth, err := newCfg.PreProcess()
+		if err != nil {
+			return fmt.Errorf("image shim config pre process error: %w", err)
+		}
+		return imgShim.Reload(newCfg, newAuth)
+	}()
+	server.RecordReload(err)
+	if err != nil {
+		logger.Error("failed to reload config, keep running with the current one: %v", err)
+		return
+	}
+	logger.Info("succeeded in reloading config %s", cfgFile)
+}
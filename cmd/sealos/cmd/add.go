@@ -34,6 +34,9 @@ add to default cluster:
 add with different ssh setting:
 	sealos add --masters x.x.x.x --nodes x.x.x.x --passwd your_diff_passwd
 Please note that the masters and nodes added in one command should have the save password.
+
+preview the nodes to be joined without touching any host:
+	sealos add --nodes x.x.x.x --dry-run
 `
 
 // addCmd represents the add command
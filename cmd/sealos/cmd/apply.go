@@ -18,18 +18,31 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/labring/sealos/pkg/apply"
+	"github.com/labring/sealos/pkg/apply/processor"
 	"github.com/labring/sealos/pkg/utils/logger"
 )
 
 var clusterFile string
 
+const exampleApply = `
+apply a Clusterfile:
+	sealos apply -f Clusterfile
+
+preview the changes without touching any host:
+	sealos apply -f Clusterfile --dry-run
+	sealos apply -f Clusterfile --dry-run -o json
+
+resume from the stages completed by the last failed run:
+	sealos apply -f Clusterfile --resume
+`
+
 func newApplyCmd() *cobra.Command {
 	applyArgs := &apply.Args{}
 	// applyCmd represents the apply command
 	var applyCmd = &cobra.Command{
 		Use:     "apply",
 		Short:   "Run cloud images within a kubernetes cluster with Clusterfile",
-		Example: `sealos apply -f Clusterfile`,
+		Example: exampleApply,
 		Args:    cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			applier, err := apply.NewApplierFromFile(cmd, clusterFile, applyArgs)
@@ -45,5 +58,6 @@ func newApplyCmd() *cobra.Command {
 	setRequireBuildahAnnotation(applyCmd)
 	applyCmd.Flags().StringVarP(&clusterFile, "Clusterfile", "f", "Clusterfile", "apply a kubernetes cluster")
 	applyArgs.RegisterFlags(applyCmd.Flags())
+	applyCmd.Flags().BoolVar(&processor.Resume, "resume", false, "skip the pipeline stages, and the hosts of the Join and RunGuest stages, completed by the last failed run")
 	return applyCmd
 }
@@ -0,0 +1,132 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/labring/sealos/pkg/backup"
+	"github.com/labring/sealos/pkg/runtime"
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+	"github.com/labring/sealos/pkg/utils/confirm"
+	"github.com/labring/sealos/pkg/utils/logger"
+)
+
+func newBackupCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "backup",
+		Short: "Backup and restore the etcd of cluster",
+		Long: `Take etcd snapshots on master0 and restore the cluster from them, the snapshots are stored
+along with a copy of the Clusterfile and the pki dir in the backups dir of the cluster.`,
+	}
+	cmd.PersistentFlags().StringVarP(&clusterName, "cluster", "c", "default", "name of cluster to applied exec action")
+	cmd.AddCommand(newBackupCreateCmd())
+	cmd.AddCommand(newBackupListCmd())
+	cmd.AddCommand(newBackupRestoreCmd())
+	return cmd
+}
+
+func newBackupCreateCmd() *cobra.Command {
+	var name string
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Take an etcd snapshot of the cluster",
+		Example: `
+take a snapshot with a generated name:
+	sealos backup create
+take a snapshot named before-upgrade:
+	sealos backup create --name before-upgrade`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			em, cluster, err := getEtcdManager(clusterName)
+			if err != nil {
+				return err
+			}
+			b, err := backup.Create(cluster, name, em)
+			if err != nil {
+				return err
+			}
+			logger.Info("backup %s is saved to %s", b.Name, backup.Dir(b.Cluster, b.Name))
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&name, "name", "", "name of the backup, default is the cluster name with current time")
+	return cmd
+}
+
+func newBackupListCmd() *cobra.Command {
+	var output string
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the backups of the cluster",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			list, err := backup.List(clusterName)
+			if err != nil {
+				return err
+			}
+			return backup.Print(cmd.OutOrStdout(), list, output)
+		},
+	}
+	cmd.Flags().StringVarP(&output, "output", "o", backup.OutputTable, fmt.Sprintf("output format, one of %s, %s, %s", backup.OutputTable, backup.OutputJSON, backup.OutputYAML))
+	return cmd
+}
+
+func newBackupRestoreCmd() *cobra.Command {
+	var force bool
+	cmd := &cobra.Command{
+		Use:   "restore NAME",
+		Short: "Restore the etcd of cluster from a backup",
+		Long: `Restore the etcd of cluster from a backup, etcd and api-server are stopped on all masters
+while restoring, the current data dir of etcd is kept with a timestamp suffix.`,
+		Example: `
+restore from the backup named before-upgrade:
+	sealos backup restore before-upgrade`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			b, err := backup.Get(clusterName, args[0])
+			if err != nil {
+				return err
+			}
+			if !force {
+				prompt := fmt.Sprintf("the cluster %s will be unavailable while restoring from backup %s, are you sure?", clusterName, b.Name)
+				if pass, err := confirm.Confirm(prompt, "you have canceled to restore the cluster !"); err != nil || !pass {
+					return err
+				}
+			}
+			em, cluster, err := getEtcdManager(clusterName)
+			if err != nil {
+				return err
+			}
+			return backup.Restore(cluster, b, em)
+		},
+	}
+	cmd.Flags().BoolVarP(&force, "force", "f", false, "restore without confirmation")
+	return cmd
+}
+
+func getEtcdManager(clusterName string) (runtime.EtcdManager, *v2.Cluster, error) {
+	rt, cluster, err := getClusterRuntime(clusterName)
+	if err != nil {
+		return nil, nil, err
+	}
+	em, ok := rt.(runtime.EtcdManager)
+	if !ok {
+		return nil, nil, fmt.Errorf("%s does not support backup and restore", cluster.GetDistribution())
+	}
+	return em, cluster, nil
+}
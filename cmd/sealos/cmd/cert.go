@@ -21,10 +21,12 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/labring/sealos/pkg/apply/processor"
+	"github.com/labring/sealos/pkg/cert"
 	"github.com/labring/sealos/pkg/clusterfile"
 	"github.com/labring/sealos/pkg/constants"
 	"github.com/labring/sealos/pkg/runtime"
 	"github.com/labring/sealos/pkg/runtime/factory"
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
 	fileutils "github.com/labring/sealos/pkg/utils/file"
 	"github.com/labring/sealos/pkg/utils/logger"
 )
@@ -38,8 +40,8 @@ func newCertCmd() *cobra.Command {
 		Long: `Add domain or ip in certs:
     you had better backup old certs first.
 	sealos cert --alt-names sealos.io,10.103.97.2,127.0.0.1,localhost
-    using "openssl x509 -noout -text -in apiserver.crt" to check the cert
-	will update cluster API server cert, you need to restart your API server manually after using sealos cert.
+    using "sealos cert check" to check the certs
+	will update cluster API server cert, the API servers are restarted one by one after using sealos cert.
 
     For example: add an EIP to cert.
     1. sealos cert --alt-names 39.105.169.253
@@ -47,49 +49,121 @@ func newCertCmd() *cobra.Command {
     3. kubectl get pod, to check if it works or not
 `,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			processor.SyncNewVersionConfig(clusterName)
-
-			clusterPath := constants.Clusterfile(clusterName)
-			pathResolver := constants.NewPathResolver(clusterName)
-
-			var runtimeConfigPath string
-
-			for _, f := range []string{
-				path.Join(pathResolver.ConfigsPath(), "kubeadm-init.yaml"),
-				path.Join(pathResolver.EtcPath(), "kubeadm-init.yaml"),
-				path.Join(pathResolver.ConfigsPath(), "k3s-init.yaml"),
-			} {
-				if fileutils.IsExist(f) {
-					runtimeConfigPath = f
-					break
-				}
-			}
-			if runtimeConfigPath == "" {
-				logger.Warn("cannot locate the default runtime config file")
-			}
-			var opts []clusterfile.OptionFunc
-			if runtimeConfigPath != "" {
-				opts = append(opts, clusterfile.WithCustomRuntimeConfigFiles([]string{runtimeConfigPath}))
-			}
-			cf := clusterfile.NewClusterFile(clusterPath, opts...)
-			if err := cf.Process(); err != nil {
+			cm, err := getCertManager(clusterName)
+			if err != nil || cm == nil {
 				return err
 			}
+			return cm.UpdateCertSANs(altNames)
+		},
+	}
+	cmd.PersistentFlags().StringVarP(&clusterName, "cluster", "c", "default", "name of cluster to applied exec action")
+	cmd.Flags().StringSliceVar(&altNames, "alt-names", []string{}, "add extra Subject Alternative Names for certs, domain or ip, eg. sealos.io or 10.103.97.2")
+	_ = cmd.MarkFlagRequired("alt-names")
+
+	cmd.AddCommand(newCertCheckCmd())
+	cmd.AddCommand(newCertRenewCmd())
+	return cmd
+}
 
-			rt, err := factory.New(cf.GetCluster(), cf.GetRuntimeConfig())
+func newCertCheckCmd() *cobra.Command {
+	var output string
+	cmd := &cobra.Command{
+		Use:   "check",
+		Short: "check the expiration of certs on every master",
+		Example: `
+check the expiration of all certs:
+	sealos cert check
+print the expiration in json:
+	sealos cert check -o json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cm, err := getCertManager(clusterName)
+			if err != nil || cm == nil {
+				return err
+			}
+			list, err := cm.CheckExpiration()
 			if err != nil {
-				return fmt.Errorf("create runtime failed: %v", err)
+				return err
 			}
-			if cm, ok := rt.(runtime.CertManager); ok {
-				logger.Info("using %s cert update implement", cf.GetCluster().GetDistribution())
-				return cm.UpdateCertSANs(altNames)
+			return cert.PrintExpirations(cmd.OutOrStdout(), list, output)
+		},
+	}
+	cmd.Flags().StringVarP(&output, "output", "o", cert.OutputTable, fmt.Sprintf("output format, one of %s, %s, %s", cert.OutputTable, cert.OutputJSON, cert.OutputYAML))
+	return cmd
+}
+
+func newCertRenewCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "renew",
+		Short: "renew all the leaf certs on every master",
+		Long: `Renew all the leaf certs and kubeconfig files signed by the existing CAs on every master,
+the control plane is restarted one master at a time and must be healthy before moving on to the next one,
+the kubeconfig of current host is updated once all masters are renewed.`,
+		Example: `
+renew all certs:
+	sealos cert renew
+renew all certs of cluster named mycluster:
+	sealos cert renew -c mycluster`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cm, err := getCertManager(clusterName)
+			if err != nil || cm == nil {
+				return err
 			}
-			return nil
+			return cm.Renew()
 		},
 	}
-	cmd.Flags().StringVarP(&clusterName, "cluster", "c", "default", "name of cluster to applied exec action")
-	cmd.Flags().StringSliceVar(&altNames, "alt-names", []string{}, "add extra Subject Alternative Names for certs, domain or ip, eg. sealos.io or 10.103.97.2")
-	_ = cmd.MarkFlagRequired("alt-names")
+}
 
-	return cmd
+// getCertManager returns nil if the runtime of cluster doesn't support managing certs.
+func getCertManager(clusterName string) (runtime.CertManager, error) {
+	rt, cluster, err := getClusterRuntime(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	cm, ok := rt.(runtime.CertManager)
+	if !ok {
+		logger.Warn("%s does not support managing certs", cluster.GetDistribution())
+		return nil, nil
+	}
+	logger.Info("using %s cert update implement", cluster.GetDistribution())
+	return cm, nil
+}
+
+// getClusterRuntime creates the runtime of an existing cluster with the runtime config it was installed with.
+func getClusterRuntime(clusterName string) (runtime.Interface, *v2.Cluster, error) {
+	processor.SyncNewVersionConfig(clusterName)
+
+	clusterPath := constants.Clusterfile(clusterName)
+	pathResolver := constants.NewPathResolver(clusterName)
+
+	var runtimeConfigPath string
+
+	for _, f := range []string{
+		path.Join(pathResolver.ConfigsPath(), "kubeadm-init.yaml"),
+		path.Join(pathResolver.EtcPath(), "kubeadm-init.yaml"),
+		path.Join(pathResolver.ConfigsPath(), "k3s-init.yaml"),
+	} {
+		if fileutils.IsExist(f) {
+			runtimeConfigPath = f
+			break
+		}
+	}
+	if runtimeConfigPath == "" {
+		logger.Warn("cannot locate the default runtime config file")
+	}
+	var opts []clusterfile.OptionFunc
+	if runtimeConfigPath != "" {
+		opts = append(opts, clusterfile.WithCustomRuntimeConfigFiles([]string{runtimeConfigPath}))
+	}
+	cf := clusterfile.NewClusterFile(clusterPath, opts...)
+	if err := cf.Process(); err != nil {
+		return nil, nil, err
+	}
+
+	rt, err := factory.New(cf.GetCluster(), cf.GetRuntimeConfig())
+	if err != nil {
+		return nil, nil, fmt.Errorf("create runtime failed: %v", err)
+	}
+	return rt, cf.GetCluster(), nil
 }
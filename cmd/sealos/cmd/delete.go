@@ -21,6 +21,7 @@ import (
 
 	"github.com/labring/sealos/pkg/apply"
 	"github.com/labring/sealos/pkg/apply/processor"
+	"github.com/labring/sealos/pkg/runtime"
 	"github.com/labring/sealos/pkg/utils/logger"
 )
 
@@ -39,12 +40,19 @@ delete masters and nodes:
 	sealos delete --masters x.x.x.x-x.x.x.y --nodes x.x.x.x-x.x.x.y
 
 Please note that sealos will delete your master if the --masters parameter is specified.
+Nodes are drained before they are removed, use --drain-timeout to change how long to wait
+for the pods to be evicted, --force-drain to delete the pods not managed by any controller as well,
+or --ignore-drain-errors to remove the nodes even if draining failed.
+
+preview the nodes to be removed without touching any host:
+	sealos delete --nodes x.x.x.x --dry-run -o json
 `
 
 // deleteCmd represents the delete command
 func newDeleteCmd() *cobra.Command {
 	deleteArgs := &apply.ScaleArgs{
 		Cluster: &apply.Cluster{},
+		Drain:   runtime.NewDefaultDrainOptions(),
 	}
 	var deleteCmd = &cobra.Command{
 		Use:     "delete",
@@ -52,8 +60,10 @@ func newDeleteCmd() *cobra.Command {
 		Args:    cobra.NoArgs,
 		Example: exampleDelete,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if err := processor.ConfirmDeleteNodes(); err != nil {
-				return err
+			if !deleteArgs.DryRun.Enabled {
+				if err := processor.ConfirmDeleteNodes(); err != nil {
+					return err
+				}
 			}
 			applier, err := apply.NewScaleApplierFromArgs(cmd, deleteArgs)
 			if err != nil {
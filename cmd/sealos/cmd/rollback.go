@@ -0,0 +1,98 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/labring/sealos/pkg/apply"
+	"github.com/labring/sealos/pkg/apply/processor"
+	"github.com/labring/sealos/pkg/clusterfile"
+	"github.com/labring/sealos/pkg/constants"
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+)
+
+var exampleRollback = `
+roll back the app of the last failed run to its previous revision:
+	sealos rollback
+roll back an app to its previous revision:
+	sealos rollback labring/helm
+roll back an app to the specified revision without confirmation:
+	sealos rollback labring/helm:v3.8.2 --to-revision 2 --force
+show the revisions of all apps:
+	sealos rollback --history
+`
+
+func newRollbackCmd() *cobra.Command {
+	rollbackArgs := &apply.RollbackArgs{
+		SSH: &apply.SSH{},
+	}
+	var history bool
+	var rollbackCmd = &cobra.Command{
+		Use:   "rollback [IMAGE]",
+		Short: "Roll back an app to a previous revision",
+		Long: `Re-mount a previous revision of an app recorded in the image history of the cluster and run its CMD again,
+the app is decided by the repository of the image, e.g. labring/helm of labring/helm:v3.8.2`,
+		Example: exampleRollback,
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var image string
+			if len(args) > 0 {
+				image = args[0]
+			}
+			if history {
+				return printImageHistory(os.Stdout, rollbackArgs.ClusterName, image)
+			}
+			applier, err := apply.NewApplierFromRollbackArgs(cmd, rollbackArgs, image)
+			if err != nil {
+				return err
+			}
+			return applier.Apply()
+		},
+	}
+	setRequireBuildahAnnotation(rollbackCmd)
+	rollbackArgs.RegisterFlags(rollbackCmd.Flags())
+	rollbackCmd.Flags().BoolVarP(&processor.ForceRollback, "force", "f", false, "roll back without confirmation")
+	rollbackCmd.Flags().BoolVar(&history, "history", false, "show the revisions of apps instead of rolling back")
+	return rollbackCmd
+}
+
+func printImageHistory(w io.Writer, clusterName, image string) error {
+	cf := clusterfile.NewClusterFile(constants.Clusterfile(clusterName))
+	if err := cf.Process(); err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "APP\tREVISION\tIMAGE\tIMAGE ID\tAPPLIED")
+	for _, h := range cf.GetCluster().Status.ImageHistory {
+		if image != "" && h.App != v2.ImageRepository(image) {
+			continue
+		}
+		for _, rev := range h.Revisions {
+			id := rev.ImageID
+			if len(id) > 12 {
+				id = id[:12]
+			}
+			fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\n", h.App, rev.Revision, rev.ImageName, id, rev.AppliedAt.Format(time.RFC3339))
+		}
+	}
+	return tw.Flush()
+}
@@ -64,10 +64,13 @@ func init() {
 			Message: "Cluster Management Commands:",
 			Commands: []*cobra.Command{
 				newApplyCmd(),
+				newBackupCmd(),
 				newCertCmd(),
 				newRunCmd(),
 				newResetCmd(),
+				newRollbackCmd(),
 				newStatusCmd(),
+				newUninstallCmd(),
 			},
 		},
 		{
@@ -16,28 +16,81 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
 
 	"github.com/labring/sealos/pkg/checker"
 	"github.com/labring/sealos/pkg/clusterfile"
-
-	"github.com/spf13/cobra"
 )
 
+const failOnNever = "never"
+
+var exampleStatus = `
+check the status of default cluster:
+	sealos status
+
+print the results in json and also fail on warnings, e.g. in a cron job:
+	sealos status -o json --fail-on warn
+
+run the extra checkers in a directory, scripts in its masters sub directory only run on masters:
+	sealos status --checker-dir /etc/sealos/checkers
+`
+
 // newStatusCmd
 func newStatusCmd() *cobra.Command {
+	var (
+		output      string
+		failOn      string
+		checkerDirs []string
+	)
 	checkCmd := &cobra.Command{
-		Use:   "status",
-		Short: "state of sealos",
-		Args:  cobra.NoArgs,
+		Use:     "status",
+		Short:   "state of sealos",
+		Args:    cobra.NoArgs,
+		Example: exampleStatus,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			cluster, err := clusterfile.GetClusterFromName(clusterName)
 			if err != nil {
 				return fmt.Errorf("get default cluster failed, %v", err)
 			}
-			list := []checker.Interface{checker.NewRegistryChecker(), checker.NewCRIShimChecker(), checker.NewCRICtlChecker(), checker.NewInitSystemChecker(), checker.NewNodeChecker(), checker.NewPodChecker(), checker.NewSvcChecker(), checker.NewClusterChecker()}
-			return checker.RunCheckList(list, cluster, checker.PhasePost)
+			list := checker.NewStatusReporters()
+			// checkers shipped in the cluster images run before the ones from command line
+			extra, err := checker.LoadScriptCheckers(append(checker.ImageCheckerDirs(cluster), checkerDirs...)...)
+			if err != nil {
+				return err
+			}
+			list = append(list, extra...)
+			report := checker.RunReporters(list, cluster)
+			if err = report.Print(os.Stdout, output); err != nil {
+				return err
+			}
+			if strings.EqualFold(failOn, failOnNever) {
+				return nil
+			}
+			status, err := checker.ParseStatus(failOn)
+			if err != nil {
+				return err
+			}
+			return report.Err(status)
+		},
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			switch output {
+			case checker.OutputTable, checker.OutputJSON, checker.OutputYAML:
+			default:
+				return fmt.Errorf("unsupported output format %s", output)
+			}
+			if !strings.EqualFold(failOn, failOnNever) {
+				_, err := checker.ParseStatus(failOn)
+				return err
+			}
+			return nil
 		},
 	}
 	checkCmd.Flags().StringVarP(&clusterName, "cluster", "c", "default", "name of cluster to applied status action")
+	checkCmd.Flags().StringVarP(&output, "output", "o", checker.OutputTable, "output format, one of table|json|yaml")
+	checkCmd.Flags().StringVar(&failOn, "fail-on", strings.ToLower(string(checker.StatusFail)), "exit with non-zero code if any check is as bad as the given status, one of warn|fail|never")
+	checkCmd.Flags().StringSliceVar(&checkerDirs, "checker-dir", nil, "directories of extra checker scripts, exit code 0 means PASS, 1 means WARN and others mean FAIL")
 	return checkCmd
 }
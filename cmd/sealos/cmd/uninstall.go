@@ -0,0 +1,57 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/labring/sealos/pkg/apply"
+	"github.com/labring/sealos/pkg/apply/processor"
+)
+
+var exampleUninstall = `
+uninstall an app from the default cluster:
+	sealos uninstall labring/helm:v3.8.2
+uninstall apps from the specified cluster without confirmation:
+	sealos uninstall -c mycluster labring/openebs:v3.4.0 labring/minio-operator:v4.5.5 --force
+
+the uninstall command of an app is read from the "sealos.io.uninstall" label of its image,
+it's executed on the first master in the working directory of the app, for example:
+	LABEL sealos.io.uninstall="helm uninstall openebs -n openebs"
+`
+
+func newUninstallCmd() *cobra.Command {
+	uninstallArgs := &apply.UninstallArgs{
+		SSH: &apply.SSH{},
+	}
+	var uninstallCmd = &cobra.Command{
+		Use:     "uninstall IMAGE [IMAGE...]",
+		Short:   "Uninstall apps from the cluster",
+		Long:    "Run the uninstall commands of the app images, then remove them from the cluster and delete their containers",
+		Example: exampleUninstall,
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			applier, err := apply.NewApplierFromUninstallArgs(cmd, uninstallArgs, args)
+			if err != nil {
+				return err
+			}
+			return applier.Apply()
+		},
+	}
+	setRequireBuildahAnnotation(uninstallCmd)
+	uninstallArgs.RegisterFlags(uninstallCmd.Flags())
+	uninstallCmd.Flags().BoolVarP(&processor.ForceUninstall, "force", "f", false, "uninstall apps without confirmation")
+	return uninstallCmd
+}
@@ -190,7 +190,7 @@ func (r *AccountReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		}
 	case pay.PaymentProcessing, pay.PaymentNotPaid:
 		return ctrl.Result{Requeue: true, RequeueAfter: time.Second}, nil
-	case pay.PaymentFailed, pay.PaymentExpired:
+	case pay.PaymentFailed, pay.PaymentExpired, pay.PaymentRefunded:
 		if err := r.Delete(ctx, payment); err != nil {
 			return ctrl.Result{}, fmt.Errorf("delete payment failed: %v", err)
 		}
@@ -1,11 +1,14 @@
 package v1
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/url"
 	"time"
 
+	"github.com/labring/sealos/controllers/pkg/code"
 	"github.com/patrickmn/go-cache"
 	"golang.org/x/net/publicsuffix"
 	netv1 "k8s.io/api/networking/v1"
@@ -79,6 +82,32 @@ func (i *IcpValidator) Query(rule *netv1.IngressRule) (*IcpResponse, error) {
 	return &response, nil
 }
 
+// Validate denies the hosts without ICP license.
+func (i *IcpValidator) Validate(_ context.Context, ing *netv1.Ingress, rule *netv1.IngressRule) (bool, error) {
+	if !i.enabled {
+		ilog.Info("icp is disabled, skip check icp", "ingress namespace", ing.Namespace, "ingress name", ing.Name, "rule host", rule.Host)
+		return false, nil
+	}
+	// check rule.host icp
+	icpRep, err := i.Query(rule)
+	if err != nil {
+		ilog.Error(err, "can not verify ingress host "+rule.Host+", icp query error")
+		return false, fmt.Errorf(code.MessageFormat, code.IngressWebhookInternalError, "can not verify ingress host "+rule.Host+", icp query error")
+	}
+	if icpRep.ErrorCode != 0 {
+		ilog.Error(err, "icp query error", "ingress namespace", ing.Namespace, "ingress name", ing.Name, "rule host", rule.Host, "icp error code", icpRep.ErrorCode, "icp reason", icpRep.Reason)
+		return false, fmt.Errorf(code.MessageFormat, code.IngressWebhookInternalError, icpRep.Reason)
+	}
+	// if icpRep.Result.SiteLicense is empty, return error, failed validate
+	if icpRep.Result.SiteLicense == "" {
+		ilog.Info("deny ingress host "+rule.Host+", icp query result is empty", "ingress namespace", ing.Namespace, "ingress name", ing.Name, "rule host", rule.Host, "icp result", icpRep.Result)
+		return false, fmt.Errorf(code.MessageFormat, code.IngressFailedIcpCheck, "icp query result is empty")
+	}
+	// pass icp check
+	ilog.Info("ingress host "+rule.Host+" pass checkIcp validate", "ingress namespace", ing.Namespace, "ingress name", ing.Name, "rule host", rule.Host, "icp result", icpRep.Result)
+	return false, nil
+}
+
 // genCacheTTL generates a cache TTL based on the response
 func genCacheTTL(rsp *IcpResponse) time.Duration {
 	// If the response is valid, and the site license is not empty, cache for 30 days
@@ -0,0 +1,304 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/labring/sealos/controllers/pkg/code"
+
+	netv1 "k8s.io/api/networking/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+// The names of the built-in validators of the ingress hosts.
+const (
+	CnameValidatorName     = "cname"
+	OwnerValidatorName     = "owner"
+	IcpValidatorName       = "icp"
+	TxtValidatorName       = "txt"
+	AllowListValidatorName = "allowlist"
+)
+
+// DefaultTxtRecordPrefix is the prefix of the TXT records verifying the ownership of the hosts,
+// e.g. _sealos-challenge.example.com for example.com.
+const DefaultTxtRecordPrefix = "_sealos-challenge"
+
+// HostValidator is a check of the hosts of the ingresses in the validator chain.
+type HostValidator interface {
+	// Validate checks the host of the rule. It returns true when the host is accepted and the
+	// remaining validators of the chain are skipped, false to go on with the next validator, and
+	// an error when the host is denied.
+	Validate(ctx context.Context, i *netv1.Ingress, rule *netv1.IngressRule) (bool, error)
+}
+
+// Resolver looks up the DNS records of the hosts, *net.Resolver implements it.
+type Resolver interface {
+	LookupCNAME(ctx context.Context, host string) (string, error)
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+// ValidatorDeps are what the validators may depend on.
+type ValidatorDeps struct {
+	// Reader lists the ingresses by the IngressHostIndex.
+	Reader   client.Reader
+	Resolver Resolver
+}
+
+// ValidatorFactory builds a validator from the config.
+type ValidatorFactory func(config *IngressConfig, deps ValidatorDeps) (HostValidator, error)
+
+var validatorFactories = map[string]ValidatorFactory{}
+
+// RegisterHostValidator registers a validator which can be enabled by its name in the config.
+func RegisterHostValidator(name string, factory ValidatorFactory) {
+	if _, ok := validatorFactories[name]; ok {
+		panic("host validator " + name + " is already registered")
+	}
+	validatorFactories[name] = factory
+}
+
+func init() {
+	RegisterHostValidator(CnameValidatorName, func(config *IngressConfig, deps ValidatorDeps) (HostValidator, error) {
+		return &CnameValidator{domain: config.Domain, resolver: deps.Resolver}, nil
+	})
+	RegisterHostValidator(OwnerValidatorName, func(_ *IngressConfig, deps ValidatorDeps) (HostValidator, error) {
+		return &OwnerValidator{reader: deps.Reader}, nil
+	})
+	RegisterHostValidator(IcpValidatorName, func(config *IngressConfig, _ ValidatorDeps) (HostValidator, error) {
+		if config.Icp.Endpoint == "" {
+			return nil, fmt.Errorf("icp endpoint is required")
+		}
+		return NewIcpValidator(true, config.Icp.Endpoint, config.Icp.Key), nil
+	})
+	RegisterHostValidator(TxtValidatorName, func(config *IngressConfig, deps ValidatorDeps) (HostValidator, error) {
+		prefix := config.Txt.RecordPrefix
+		if prefix == "" {
+			prefix = DefaultTxtRecordPrefix
+		}
+		return &TxtValidator{domain: config.Domain, recordPrefix: prefix, resolver: deps.Resolver}, nil
+	})
+	RegisterHostValidator(AllowListValidatorName, func(config *IngressConfig, _ ValidatorDeps) (HostValidator, error) {
+		return &AllowListValidator{allowList: config.AllowList}, nil
+	})
+}
+
+// IngressConfig configures the validation of the ingress hosts.
+type IngressConfig struct {
+	// Domain is the domain of the cloud, the hosts under it are checked by the owner validator only.
+	Domain string `json:"domain,omitempty"`
+	// Validators are the names of the validators of the chain in order.
+	Validators []string  `json:"validators,omitempty"`
+	Icp        IcpConfig `json:"icp,omitempty"`
+	Txt        TxtConfig `json:"txt,omitempty"`
+	// AllowList are the hosts each namespace may use without the other checks, e.g.
+	// {"ns-admin": ["*.example.com"]}, *.example.com matches the subdomains of example.com.
+	AllowList map[string][]string `json:"allowList,omitempty"`
+}
+
+type IcpConfig struct {
+	Endpoint string `json:"endpoint,omitempty"`
+	Key      string `json:"key,omitempty"`
+}
+
+type TxtConfig struct {
+	// RecordPrefix is the prefix of the TXT records, defaults to DefaultTxtRecordPrefix.
+	RecordPrefix string `json:"recordPrefix,omitempty"`
+}
+
+// NewIngressConfigFromEnv returns the config from the env: DOMAIN, ICP_ENABLED, ICP_ENDPOINT and
+// ICP_KEY, the validators are cname, owner and icp if it's enabled.
+func NewIngressConfigFromEnv() *IngressConfig {
+	config := &IngressConfig{
+		Domain:     os.Getenv("DOMAIN"),
+		Validators: []string{CnameValidatorName, OwnerValidatorName},
+		Icp: IcpConfig{
+			Endpoint: os.Getenv("ICP_ENDPOINT"),
+			Key:      os.Getenv("ICP_KEY"),
+		},
+	}
+	if os.Getenv("ICP_ENABLED") == "true" {
+		config.Validators = append(config.Validators, IcpValidatorName)
+	}
+	return config
+}
+
+// LoadFile overrides the config with the yaml file, e.g. mounted from a ConfigMap.
+func (c *IngressConfig) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := yaml.UnmarshalStrict(data, c); err != nil {
+		return fmt.Errorf("parse ingress config %s: %v", path, err)
+	}
+	return nil
+}
+
+// BuildValidators builds the validator chain of the config.
+func (c *IngressConfig) BuildValidators(deps ValidatorDeps) ([]HostValidator, error) {
+	if deps.Resolver == nil {
+		deps.Resolver = net.DefaultResolver
+	}
+	validators := make([]HostValidator, 0, len(c.Validators))
+	for _, name := range c.Validators {
+		name = strings.TrimSpace(name)
+		factory, ok := validatorFactories[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown host validator %q, available: %s", name, strings.Join(registeredValidators(), ", "))
+		}
+		v, err := factory(c, deps)
+		if err != nil {
+			return nil, fmt.Errorf("build host validator %s: %v", name, err)
+		}
+		validators = append(validators, v)
+	}
+	return validators, nil
+}
+
+func registeredValidators() []string {
+	names := make([]string, 0, len(validatorFactories))
+	for name := range validatorFactories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// isCloudHost returns whether the host is the domain of the cloud or a subdomain of it, a host
+// like evilcloud.io is not under cloud.io.
+func isCloudHost(host, domain string) bool {
+	return domain != "" && (host == domain || strings.HasSuffix(host, "."+domain))
+}
+
+// CnameValidator denies the hosts which are not a CNAME to the domain of the cloud.
+type CnameValidator struct {
+	domain   string
+	resolver Resolver
+}
+
+func (v *CnameValidator) Validate(ctx context.Context, i *netv1.Ingress, rule *netv1.IngressRule) (bool, error) {
+	// there is no domain to check the cname against if DOMAIN is not set
+	if v.domain == "" {
+		return false, nil
+	}
+	// check if ingress host is end with domain
+	if isCloudHost(rule.Host, v.domain) {
+		ilog.Info("ingress host is end with "+v.domain+", skip validate", "ingress namespace", i.Namespace, "ingress name", i.Name)
+		return false, nil
+	}
+
+	// get cname and check if it is cname to domain
+	cname, err := v.resolver.LookupCNAME(ctx, rule.Host)
+	if err != nil {
+		ilog.Error(err, "can not verify ingress host "+rule.Host+", lookup cname error")
+		return false, err
+	}
+	// remove last dot
+	cname = strings.TrimSuffix(cname, ".")
+
+	// if cname is not end with domain, return error
+	if !isCloudHost(cname, v.domain) {
+		ilog.Info("deny ingress host "+rule.Host+", cname is not end with "+v.domain, "ingress namespace", i.Namespace, "ingress name", i.Name, "cname", cname)
+		return false, fmt.Errorf(code.MessageFormat, code.IngressFailedCnameCheck, "can not verify ingress host "+rule.Host+", cname is not end with "+v.domain)
+	}
+	ilog.Info("ingress host "+rule.Host+" is cname to "+cname+", pass checkCname validate", "ingress namespace", i.Namespace, "ingress name", i.Name, "cname", cname)
+	return false, nil
+}
+
+// OwnerValidator denies the hosts used by the ingresses of other namespaces.
+type OwnerValidator struct {
+	reader client.Reader
+}
+
+func (v *OwnerValidator) Validate(ctx context.Context, i *netv1.Ingress, rule *netv1.IngressRule) (bool, error) {
+	iList := &netv1.IngressList{}
+	if err := v.reader.List(ctx, iList, client.MatchingFields{IngressHostIndex: rule.Host}); err != nil {
+		ilog.Error(err, "can not verify ingress host "+rule.Host+", list ingress error")
+		return false, fmt.Errorf(code.MessageFormat, code.IngressFailedOwnerCheck, err.Error())
+	}
+
+	for _, exitsIngress := range iList.Items {
+		if exitsIngress.Namespace != i.Namespace {
+			ilog.Info("ingress host "+rule.Host+" is owned by "+exitsIngress.Namespace+", failed validate", "ingress namespace", i.Namespace, "ingress name", i.Name)
+			return false, fmt.Errorf(code.MessageFormat, code.IngressFailedOwnerCheck, "ingress host "+rule.Host+" is owned by other user, you can not create ingress with same host.")
+		}
+	}
+	// pass owner check
+	ilog.Info("ingress host "+rule.Host+" pass checkOwner validate", "ingress namespace", i.Namespace, "ingress name", i.Name)
+	return false, nil
+}
+
+// TxtValidator denies the hosts whose owners haven't verified the namespace of the ingress, by a
+// TXT record like `_sealos-challenge.example.com TXT "ns-admin"` for the host example.com, or
+// *.example.com.
+type TxtValidator struct {
+	domain       string
+	recordPrefix string
+	resolver     Resolver
+}
+
+func (v *TxtValidator) Validate(ctx context.Context, i *netv1.Ingress, rule *netv1.IngressRule) (bool, error) {
+	if isCloudHost(rule.Host, v.domain) {
+		return false, nil
+	}
+	record := v.recordPrefix + "." + strings.TrimPrefix(rule.Host, "*.")
+	txts, err := v.resolver.LookupTXT(ctx, record)
+	if err != nil {
+		ilog.Info("deny ingress host "+rule.Host+", lookup txt error", "ingress namespace", i.Namespace, "ingress name", i.Name, "record", record, "error", err.Error())
+		return false, fmt.Errorf(code.MessageFormat, code.IngressFailedTxtCheck, "can not verify ingress host "+rule.Host+", add a TXT record "+record+" with the value "+i.Namespace)
+	}
+	for _, txt := range txts {
+		if strings.TrimSpace(txt) == i.Namespace {
+			ilog.Info("ingress host "+rule.Host+" pass checkTxt validate", "ingress namespace", i.Namespace, "ingress name", i.Name, "record", record)
+			return false, nil
+		}
+	}
+	ilog.Info("deny ingress host "+rule.Host+", txt record does not match the namespace", "ingress namespace", i.Namespace, "ingress name", i.Name, "record", record, "txt", txts)
+	return false, fmt.Errorf(code.MessageFormat, code.IngressFailedTxtCheck, "can not verify ingress host "+rule.Host+", the TXT record "+record+" is not "+i.Namespace)
+}
+
+// AllowListValidator accepts the hosts allowed for the namespace of the ingress, the remaining
+// validators are skipped for them.
+type AllowListValidator struct {
+	allowList map[string][]string
+}
+
+func (v *AllowListValidator) Validate(_ context.Context, i *netv1.Ingress, rule *netv1.IngressRule) (bool, error) {
+	for _, pattern := range v.allowList[i.Namespace] {
+		if matchHost(pattern, rule.Host) {
+			ilog.Info("ingress host "+rule.Host+" is allowed by "+pattern+", skip the remaining validate", "ingress namespace", i.Namespace, "ingress name", i.Name)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// matchHost returns whether the host matches the pattern, *.example.com matches the subdomains of
+// example.com at any level.
+func matchHost(pattern, host string) bool {
+	if strings.HasPrefix(pattern, "*.") {
+		return strings.HasSuffix(host, pattern[1:])
+	}
+	return pattern == host
+}
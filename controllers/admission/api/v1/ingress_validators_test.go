@@ -0,0 +1,178 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	netv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// fakeResolver resolves the records from the maps, so that the validators are tested offline.
+type fakeResolver struct {
+	cnames map[string]string
+	txts   map[string][]string
+}
+
+func (r *fakeResolver) LookupCNAME(_ context.Context, host string) (string, error) {
+	if cname, ok := r.cnames[host]; ok {
+		return cname, nil
+	}
+	return "", errors.New("no such host")
+}
+
+func (r *fakeResolver) LookupTXT(_ context.Context, name string) ([]string, error) {
+	if txts, ok := r.txts[name]; ok {
+		return txts, nil
+	}
+	return nil, errors.New("no such host")
+}
+
+func TestValidatorChain(t *testing.T) {
+	resolver := &fakeResolver{
+		cnames: map[string]string{
+			"cname.example.com": "abc.cloud.sealos.io.",
+			"other.example.com": "other.example.net.",
+			"evil.example.com":  "abc.evilcloud.sealos.io.",
+		},
+		txts: map[string][]string{
+			"_sealos-challenge.txt.example.com": {"ns-other", "ns-test"},
+			"_sealos-challenge.example.org":     {"ns-other"},
+		},
+	}
+	tests := []struct {
+		name       string
+		validators []string
+		host       string
+		// wantErr is the substring of the error, empty if the host is accepted
+		wantErr string
+	}{
+		{name: "cloud host", validators: []string{"cname", "txt"}, host: "abc.cloud.sealos.io"},
+		{name: "cloud domain", validators: []string{"cname", "txt"}, host: "cloud.sealos.io"},
+		{name: "lookalike of the cloud", validators: []string{"cname"}, host: "evilcloud.sealos.io", wantErr: "no such host"},
+		{name: "txt of lookalike of the cloud", validators: []string{"txt"}, host: "evilcloud.sealos.io", wantErr: "add a TXT record _sealos-challenge.evilcloud.sealos.io"},
+		{name: "cname to lookalike of the cloud", validators: []string{"cname"}, host: "evil.example.com", wantErr: "cname is not end with cloud.sealos.io"},
+		{name: "cname to the cloud", validators: []string{"cname"}, host: "cname.example.com"},
+		{name: "cname to other domain", validators: []string{"cname"}, host: "other.example.com", wantErr: "cname is not end with cloud.sealos.io"},
+		{name: "no cname", validators: []string{"cname"}, host: "none.example.com", wantErr: "no such host"},
+		{name: "txt of the namespace", validators: []string{"txt"}, host: "txt.example.com"},
+		{name: "txt of wildcard host", validators: []string{"txt"}, host: "*.example.org", wantErr: "is not ns-test"},
+		{name: "no txt", validators: []string{"txt"}, host: "none.example.com", wantErr: "add a TXT record _sealos-challenge.none.example.com"},
+		{name: "allowed host skips the remaining", validators: []string{"allowlist", "cname", "txt"}, host: "a.b.allowed.com"},
+		{name: "exact allowed host", validators: []string{"allowlist", "txt"}, host: "exact.com"},
+		{name: "host not allowed", validators: []string{"allowlist", "txt"}, host: "allowed.com", wantErr: "add a TXT record"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &IngressConfig{
+				Domain:     "cloud.sealos.io",
+				Validators: tt.validators,
+				AllowList:  map[string][]string{"ns-test": {"*.allowed.com", "exact.com"}},
+			}
+			validators, err := config.BuildValidators(ValidatorDeps{Resolver: resolver})
+			if err != nil {
+				t.Fatal(err)
+			}
+			ingress := &netv1.Ingress{ObjectMeta: metav1.ObjectMeta{Namespace: "ns-test", Name: "test"}}
+			err = validateRule(context.Background(), validators, ingress, &netv1.IngressRule{Host: tt.host})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validate %s: %v", tt.host, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validate %s: got %v, want %q", tt.host, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidatorChainWithoutDomain(t *testing.T) {
+	resolver := &fakeResolver{txts: map[string][]string{"_sealos-challenge.txt.example.com": {"ns-test"}}}
+	tests := []struct {
+		name       string
+		validators []string
+		host       string
+		wantErr    string
+	}{
+		{name: "cname is not checked", validators: []string{"cname"}, host: "none.example.com"},
+		{name: "txt of the namespace", validators: []string{"cname", "txt"}, host: "txt.example.com"},
+		{name: "txt is still checked", validators: []string{"cname", "txt"}, host: "none.example.com", wantErr: "add a TXT record"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &IngressConfig{Validators: tt.validators}
+			validators, err := config.BuildValidators(ValidatorDeps{Resolver: resolver})
+			if err != nil {
+				t.Fatal(err)
+			}
+			ingress := &netv1.Ingress{ObjectMeta: metav1.ObjectMeta{Namespace: "ns-test", Name: "test"}}
+			err = validateRule(context.Background(), validators, ingress, &netv1.IngressRule{Host: tt.host})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validate %s: %v", tt.host, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validate %s: got %v, want %q", tt.host, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIngressConfig(t *testing.T) {
+	t.Setenv("DOMAIN", "cloud.sealos.io")
+	t.Setenv("ICP_ENABLED", "true")
+	t.Setenv("ICP_ENDPOINT", "http://icp.example.com")
+	config := NewIngressConfigFromEnv()
+	if got := strings.Join(config.Validators, ","); got != "cname,owner,icp" {
+		t.Errorf("validators from env = %s", got)
+	}
+
+	file := filepath.Join(t.TempDir(), "ingress.yaml")
+	if err := os.WriteFile(file, []byte("validators: [owner, allowlist, txt]\ntxt:\n  recordPrefix: _verify\nallowList:\n  ns-test: ['*.example.com']\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.LoadFile(file); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(config.Validators, ","); got != "owner,allowlist,txt" {
+		t.Errorf("validators from file = %s", got)
+	}
+	if config.Domain != "cloud.sealos.io" || config.Txt.RecordPrefix != "_verify" || len(config.AllowList["ns-test"]) != 1 {
+		t.Errorf("config from file = %+v", config)
+	}
+
+	config.Validators = []string{"dns"}
+	if _, err := config.BuildValidators(ValidatorDeps{}); err == nil || !strings.Contains(err.Error(), `unknown host validator "dns"`) {
+		t.Errorf("build unknown validator: %v", err)
+	}
+	if err := os.WriteFile(file, []byte("validator: [owner]\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.LoadFile(file); err == nil {
+		t.Error("load unknown field: want error")
+	}
+}
@@ -19,14 +19,8 @@ package v1
 import (
 	"context"
 	"errors"
-	"fmt"
-	"net"
-	"os"
-	"strings"
 	"time"
 
-	"github.com/labring/sealos/controllers/pkg/code"
-
 	netv1 "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -64,10 +58,13 @@ func (m *IngressMutator) Default(_ context.Context, _ runtime.Object) error {
 
 type IngressValidator struct {
 	client.Client
-	domain string
-	cache  cache.Cache
+	cache cache.Cache
 
-	IcpValidator *IcpValidator
+	// Config configures the validator chain of the hosts, defaults to NewIngressConfigFromEnv.
+	Config *IngressConfig
+	// Resolver looks up the DNS records of the hosts, defaults to net.DefaultResolver.
+	Resolver   Resolver
+	validators []HostValidator
 }
 
 const IngressHostIndex = "host"
@@ -75,19 +72,19 @@ const IngressHostIndex = "host"
 func (v *IngressValidator) SetupWithManager(mgr ctrl.Manager) error {
 	ilog.Info("starting webhook cache map")
 
-	iv := IngressValidator{
-		Client: mgr.GetClient(),
-		domain: os.Getenv("DOMAIN"),
-		cache:  mgr.GetCache(),
-
-		IcpValidator: NewIcpValidator(
-			os.Getenv("ICP_ENABLED") == "true",
-			os.Getenv("ICP_ENDPOINT"),
-			os.Getenv("ICP_KEY"),
-		),
+	v.Client = mgr.GetClient()
+	v.cache = mgr.GetCache()
+	if v.Config == nil {
+		v.Config = NewIngressConfigFromEnv()
+	}
+	validators, err := v.Config.BuildValidators(ValidatorDeps{Reader: v.cache, Resolver: v.Resolver})
+	if err != nil {
+		return err
 	}
+	v.validators = validators
+	ilog.Info("ingress host validators", "validators", v.Config.Validators)
 
-	err := iv.cache.IndexField(
+	err = v.cache.IndexField(
 		context.Background(),
 		&netv1.Ingress{},
 		IngressHostIndex,
@@ -106,7 +103,7 @@ func (v *IngressValidator) SetupWithManager(mgr ctrl.Manager) error {
 
 	return builder.WebhookManagedBy(mgr).
 		For(&netv1.Ingress{}).
-		WithValidator(&iv).
+		WithValidator(v).
 		Complete()
 }
 
@@ -160,87 +157,26 @@ func (v *IngressValidator) validate(ctx context.Context, i *netv1.Ingress) error
 		return nil
 	}
 
-	checks := []func(*netv1.Ingress, *netv1.IngressRule) error{
-		v.checkCname,
-		v.checkOwner,
-		v.checkIcp,
-	}
-
 	for _, rule := range i.Spec.Rules {
-		for _, check := range checks {
-			if err := check(i, &rule); err != nil {
-				return err
-			}
+		if err := validateRule(ctx, v.validators, i, &rule); err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
 
-func (v *IngressValidator) checkCname(i *netv1.Ingress, rule *netv1.IngressRule) error {
-	// check if ingress host is end with domain
-	if strings.HasSuffix(rule.Host, v.domain) {
-		ilog.Info("ingress host is end with "+v.domain+", skip validate", "ingress namespace", i.Namespace, "ingress name", i.Name)
-		return nil
-	}
-
-	// get cname and check if it is cname to domain
-	cname, err := net.LookupCNAME(rule.Host)
-	if err != nil {
-		ilog.Error(err, "can not verify ingress host "+rule.Host+", lookup cname error")
-		return err
-	}
-	// remove last dot
-	cname = strings.TrimSuffix(cname, ".")
-
-	// if cname is not end with domain, return error
-	if !strings.HasSuffix(cname, v.domain) {
-		ilog.Info("deny ingress host "+rule.Host+", cname is not end with "+v.domain, "ingress namespace", i.Namespace, "ingress name", i.Name, "cname", cname)
-		return fmt.Errorf(code.MessageFormat, code.IngressFailedCnameCheck, "can not verify ingress host "+rule.Host+", cname is not end with "+v.domain)
-	}
-	ilog.Info("ingress host "+rule.Host+" is cname to "+cname+", pass checkCname validate", "ingress namespace", i.Namespace, "ingress name", i.Name, "cname", cname)
-	return nil
-}
-
-func (v *IngressValidator) checkOwner(i *netv1.Ingress, rule *netv1.IngressRule) error {
-	iList := &netv1.IngressList{}
-	if err := v.cache.List(context.Background(), iList, client.MatchingFields{IngressHostIndex: rule.Host}); err != nil {
-		ilog.Error(err, "can not verify ingress host "+rule.Host+", list ingress error")
-		return fmt.Errorf(code.MessageFormat, code.IngressFailedOwnerCheck, err.Error())
-	}
-
-	for _, exitsIngress := range iList.Items {
-		if exitsIngress.Namespace != i.Namespace {
-			ilog.Info("ingress host "+rule.Host+" is owned by "+i.Namespace+", failed validate", "ingress namespace", i.Namespace, "ingress name", i.Name)
-			return fmt.Errorf(code.MessageFormat, code.IngressFailedOwnerCheck, "ingress host "+rule.Host+" is owned by other user, you can not create ingress with same host.")
+// validateRule runs the validators of the chain on the host of the rule in order, until one of
+// them denies or accepts it.
+func validateRule(ctx context.Context, validators []HostValidator, i *netv1.Ingress, rule *netv1.IngressRule) error {
+	for _, validator := range validators {
+		accepted, err := validator.Validate(ctx, i, rule)
+		if err != nil {
+			return err
+		}
+		if accepted {
+			return nil
 		}
 	}
-	// pass owner check
-	ilog.Info("ingress host "+rule.Host+" pass checkOwner validate", "ingress namespace", i.Namespace, "ingress name", i.Name)
-	return nil
-}
-
-func (v *IngressValidator) checkIcp(i *netv1.Ingress, rule *netv1.IngressRule) error {
-	if !v.IcpValidator.enabled {
-		ilog.Info("icp is disabled, skip check icp", "ingress namespace", i.Namespace, "ingress name", i.Name, "rule host", rule.Host)
-		return nil
-	}
-	// check rule.host icp
-	icpRep, err := v.IcpValidator.Query(rule)
-	if err != nil {
-		ilog.Error(err, "can not verify ingress host "+rule.Host+", icp query error")
-		return fmt.Errorf(code.MessageFormat, code.IngressWebhookInternalError, "can not verify ingress host "+rule.Host+", icp query error")
-	}
-	if icpRep.ErrorCode != 0 {
-		ilog.Error(err, "icp query error", "ingress namespace", i.Namespace, "ingress name", i.Name, "rule host", rule.Host, "icp error code", icpRep.ErrorCode, "icp reason", icpRep.Reason)
-		return fmt.Errorf(code.MessageFormat, code.IngressWebhookInternalError, icpRep.Reason)
-	}
-	// if icpRep.Result.SiteLicense is empty, return error, failed validate
-	if icpRep.Result.SiteLicense == "" {
-		ilog.Info("deny ingress host "+rule.Host+", icp query result is empty", "ingress namespace", i.Namespace, "ingress name", i.Name, "rule host", rule.Host, "icp result", icpRep.Result)
-		return fmt.Errorf(code.MessageFormat, code.IngressFailedIcpCheck, "icp query result is empty")
-	}
-	// pass icp check
-	ilog.Info("ingress host "+rule.Host+" pass checkIcp validate", "ingress namespace", i.Namespace, "ingress name", i.Name, "rule host", rule.Host, "icp result", icpRep.Result)
 	return nil
 }
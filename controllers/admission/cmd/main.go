@@ -18,8 +18,8 @@ package main
 
 import (
 	"flag"
-
 	"os"
+	"strings"
 
 	v1 "github.com/labring/sealos/controllers/admission/api/v1"
 
@@ -52,11 +52,17 @@ func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var ingressConfigFile string
+	var ingressValidators string
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.StringVar(&ingressConfigFile, "ingress-config", "",
+		"The yaml config file of the ingress host validators, e.g. mounted from a ConfigMap, it overrides the env.")
+	flag.StringVar(&ingressValidators, "ingress-validators", "",
+		"The comma separated ingress host validators in order, e.g. owner,allowlist,txt, it overrides the config file.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -89,7 +95,20 @@ func main() {
 		os.Exit(1)
 	}
 
-	if (&v1.IngressValidator{}).SetupWithManager(mgr) != nil {
+	ingressConfig := v1.NewIngressConfigFromEnv()
+	if _, err := os.Stat(ingressConfigFile); ingressConfigFile != "" && os.IsNotExist(err) {
+		// the ConfigMap is optional
+		setupLog.Info("ingress config not found, use the env", "file", ingressConfigFile)
+	} else if ingressConfigFile != "" {
+		if err := ingressConfig.LoadFile(ingressConfigFile); err != nil {
+			setupLog.Error(err, "unable to load ingress config")
+			os.Exit(1)
+		}
+	}
+	if ingressValidators != "" {
+		ingressConfig.Validators = strings.Split(ingressValidators, ",")
+	}
+	if err := (&v1.IngressValidator{Config: ingressConfig}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create ingress validator webhook")
 		os.Exit(1)
 	}
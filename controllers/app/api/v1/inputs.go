@@ -0,0 +1,336 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	// placeholderPrefix starts the placeholders and functions of the templates, e.g. ${{ random(8) }}
+	placeholderPrefix = "${{"
+	// secretLength is the length of the generated secrets, unless the bounds of the input don't allow it
+	secretLength  = 16
+	secretLetters = "abcdefghijklmnopqrstuvwxyz0123456789"
+)
+
+// Validate checks the definition of the input.
+func (in InputData) Validate() error {
+	switch in.Type {
+	case InputDataTypeString, InputDataTypeSecret, InputDataTypeNumber, InputDataTypeBoolean:
+		if len(in.Options) > 0 {
+			return fmt.Errorf("options are only allowed for choice inputs")
+		}
+	case InputDataTypeChoice:
+		if len(in.Options) == 0 {
+			return fmt.Errorf("choice input must have options")
+		}
+	default:
+		return fmt.Errorf("unsupported type %q", in.Type)
+	}
+	if in.Pattern != "" {
+		if in.Type != InputDataTypeString && in.Type != InputDataTypeSecret {
+			return fmt.Errorf("pattern is only allowed for string and secret inputs")
+		}
+		if _, err := regexp.Compile(in.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern: %v", err)
+		}
+	}
+	if in.Min != nil || in.Max != nil {
+		if in.Type == InputDataTypeBoolean || in.Type == InputDataTypeChoice {
+			return fmt.Errorf("min and max are not allowed for %s inputs", in.Type)
+		}
+		if in.Min != nil && in.Max != nil && *in.Min > *in.Max {
+			return fmt.Errorf("min %d is greater than max %d", *in.Min, *in.Max)
+		}
+	}
+	// defaults with functions are resolved by the clients
+	if in.Default != "" && !strings.Contains(in.Default, placeholderPrefix) {
+		if err := in.ValidateValue(in.Default); err != nil {
+			return fmt.Errorf("invalid default: %v", err)
+		}
+	}
+	return nil
+}
+
+// ValidateValue checks the value against the type and the constraints of the input, control
+// characters like newlines are rejected. The values are put into the manifests as yaml scalars
+// when the instance is rendered, so they can't change the structure of the manifests.
+func (in InputData) ValidateValue(v string) error {
+	for _, r := range v {
+		if unicode.IsControl(r) {
+			return fmt.Errorf("control characters are not allowed")
+		}
+	}
+	switch in.Type {
+	case InputDataTypeNumber:
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", v)
+		}
+		if in.Min != nil && f < float64(*in.Min) {
+			return fmt.Errorf("%s is less than %d", v, *in.Min)
+		}
+		if in.Max != nil && f > float64(*in.Max) {
+			return fmt.Errorf("%s is greater than %d", v, *in.Max)
+		}
+	case InputDataTypeBoolean:
+		if v != "true" && v != "false" {
+			return fmt.Errorf("%q is not true or false", v)
+		}
+	case InputDataTypeChoice:
+		for _, o := range in.Options {
+			if v == o {
+				return nil
+			}
+		}
+		return fmt.Errorf("%q is not one of %s", v, strings.Join(in.Options, ", "))
+	default:
+		length := int64(len([]rune(v)))
+		if in.Min != nil && length < *in.Min {
+			return fmt.Errorf("length %d is less than %d", length, *in.Min)
+		}
+		if in.Max != nil && length > *in.Max {
+			return fmt.Errorf("length %d is greater than %d", length, *in.Max)
+		}
+		if in.Pattern != "" {
+			re, err := regexp.Compile(in.Pattern)
+			if err != nil {
+				return fmt.Errorf("invalid pattern: %v", err)
+			}
+			if !re.MatchString(v) {
+				return fmt.Errorf("does not match pattern %s", in.Pattern)
+			}
+		}
+	}
+	return nil
+}
+
+// Validate checks the definitions of the inputs.
+func (in Inputs) Validate() error {
+	var errs []string
+	for k, input := range in {
+		if err := input.Validate(); err != nil {
+			errs = append(errs, fmt.Sprintf("input %s: %v", k, err))
+		}
+	}
+	return joinErrors(errs)
+}
+
+// Resolve checks the values of the user against the inputs, and returns the values of all the
+// inputs with the defaults filled in.
+func (in Inputs) Resolve(values map[string]string) (map[string]string, error) {
+	var errs []string
+	for k := range values {
+		if _, ok := in[k]; !ok {
+			errs = append(errs, fmt.Sprintf("input %s is not defined by the template", k))
+		}
+	}
+	ret := make(map[string]string, len(in))
+	for k, input := range in {
+		v, ok := values[k]
+		if !ok || v == "" {
+			v = input.Default
+			if strings.Contains(v, placeholderPrefix) {
+				// the default depends on the client, e.g. a random password
+				v = ""
+			}
+		}
+		if v == "" {
+			if input.Required || input.Type == InputDataTypeSecret {
+				errs = append(errs, fmt.Sprintf("input %s is required", k))
+			}
+			ret[k] = ""
+			continue
+		}
+		if err := input.ValidateValue(v); err != nil {
+			errs = append(errs, fmt.Sprintf("input %s: %v", k, err))
+			continue
+		}
+		ret[k] = v
+	}
+	if err := joinErrors(errs); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// GenerateSecrets returns the values with random values for the omitted secret inputs, and
+// whether any is generated. The secrets are kept in the values, so that rendering the template
+// again gives the same resources.
+func (in Inputs) GenerateSecrets(values map[string]string) (map[string]string, bool, error) {
+	generated := false
+	for k, input := range in {
+		if input.Type != InputDataTypeSecret || values[k] != "" {
+			continue
+		}
+		length := int64(secretLength)
+		if input.Min != nil && length < *input.Min {
+			length = *input.Min
+		}
+		if input.Max != nil && length > *input.Max {
+			length = *input.Max
+		}
+		secret, err := randomString(int(length))
+		if err != nil {
+			return nil, false, err
+		}
+		if values == nil {
+			values = map[string]string{}
+		}
+		values[k] = secret
+		generated = true
+	}
+	return values, generated, nil
+}
+
+// JSONSchema returns the JSON schema of the inputs, an object with a property for each input.
+func (in Inputs) JSONSchema() ([]byte, error) {
+	properties := make(map[string]interface{}, len(in))
+	required := []string{}
+	for k, input := range in {
+		p := map[string]interface{}{}
+		if input.Description != "" {
+			p["description"] = input.Description
+		}
+		if input.Default != "" && !strings.Contains(input.Default, placeholderPrefix) {
+			p["default"] = schemaValue(input, input.Default)
+		}
+		switch input.Type {
+		case InputDataTypeNumber:
+			p["type"] = "number"
+			if input.Min != nil {
+				p["minimum"] = *input.Min
+			}
+			if input.Max != nil {
+				p["maximum"] = *input.Max
+			}
+		case InputDataTypeBoolean:
+			p["type"] = "boolean"
+		case InputDataTypeChoice:
+			p["type"] = "string"
+			p["enum"] = input.Options
+		default:
+			p["type"] = "string"
+			if input.Type == InputDataTypeSecret {
+				p["format"] = "password"
+				p["writeOnly"] = true
+			}
+			if input.Pattern != "" {
+				p["pattern"] = input.Pattern
+			}
+			if input.Min != nil {
+				p["minLength"] = *input.Min
+			}
+			if input.Max != nil {
+				p["maxLength"] = *input.Max
+			}
+		}
+		properties[k] = p
+		// secrets are generated when omitted
+		if input.Required && input.Type != InputDataTypeSecret {
+			required = append(required, k)
+		}
+	}
+	sort.Strings(required)
+	return json.Marshal(map[string]interface{}{
+		"$schema":              "http://json-schema.org/draft-07/schema#",
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	})
+}
+
+// schemaValue returns the value as the JSON type of the input.
+func schemaValue(input InputData, v string) interface{} {
+	switch input.Type {
+	case InputDataTypeNumber:
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f
+		}
+	case InputDataTypeBoolean:
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return v
+}
+
+// ResolveDefaults returns the defaults of the template overridden by the ones of the instance.
+func ResolveDefaults(tmpl *Template, instance *Instance) (map[string]string, error) {
+	defaults := Defaults{}
+	for k, v := range tmpl.Spec.Defaults {
+		defaults[k] = v
+	}
+	for k, v := range instance.Spec.Defaults {
+		defaults[k] = v
+	}
+	var errs []string
+	ret := make(map[string]string, len(defaults))
+	for k, v := range defaults {
+		// functions like ${{ random(8) }} are resolved by the client when the instance is created,
+		// so that rendering the instance again gives the same resources
+		if strings.Contains(v.Value, placeholderPrefix) {
+			errs = append(errs, fmt.Sprintf("default %s must be resolved in the instance, got %q", k, v.Value))
+			continue
+		}
+		typ := InputDataType(v.Type)
+		if typ == "" {
+			typ = InputDataTypeString
+		}
+		if err := (InputData{Type: typ}).ValidateValue(v.Value); err != nil {
+			errs = append(errs, fmt.Sprintf("default %s: %v", k, err))
+			continue
+		}
+		ret[k] = v.Value
+	}
+	if err := joinErrors(errs); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	max := big.NewInt(int64(len(secretLetters)))
+	for i := range b {
+		idx, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = secretLetters[idx.Int64()]
+	}
+	return string(b), nil
+}
+
+func joinErrors(errs []string) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	sort.Strings(errs)
+	return errors.New(strings.Join(errs, "; "))
+}
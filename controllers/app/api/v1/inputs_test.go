@@ -0,0 +1,331 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func int64Ptr(i int64) *int64 {
+	return &i
+}
+
+func TestInputDataValidateValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   InputData
+		value   string
+		wantErr string
+	}{
+		{name: "string", input: InputData{Type: InputDataTypeString}, value: "hello world"},
+		{name: "empty string", input: InputData{Type: InputDataTypeString}, value: ""},
+		{name: "string with newline", input: InputData{Type: InputDataTypeString}, value: "a\nkind: Secret", wantErr: "control characters are not allowed"},
+		{name: "string with tab", input: InputData{Type: InputDataTypeString}, value: "a\tb", wantErr: "control characters are not allowed"},
+		{name: "string with carriage return", input: InputData{Type: InputDataTypeString}, value: "a\rb", wantErr: "control characters are not allowed"},
+		{name: "string with NUL", input: InputData{Type: InputDataTypeString}, value: "a\x00b", wantErr: "control characters are not allowed"},
+		{name: "string with C1 control", input: InputData{Type: InputDataTypeString}, value: "a\u0085b", wantErr: "control characters are not allowed"},
+		{name: "number with newline", input: InputData{Type: InputDataTypeNumber}, value: "1\n", wantErr: "control characters are not allowed"},
+		{name: "unicode string", input: InputData{Type: InputDataTypeString}, value: "你好"},
+
+		{name: "string min length", input: InputData{Type: InputDataTypeString, Min: int64Ptr(3)}, value: "abc"},
+		{name: "string too short", input: InputData{Type: InputDataTypeString, Min: int64Ptr(3)}, value: "ab", wantErr: "length 2 is less than 3"},
+		{name: "string max length", input: InputData{Type: InputDataTypeString, Max: int64Ptr(3)}, value: "abc"},
+		{name: "string too long", input: InputData{Type: InputDataTypeString, Max: int64Ptr(3)}, value: "abcd", wantErr: "length 4 is greater than 3"},
+		{name: "length counts runes", input: InputData{Type: InputDataTypeString, Max: int64Ptr(2)}, value: "你好"},
+		{name: "string is not compared as a number", input: InputData{Type: InputDataTypeString, Max: int64Ptr(3)}, value: "99"},
+		{name: "string matches pattern", input: InputData{Type: InputDataTypeString, Pattern: "^[a-z]+$"}, value: "abc"},
+		{name: "string does not match pattern", input: InputData{Type: InputDataTypeString, Pattern: "^[a-z]+$"}, value: "ABC", wantErr: "does not match pattern ^[a-z]+$"},
+		{name: "unanchored pattern", input: InputData{Type: InputDataTypeString, Pattern: "[0-9]"}, value: "a1b"},
+
+		{name: "secret", input: InputData{Type: InputDataTypeSecret, Min: int64Ptr(8)}, value: "password"},
+		{name: "secret too short", input: InputData{Type: InputDataTypeSecret, Min: int64Ptr(8)}, value: "pass", wantErr: "length 4 is less than 8"},
+		{name: "secret does not match pattern", input: InputData{Type: InputDataTypeSecret, Pattern: "[0-9]"}, value: "password", wantErr: "does not match pattern"},
+
+		{name: "integer", input: InputData{Type: InputDataTypeNumber}, value: "42"},
+		{name: "float", input: InputData{Type: InputDataTypeNumber}, value: "0.5"},
+		{name: "negative", input: InputData{Type: InputDataTypeNumber}, value: "-1"},
+		{name: "not a number", input: InputData{Type: InputDataTypeNumber}, value: "ten", wantErr: `"ten" is not a number`},
+		{name: "empty number", input: InputData{Type: InputDataTypeNumber}, value: "", wantErr: `"" is not a number`},
+		{name: "number min", input: InputData{Type: InputDataTypeNumber, Min: int64Ptr(1)}, value: "1"},
+		{name: "number less than min", input: InputData{Type: InputDataTypeNumber, Min: int64Ptr(1)}, value: "0.5", wantErr: "0.5 is less than 1"},
+		{name: "number max", input: InputData{Type: InputDataTypeNumber, Max: int64Ptr(10)}, value: "10"},
+		{name: "number greater than max", input: InputData{Type: InputDataTypeNumber, Max: int64Ptr(10)}, value: "11", wantErr: "11 is greater than 10"},
+		{name: "number is not compared by length", input: InputData{Type: InputDataTypeNumber, Max: int64Ptr(3)}, value: "1.25"},
+
+		{name: "true", input: InputData{Type: InputDataTypeBoolean}, value: "true"},
+		{name: "false", input: InputData{Type: InputDataTypeBoolean}, value: "false"},
+		{name: "capitalized boolean", input: InputData{Type: InputDataTypeBoolean}, value: "True", wantErr: `"True" is not true or false`},
+		{name: "numeric boolean", input: InputData{Type: InputDataTypeBoolean}, value: "1", wantErr: `"1" is not true or false`},
+
+		{name: "choice", input: InputData{Type: InputDataTypeChoice, Options: []string{"small", "large"}}, value: "large"},
+		{name: "not a choice", input: InputData{Type: InputDataTypeChoice, Options: []string{"small", "large"}}, value: "medium", wantErr: `"medium" is not one of small, large`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.input.ValidateValue(tt.value)
+			checkError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestInputDataValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   InputData
+		wantErr string
+	}{
+		{name: "string", input: InputData{Type: InputDataTypeString, Pattern: "^a", Min: int64Ptr(1), Max: int64Ptr(2)}},
+		{name: "choice", input: InputData{Type: InputDataTypeChoice, Options: []string{"a"}, Default: "a"}},
+		{name: "unsupported type", input: InputData{Type: "date"}, wantErr: `unsupported type "date"`},
+		{name: "empty type", input: InputData{}, wantErr: `unsupported type ""`},
+		{name: "choice without options", input: InputData{Type: InputDataTypeChoice}, wantErr: "choice input must have options"},
+		{name: "options of a string", input: InputData{Type: InputDataTypeString, Options: []string{"a"}}, wantErr: "options are only allowed for choice inputs"},
+		{name: "pattern of a number", input: InputData{Type: InputDataTypeNumber, Pattern: "^1"}, wantErr: "pattern is only allowed for string and secret inputs"},
+		{name: "invalid pattern", input: InputData{Type: InputDataTypeString, Pattern: "("}, wantErr: "invalid pattern"},
+		{name: "min of a boolean", input: InputData{Type: InputDataTypeBoolean, Min: int64Ptr(0)}, wantErr: "min and max are not allowed for boolean inputs"},
+		{name: "max of a choice", input: InputData{Type: InputDataTypeChoice, Options: []string{"a"}, Max: int64Ptr(1)}, wantErr: "min and max are not allowed for choice inputs"},
+		{name: "min greater than max", input: InputData{Type: InputDataTypeNumber, Min: int64Ptr(2), Max: int64Ptr(1)}, wantErr: "min 2 is greater than max 1"},
+		{name: "invalid default", input: InputData{Type: InputDataTypeNumber, Default: "ten"}, wantErr: "invalid default"},
+		{name: "default not in options", input: InputData{Type: InputDataTypeChoice, Options: []string{"a"}, Default: "b"}, wantErr: "invalid default"},
+		{name: "default with function", input: InputData{Type: InputDataTypeSecret, Min: int64Ptr(32), Default: "${{ random(8) }}"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkError(t, tt.input.Validate(), tt.wantErr)
+		})
+	}
+}
+
+func TestInputsResolve(t *testing.T) {
+	inputs := Inputs{
+		"name":     {Type: InputDataTypeString, Required: true},
+		"replicas": {Type: InputDataTypeNumber, Default: "1", Min: int64Ptr(1)},
+		"debug":    {Type: InputDataTypeBoolean},
+		"password": {Type: InputDataTypeSecret, Default: "${{ random(8) }}"},
+	}
+	tests := []struct {
+		name    string
+		values  map[string]string
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name:   "defaults are filled in",
+			values: map[string]string{"name": "demo", "password": "secret"},
+			want:   map[string]string{"name": "demo", "replicas": "1", "debug": "", "password": "secret"},
+		},
+		{
+			name:   "empty values use the defaults",
+			values: map[string]string{"name": "demo", "replicas": "", "password": "secret"},
+			want:   map[string]string{"name": "demo", "replicas": "1", "debug": "", "password": "secret"},
+		},
+		{
+			name:   "values override the defaults",
+			values: map[string]string{"name": "demo", "replicas": "3", "debug": "true", "password": "secret"},
+			want:   map[string]string{"name": "demo", "replicas": "3", "debug": "true", "password": "secret"},
+		},
+		{
+			name:    "required input",
+			values:  map[string]string{"password": "secret"},
+			wantErr: "input name is required",
+		},
+		{
+			// the function default is resolved by the client, the controller never sees it
+			name:    "secret is required after defaulting",
+			values:  map[string]string{"name": "demo"},
+			wantErr: "input password is required",
+		},
+		{
+			name:    "invalid value",
+			values:  map[string]string{"name": "demo", "replicas": "0", "password": "secret"},
+			wantErr: "input replicas: 0 is less than 1",
+		},
+		{
+			name:    "control characters",
+			values:  map[string]string{"name": "demo\nkind: Secret", "password": "secret"},
+			wantErr: "input name: control characters are not allowed",
+		},
+		{
+			name:    "undefined input",
+			values:  map[string]string{"name": "demo", "password": "secret", "extra": "x"},
+			wantErr: "input extra is not defined by the template",
+		},
+		{
+			name:    "errors are sorted",
+			values:  map[string]string{"debug": "yes"},
+			wantErr: `input debug: "yes" is not true or false; input name is required; input password is required`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := inputs.Resolve(tt.values)
+			checkError(t, err, tt.wantErr)
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expect %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestInputsGenerateSecrets(t *testing.T) {
+	inputs := Inputs{
+		"password": {Type: InputDataTypeSecret},
+		"short":    {Type: InputDataTypeSecret, Max: int64Ptr(8)},
+		"long":     {Type: InputDataTypeSecret, Min: int64Ptr(32)},
+		"given":    {Type: InputDataTypeSecret},
+		"name":     {Type: InputDataTypeString},
+	}
+	values, generated, err := inputs.GenerateSecrets(map[string]string{"given": "secret"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !generated {
+		t.Error("expect secrets to be generated")
+	}
+	for k, length := range map[string]int{"password": secretLength, "short": 8, "long": 32} {
+		if len(values[k]) != length {
+			t.Errorf("expect %s to have length %d, got %q", k, length, values[k])
+		}
+		if err := inputs[k].ValidateValue(values[k]); err != nil {
+			t.Errorf("generated %s is invalid: %v", k, err)
+		}
+	}
+	if values["given"] != "secret" {
+		t.Errorf("expect given secret to be kept, got %q", values["given"])
+	}
+	if _, ok := values["name"]; ok {
+		t.Errorf("expect no value for string input, got %q", values["name"])
+	}
+	// the generated secrets are accepted when resolving the values
+	if _, err := inputs.Resolve(values); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	_, generated, err = inputs.GenerateSecrets(values)
+	if err != nil || generated {
+		t.Errorf("expect nothing to be generated again, got %v, %v", generated, err)
+	}
+}
+
+func TestInputsJSONSchema(t *testing.T) {
+	inputs := Inputs{
+		"name":     {Type: InputDataTypeString, Description: "name of the app", Required: true, Pattern: "^[a-z]+$", Min: int64Ptr(1), Max: int64Ptr(63)},
+		"replicas": {Type: InputDataTypeNumber, Default: "1", Min: int64Ptr(1), Max: int64Ptr(5)},
+		"debug":    {Type: InputDataTypeBoolean, Default: "false", Required: true},
+		"size":     {Type: InputDataTypeChoice, Options: []string{"small", "large"}, Default: "small"},
+		"password": {Type: InputDataTypeSecret, Required: true, Default: "${{ random(8) }}"},
+	}
+	data, err := inputs.JSONSchema()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("invalid schema %s: %v", data, err)
+	}
+	want := map[string]interface{}{
+		"$schema": "http://json-schema.org/draft-07/schema#",
+		"type":    "object",
+		"properties": map[string]interface{}{
+			"name": map[string]interface{}{
+				"type":        "string",
+				"description": "name of the app",
+				"pattern":     "^[a-z]+$",
+				"minLength":   float64(1),
+				"maxLength":   float64(63),
+			},
+			"replicas": map[string]interface{}{
+				"type":    "number",
+				"default": float64(1),
+				"minimum": float64(1),
+				"maximum": float64(5),
+			},
+			"debug": map[string]interface{}{
+				"type":    "boolean",
+				"default": false,
+			},
+			"size": map[string]interface{}{
+				"type":    "string",
+				"enum":    []interface{}{"small", "large"},
+				"default": "small",
+			},
+			// the function default is left to the client
+			"password": map[string]interface{}{
+				"type":      "string",
+				"format":    "password",
+				"writeOnly": true,
+			},
+		},
+		// secrets are generated when omitted, so they are never required
+		"required":             []interface{}{"debug", "name"},
+		"additionalProperties": false,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expect schema %v, got %s", want, data)
+	}
+
+	data, err = Inputs{}.JSONSchema()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(string(data), `"required":[]`) {
+		t.Errorf("expect an empty required list, got %s", data)
+	}
+}
+
+func TestResolveDefaults(t *testing.T) {
+	tmpl := &Template{Spec: TemplateSpec{TemplateData: TemplateData{Defaults: Defaults{
+		"app_name": {Type: DefaultDataTypeString, Value: "demo"},
+		"port":     {Type: DefaultDataTypeNumber, Value: "80"},
+		"random":   {Type: DefaultDataTypeString, Value: "${{ random(8) }}"},
+	}}}}
+	instance := &Instance{Spec: InstanceSpec{TemplateData: TemplateData{Defaults: Defaults{
+		"random": {Type: DefaultDataTypeString, Value: "abcdefgh"},
+		"port":   {Value: "8080"},
+	}}}}
+	got, err := ResolveDefaults(tmpl, instance)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]string{"app_name": "demo", "port": "8080", "random": "abcdefgh"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expect %v, got %v", want, got)
+	}
+
+	_, err = ResolveDefaults(tmpl, &Instance{})
+	checkError(t, err, `default random must be resolved in the instance, got "${{ random(8) }}"`)
+
+	instance.Spec.Defaults["port"] = DefaultData{Type: DefaultDataTypeNumber, Value: "eighty"}
+	_, err = ResolveDefaults(tmpl, instance)
+	checkError(t, err, `default port: "eighty" is not a number`)
+}
+
+func checkError(t *testing.T, err error, wantErr string) {
+	t.Helper()
+	if wantErr == "" {
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		return
+	}
+	if err == nil || !strings.Contains(err.Error(), wantErr) {
+		t.Errorf("expect error containing %q, got %v", wantErr, err)
+	}
+}
@@ -18,20 +18,75 @@ package v1
 
 import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 )
 
+// TemplateReference refers to the Template an Instance is rendered from.
+type TemplateReference struct {
+	Name string `json:"name"`
+	// Namespace of the Template, defaults to the namespace of the Instance.
+	// Templates in other namespaces can only be used from the shared template namespace of the controller.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
 // InstanceSpec defines the desired state of Instance
 // +kubebuilder:validation:XValidation:rule="'app_name' in self.defaults",message="defaults must have app_name key"
 type InstanceSpec struct {
 	TemplateData `json:",inline"`
+
+	// TemplateRef is the Template whose manifests are rendered and applied by the controller.
+	// Instances without it only record the resources deployed by the client.
+	// +optional
+	TemplateRef *TemplateReference `json:"templateRef,omitempty"`
+	// Values are the user inputs, they are validated against the inputs of the Template.
+	// +optional
+	Values map[string]string `json:"values,omitempty"`
+}
+
+const (
+	// InstanceConditionRendered is true when the inputs are valid and the manifests are rendered.
+	InstanceConditionRendered = "Rendered"
+	// InstanceConditionReady is true when all the resources of the Instance are ready.
+	InstanceConditionReady = "Ready"
+)
+
+// ResourceStatus is the status of a resource applied for the Instance.
+type ResourceStatus struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	Ready      bool   `json:"ready"`
+	// +optional
+	Message string `json:"message,omitempty"`
 }
 
 // InstanceStatus defines the observed state of Instance
 type InstanceStatus struct {
+	// ObservedGeneration is the generation of the Instance the resources are applied for.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// TemplateGeneration is the generation of the Template the resources are rendered from.
+	// +optional
+	TemplateGeneration int64 `json:"templateGeneration,omitempty"`
+	// Ready is true when all the resources are ready.
+	// +optional
+	Ready bool `json:"ready,omitempty"`
+	// Resources are the resources applied for the Instance, the ones removed from the Template
+	// are deleted on upgrade.
+	// +optional
+	Resources []ResourceStatus `json:"resources,omitempty"`
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Template",type=string,JSONPath=".spec.templateRef.name"
+//+kubebuilder:printcolumn:name="Ready",type=boolean,JSONPath=".status.ready"
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=".metadata.creationTimestamp"
 
 // Instance is the Schema for the instances API
 type Instance struct {
@@ -51,6 +106,15 @@ type InstanceList struct {
 	Items           []Instance `json:"items"`
 }
 
+// TemplateKey returns the key of the Template the Instance refers to, TemplateRef must be set.
+func (in *Instance) TemplateKey() types.NamespacedName {
+	key := types.NamespacedName{Namespace: in.Spec.TemplateRef.Namespace, Name: in.Spec.TemplateRef.Name}
+	if key.Namespace == "" {
+		key.Namespace = in.Namespace
+	}
+	return key
+}
+
 func init() {
 	SchemeBuilder.Register(&Instance{}, &InstanceList{})
 }
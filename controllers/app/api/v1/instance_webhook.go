@@ -0,0 +1,139 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// log is for logging in this package.
+var instancelog = logf.Log.WithName("instance-resource")
+
+// SetupWebhookWithManager registers the webhooks of the instances, templateNamespace is the
+// namespace of the templates shared with all users.
+func (r *Instance) SetupWebhookWithManager(mgr ctrl.Manager, templateNamespace string) error {
+	w := &InstanceWebhook{Client: mgr.GetClient(), TemplateNamespace: templateNamespace}
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		WithDefaulter(w).
+		WithValidator(w).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-app-sealos-io-v1-instance,mutating=true,failurePolicy=fail,sideEffects=None,groups=app.sealos.io,resources=instances,verbs=create;update,versions=v1,name=minstance.kb.io,admissionReviewVersions=v1
+//+kubebuilder:webhook:path=/validate-app-sealos-io-v1-instance,mutating=false,failurePolicy=fail,sideEffects=None,groups=app.sealos.io,resources=instances,verbs=create;update,versions=v1,name=vinstance.kb.io,admissionReviewVersions=v1
+//+kubebuilder:object:generate=false
+
+// InstanceWebhook generates the omitted secrets of the instances rendered from templates, and
+// validates their values against the inputs of the templates.
+type InstanceWebhook struct {
+	client.Client
+	TemplateNamespace string
+}
+
+func (w *InstanceWebhook) Default(ctx context.Context, obj runtime.Object) error {
+	instance, ok := obj.(*Instance)
+	if !ok {
+		return errors.New("obj convert Instance is error")
+	}
+	if instance.Spec.TemplateRef == nil || !instance.DeletionTimestamp.IsZero() {
+		return nil
+	}
+	tmpl, err := w.getTemplate(ctx, instance)
+	if err != nil {
+		// rejected by the validator
+		return nil
+	}
+	values, generated, err := tmpl.Spec.Inputs.GenerateSecrets(instance.Spec.Values)
+	if err != nil {
+		return err
+	}
+	if generated {
+		instancelog.Info("generate secrets", "namespace", instance.Namespace, "name", instance.Name)
+		instance.Spec.Values = values
+	}
+	return nil
+}
+
+func (w *InstanceWebhook) ValidateCreate(ctx context.Context, obj runtime.Object) error {
+	instance, ok := obj.(*Instance)
+	if !ok {
+		return errors.New("obj convert Instance is error")
+	}
+	return w.validate(ctx, instance)
+}
+
+func (w *InstanceWebhook) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) error {
+	oldInstance, ok := oldObj.(*Instance)
+	if !ok {
+		return errors.New("obj convert Instance is error")
+	}
+	instance, ok := newObj.(*Instance)
+	if !ok {
+		return errors.New("obj convert Instance is error")
+	}
+	// the instance must stay updatable, e.g. to remove its finalizer, after the template changes
+	if !instance.DeletionTimestamp.IsZero() || equality.Semantic.DeepEqual(oldInstance.Spec, instance.Spec) {
+		return nil
+	}
+	return w.validate(ctx, instance)
+}
+
+func (w *InstanceWebhook) ValidateDelete(_ context.Context, _ runtime.Object) error {
+	return nil
+}
+
+func (w *InstanceWebhook) validate(ctx context.Context, instance *Instance) error {
+	if instance.Spec.TemplateRef == nil {
+		return nil
+	}
+	tmpl, err := w.getTemplate(ctx, instance)
+	if err != nil {
+		return err
+	}
+	if _, err := ResolveDefaults(tmpl, instance); err != nil {
+		return err
+	}
+	if _, err := tmpl.Spec.Inputs.Resolve(instance.Spec.Values); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (w *InstanceWebhook) getTemplate(ctx context.Context, instance *Instance) (*Template, error) {
+	key := instance.TemplateKey()
+	if key.Namespace != instance.Namespace && key.Namespace != w.TemplateNamespace {
+		return nil, fmt.Errorf("template %s is not in the namespace of the instance or the shared template namespace", key)
+	}
+	tmpl := &Template{}
+	if err := w.Get(ctx, key, tmpl); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("template %s not found", key)
+		}
+		return nil, err
+	}
+	return tmpl, nil
+}
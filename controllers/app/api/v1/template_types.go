@@ -29,8 +29,9 @@ const (
 type DefaultDataType string
 
 const (
-	DefaultDataTypeString = "string"
-	DefaultDataTypeNumber = "number"
+	DefaultDataTypeString  = "string"
+	DefaultDataTypeNumber  = "number"
+	DefaultDataTypeBoolean = "boolean"
 )
 
 type DefaultData struct {
@@ -45,6 +46,12 @@ type InputDataType string
 const (
 	InputDataTypeString = "string"
 	InputDataTypeNumber = "number"
+	// InputDataTypeBoolean is true or false.
+	InputDataTypeBoolean = "boolean"
+	// InputDataTypeChoice is one of the options.
+	InputDataTypeChoice = "choice"
+	// InputDataTypeSecret is a string like a password, a random one is generated when it's omitted.
+	InputDataTypeSecret = "secret"
 )
 
 type InputData struct {
@@ -52,6 +59,19 @@ type InputData struct {
 	Type        InputDataType `json:"type"`
 	Default     string        `json:"default,omitempty"`
 	Required    bool          `json:"required,omitempty"`
+	// Options are the allowed values of a choice input.
+	// +optional
+	Options []string `json:"options,omitempty"`
+	// Pattern is a regular expression the value of a string or secret input must match,
+	// it's not anchored, use ^ and $ to match the whole value.
+	// +optional
+	Pattern string `json:"pattern,omitempty"`
+	// Min is the minimum of a number input, or the minimum length of a string or secret input.
+	// +optional
+	Min *int64 `json:"min,omitempty"`
+	// Max is the maximum of a number input, or the maximum length of a string or secret input.
+	// +optional
+	Max *int64 `json:"max,omitempty"`
 }
 
 type Inputs map[string]InputData
@@ -74,10 +94,31 @@ type TemplateData struct {
 // +kubebuilder:validation:XValidation:rule="'app_name' in self.defaults",message="defaults must have app_name key"
 type TemplateSpec struct {
 	TemplateData `json:",inline"`
+
+	// Manifests are the resources of the template as multi-document yaml, the placeholders
+	// like ${{ defaults.app_name }}, ${{ inputs.api_key }} and ${{ SEALOS_CLOUD_DOMAIN }} are
+	// replaced when an Instance is rendered.
+	// +optional
+	Manifests string `json:"manifests,omitempty"`
 }
 
+const (
+	// TemplateConditionInputsValid is true when the inputs of the template are well defined.
+	TemplateConditionInputsValid = "InputsValid"
+)
+
 // TemplateStatus defines the observed state of Template
 type TemplateStatus struct {
+	// ObservedGeneration is the generation of the Template the status is computed for.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// InputsSchema is the JSON schema of the inputs, UIs can build the forms of the inputs from it.
+	// +optional
+	InputsSchema string `json:"inputsSchema,omitempty"`
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 //+kubebuilder:object:root=true
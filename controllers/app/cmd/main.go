@@ -32,6 +32,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	appv1 "github.com/labring/sealos/controllers/app/api/v1"
+	"github.com/labring/sealos/controllers/app/internal/controller"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -50,17 +51,26 @@ func init() {
 // Note: Add role here for controllers without real controller go file, with just CRDs.
 // +kubebuilder:rbac:groups=app.sealos.io,resources=apps,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=app.sealos.io,resources=templates,verbs=get;list;watch;create;update;patch;delete
-// +kubebuilder:rbac:groups=app.sealos.io,resources=instances,verbs=get;list;watch;create;update;patch;delete
+
+// platformVars are the variables of the platform available to the templates, and their defaults.
+var platformVars = map[string]string{
+	"SEALOS_CLOUD_DOMAIN":     "cloud.sealos.io",
+	"SEALOS_CERT_SECRET_NAME": "wildcard-cert",
+	"TEMPLATE_REPO_URL":       "https://github.com/labring-actions/templates",
+}
 
 func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var templateNamespace string
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.StringVar(&templateNamespace, "template-namespace", "template-frontend",
+		"The namespace of the templates shared with all users, instances can use the templates in it or in their own namespace.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -93,6 +103,37 @@ func main() {
 		os.Exit(1)
 	}
 
+	vars := make(map[string]string, len(platformVars))
+	for k, v := range platformVars {
+		if value := os.Getenv(k); value != "" {
+			v = value
+		}
+		vars[k] = v
+	}
+	if err = (&controller.InstanceReconciler{
+		Client:            mgr.GetClient(),
+		Scheme:            mgr.GetScheme(),
+		TemplateNamespace: templateNamespace,
+		PlatformVars:      vars,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "Instance")
+		os.Exit(1)
+	}
+	if err = (&controller.TemplateReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "Template")
+		os.Exit(1)
+	}
+	if os.Getenv("DISABLE_WEBHOOKS") == "true" {
+		setupLog.Info("disable all webhooks")
+	} else {
+		if err = (&appv1.Instance{}).SetupWebhookWithManager(mgr, templateNamespace); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Instance")
+			os.Exit(1)
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
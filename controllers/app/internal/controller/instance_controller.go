@@ -0,0 +1,407 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	appv1 "github.com/labring/sealos/controllers/app/api/v1"
+)
+
+const (
+	FinalizerName = "app.sealos.io/instance-finalizer"
+	// InstanceLabel is set on the resources of an Instance to its name, the template frontend
+	// lists the resources of an instance by it.
+	InstanceLabel = "cloud.sealos.io/deploy-on-sealos"
+	// fieldOwner is the field manager of the server-side apply of the resources
+	fieldOwner = "sealos-app-controller"
+	// templateRefIndex indexes the instances by the namespace/name of their template
+	templateRefIndex = "spec.templateRef"
+	// notReadyRequeueInterval is how often the readiness of the resources is checked until they are ready
+	notReadyRequeueInterval = 10 * time.Second
+)
+
+// InstanceReconciler renders the Template of an Instance with its inputs and applies the resources
+// into the namespace of the Instance.
+type InstanceReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	recorder record.EventRecorder
+	// TemplateNamespace is the namespace of the templates shared with all users, Instances can
+	// only use the templates in it or in their own namespace.
+	TemplateNamespace string
+	// PlatformVars are the variables of the platform available to all templates, e.g. SEALOS_CLOUD_DOMAIN.
+	PlatformVars map[string]string
+}
+
+// The resources which templates can contain, the controller creates them with its own permissions,
+// so kinds which could grant more permissions, like roles, are left out.
+//+kubebuilder:rbac:groups=app.sealos.io,resources=instances,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=app.sealos.io,resources=instances/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=app.sealos.io,resources=instances/finalizers,verbs=update
+//+kubebuilder:rbac:groups=app.sealos.io,resources=templates,verbs=get;list;watch
+//+kubebuilder:rbac:groups=app.sealos.io,resources=apps,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=core,resources=services;configmaps;secrets;persistentvolumeclaims;serviceaccounts,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+//+kubebuilder:rbac:groups=apps,resources=deployments;statefulsets,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=batch,resources=jobs;cronjobs,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=apps.kubeblocks.io,resources=clusters,verbs=get;list;watch;create;update;patch;delete
+
+func (r *InstanceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	instance := &appv1.Instance{}
+	if err := r.Get(ctx, req.NamespacedName, instance); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !instance.DeletionTimestamp.IsZero() {
+		if !controllerutil.ContainsFinalizer(instance, FinalizerName) {
+			return ctrl.Result{}, nil
+		}
+		if err := r.deleteResources(ctx, instance, instance.Status.Resources); err != nil {
+			return ctrl.Result{}, err
+		}
+		controllerutil.RemoveFinalizer(instance, FinalizerName)
+		return ctrl.Result{}, r.Update(ctx, instance)
+	}
+	if instance.Spec.TemplateRef == nil {
+		// the resources are deployed by the client, clean up the ones rendered
+		// before the templateRef was cleared
+		return ctrl.Result{}, r.releaseInstance(ctx, instance)
+	}
+	if controllerutil.AddFinalizer(instance, FinalizerName) {
+		if err := r.Update(ctx, instance); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// the secrets are generated by the webhook, unless it's disabled
+	if generated, err := r.generateSecrets(ctx, instance); err != nil || generated {
+		// the update of the instance triggers the next reconcile
+		return ctrl.Result{}, err
+	}
+
+	tmpl, objs, err := r.render(ctx, instance)
+	if err != nil {
+		logger.Error(err, "render instance failed")
+		r.recorder.Eventf(instance, corev1.EventTypeWarning, "RenderFailed", "%v", err)
+		meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+			Type:               appv1.InstanceConditionRendered,
+			Status:             metav1.ConditionFalse,
+			Reason:             "RenderFailed",
+			Message:            err.Error(),
+			ObservedGeneration: instance.Generation,
+		})
+		instance.Status.Ready = false
+		if updateErr := r.Status().Update(ctx, instance); updateErr != nil {
+			return ctrl.Result{}, updateErr
+		}
+		if isTerminal(err) {
+			// wait for the instance or the template to change
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+	meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+		Type:               appv1.InstanceConditionRendered,
+		Status:             metav1.ConditionTrue,
+		Reason:             "Rendered",
+		Message:            fmt.Sprintf("rendered %d resources from template %s/%s", len(objs), tmpl.Namespace, tmpl.Name),
+		ObservedGeneration: instance.Generation,
+	})
+
+	resources, applyErr := r.applyResources(ctx, instance, objs)
+	// delete the resources removed from the template, e.g. after an upgrade
+	if err := r.deleteResources(ctx, instance, removedResources(instance.Status.Resources, resources)); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	ready := applyErr == nil
+	notReady := 0
+	for _, res := range resources {
+		if !res.Ready {
+			ready = false
+			notReady++
+		}
+	}
+	instance.Status.ObservedGeneration = instance.Generation
+	instance.Status.TemplateGeneration = tmpl.Generation
+	instance.Status.Resources = resources
+	instance.Status.Ready = ready
+	readyCondition := metav1.Condition{
+		Type:               appv1.InstanceConditionReady,
+		Status:             metav1.ConditionTrue,
+		Reason:             "Ready",
+		Message:            "all resources are ready",
+		ObservedGeneration: instance.Generation,
+	}
+	if applyErr != nil {
+		readyCondition.Status, readyCondition.Reason, readyCondition.Message = metav1.ConditionFalse, "ApplyFailed", applyErr.Error()
+		r.recorder.Eventf(instance, corev1.EventTypeWarning, "ApplyFailed", "%v", applyErr)
+	} else if !ready {
+		readyCondition.Status, readyCondition.Reason = metav1.ConditionFalse, "NotReady"
+		readyCondition.Message = fmt.Sprintf("%d/%d resources are not ready", notReady, len(resources))
+	}
+	meta.SetStatusCondition(&instance.Status.Conditions, readyCondition)
+	if err := r.Status().Update(ctx, instance); err != nil {
+		return ctrl.Result{}, err
+	}
+	if applyErr != nil {
+		return ctrl.Result{}, applyErr
+	}
+	if !ready {
+		return ctrl.Result{RequeueAfter: notReadyRequeueInterval}, nil
+	}
+	return ctrl.Result{}, nil
+}
+
+// releaseInstance deletes the resources rendered from the template and drops the finalizer
+// of an instance which no longer refers to a template.
+func (r *InstanceReconciler) releaseInstance(ctx context.Context, instance *appv1.Instance) error {
+	if !controllerutil.ContainsFinalizer(instance, FinalizerName) {
+		return nil
+	}
+	if len(instance.Status.Resources) > 0 {
+		if err := r.deleteResources(ctx, instance, instance.Status.Resources); err != nil {
+			return err
+		}
+		instance.Status.Resources = nil
+		instance.Status.Ready = false
+		if err := r.Status().Update(ctx, instance); err != nil {
+			return err
+		}
+	}
+	controllerutil.RemoveFinalizer(instance, FinalizerName)
+	return r.Update(ctx, instance)
+}
+
+// terminalError is an error which retrying doesn't fix, e.g. invalid inputs.
+type terminalError struct {
+	error
+}
+
+func isTerminal(err error) bool {
+	_, ok := err.(terminalError)
+	return ok
+}
+
+// getTemplate gets the template of the instance.
+func (r *InstanceReconciler) getTemplate(ctx context.Context, instance *appv1.Instance) (*appv1.Template, error) {
+	key := instance.TemplateKey()
+	if key.Namespace != instance.Namespace && key.Namespace != r.TemplateNamespace {
+		return nil, terminalError{fmt.Errorf("template %s is not in the namespace of the instance or the shared template namespace", key)}
+	}
+	tmpl := &appv1.Template{}
+	if err := r.Get(ctx, key, tmpl); err != nil {
+		if apierrors.IsNotFound(err) {
+			// the template is watched, the instance is reconciled again when it's created
+			return nil, terminalError{fmt.Errorf("template %s not found", key)}
+		}
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// generateSecrets fills the omitted secret inputs of the instance with random values, and
+// returns whether the instance is updated.
+func (r *InstanceReconciler) generateSecrets(ctx context.Context, instance *appv1.Instance) (bool, error) {
+	tmpl, err := r.getTemplate(ctx, instance)
+	if err != nil {
+		// reported by render
+		return false, nil
+	}
+	values, generated, err := tmpl.Spec.Inputs.GenerateSecrets(instance.Spec.Values)
+	if err != nil || !generated {
+		return false, err
+	}
+	instance.Spec.Values = values
+	if err := r.Update(ctx, instance); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// render gets the template of the instance and renders its resources.
+func (r *InstanceReconciler) render(ctx context.Context, instance *appv1.Instance) (*appv1.Template, []*unstructured.Unstructured, error) {
+	tmpl, err := r.getTemplate(ctx, instance)
+	if err != nil {
+		return nil, nil, err
+	}
+	vars, err := renderVars(tmpl, instance, r.platformVars(instance))
+	if err != nil {
+		return nil, nil, terminalError{err}
+	}
+	objs, err := render(tmpl.Spec.Manifests, vars, instance.Namespace)
+	if err != nil {
+		return nil, nil, terminalError{err}
+	}
+	return tmpl, objs, nil
+}
+
+func (r *InstanceReconciler) platformVars(instance *appv1.Instance) map[string]string {
+	vars := make(map[string]string, len(r.PlatformVars)+1)
+	for k, v := range r.PlatformVars {
+		vars[k] = v
+	}
+	vars["SEALOS_NAMESPACE"] = instance.Namespace
+	return vars
+}
+
+// applyResources applies the resources with the instance as their owner, and returns their status.
+// A resource failing to apply doesn't stop the others, the first error is returned.
+func (r *InstanceReconciler) applyResources(ctx context.Context, instance *appv1.Instance, objs []*unstructured.Unstructured) ([]appv1.ResourceStatus, error) {
+	var firstErr error
+	resources := make([]appv1.ResourceStatus, 0, len(objs))
+	for _, obj := range objs {
+		res := appv1.ResourceStatus{APIVersion: obj.GetAPIVersion(), Kind: obj.GetKind(), Name: obj.GetName()}
+		if err := r.apply(ctx, instance, obj); err != nil {
+			res.Message = err.Error()
+			if firstErr == nil {
+				firstErr = fmt.Errorf("apply %s %s failed: %v", obj.GetKind(), obj.GetName(), err)
+			}
+		} else {
+			res.Ready, res.Message = resourceReady(obj)
+		}
+		resources = append(resources, res)
+	}
+	return resources, firstErr
+}
+
+func (r *InstanceReconciler) apply(ctx context.Context, instance *appv1.Instance, obj *unstructured.Unstructured) error {
+	gvk := obj.GroupVersionKind()
+	mapping, err := r.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return err
+	}
+	if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+		return fmt.Errorf("cluster scoped resources are not allowed")
+	}
+	// don't take over the resources of others, e.g. the ones deployed by the client
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(gvk)
+	err = r.Get(ctx, client.ObjectKeyFromObject(obj), existing)
+	if err == nil && !metav1.IsControlledBy(existing, instance) {
+		return fmt.Errorf("resource exists and is not owned by the instance")
+	}
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[InstanceLabel] = instance.Name
+	obj.SetLabels(labels)
+	if err := controllerutil.SetControllerReference(instance, obj, r.Scheme); err != nil {
+		return err
+	}
+	return r.Patch(ctx, obj, client.Apply, client.FieldOwner(fieldOwner), client.ForceOwnership)
+}
+
+// deleteResources deletes the resources owned by the instance.
+func (r *InstanceReconciler) deleteResources(ctx context.Context, instance *appv1.Instance, resources []appv1.ResourceStatus) error {
+	for _, res := range resources {
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(schema.FromAPIVersionAndKind(res.APIVersion, res.Kind))
+		if err := r.Get(ctx, types.NamespacedName{Namespace: instance.Namespace, Name: res.Name}, obj); err != nil {
+			if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+				continue
+			}
+			return err
+		}
+		if !metav1.IsControlledBy(obj, instance) {
+			continue
+		}
+		if err := r.Delete(ctx, obj, client.PropagationPolicy(metav1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("delete %s %s failed: %v", res.Kind, res.Name, err)
+		}
+		log.FromContext(ctx).Info("deleted resource of instance", "kind", res.Kind, "name", res.Name)
+	}
+	return nil
+}
+
+// removedResources returns the old resources which are not in the current ones.
+func removedResources(old, current []appv1.ResourceStatus) []appv1.ResourceStatus {
+	keep := make(map[string]bool, len(current))
+	for _, res := range current {
+		keep[statusKey(res)] = true
+	}
+	var removed []appv1.ResourceStatus
+	for _, res := range old {
+		if !keep[statusKey(res)] {
+			removed = append(removed, res)
+		}
+	}
+	return removed
+}
+
+func statusKey(res appv1.ResourceStatus) string {
+	return resourceKey(schema.FromAPIVersionAndKind(res.APIVersion, res.Kind).GroupKind(), res.Name)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *InstanceReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.recorder = mgr.GetEventRecorderFor("app-controller")
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &appv1.Instance{}, templateRefIndex, func(obj client.Object) []string {
+		instance := obj.(*appv1.Instance)
+		if instance.Spec.TemplateRef == nil {
+			return nil
+		}
+		return []string{instance.TemplateKey().String()}
+	}); err != nil {
+		return err
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&appv1.Instance{}).
+		// upgrade the instances when their template changes
+		Watches(&source.Kind{Type: &appv1.Template{}}, handler.EnqueueRequestsFromMapFunc(r.instancesOfTemplate)).
+		Complete(r)
+}
+
+func (r *InstanceReconciler) instancesOfTemplate(obj client.Object) []reconcile.Request {
+	instances := &appv1.InstanceList{}
+	key := types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()}
+	if err := r.List(context.Background(), instances, client.MatchingFields{templateRefIndex: key.String()}); err != nil {
+		log.Log.Error(err, "list instances of template failed", "template", key)
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(instances.Items))
+	for _, instance := range instances.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&instance)})
+	}
+	return requests
+}
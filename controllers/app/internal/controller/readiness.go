@@ -0,0 +1,93 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// resourceReady tells if the applied resource is ready from its status. Workloads are ready when
+// all their replicas are, resources with a Ready condition when it's true, and the others once
+// they are applied.
+func resourceReady(obj *unstructured.Unstructured) (bool, string) {
+	generation := obj.GetGeneration()
+	observed, found, _ := unstructured.NestedInt64(obj.Object, "status", "observedGeneration")
+	if found && observed < generation {
+		return false, "waiting for the controller to observe the latest generation"
+	}
+
+	gk := obj.GroupVersionKind().GroupKind()
+	switch gk.String() {
+	case "Deployment.apps", "StatefulSet.apps":
+		replicas, found, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+		if !found {
+			replicas = 1
+		}
+		ready, _, _ := unstructured.NestedInt64(obj.Object, "status", "readyReplicas")
+		updated, _, _ := unstructured.NestedInt64(obj.Object, "status", "updatedReplicas")
+		if ready < replicas || updated < replicas {
+			return false, fmt.Sprintf("%d/%d replicas are ready, %d are updated", ready, replicas, updated)
+		}
+		return true, ""
+	case "DaemonSet.apps":
+		desired, _, _ := unstructured.NestedInt64(obj.Object, "status", "desiredNumberScheduled")
+		ready, _, _ := unstructured.NestedInt64(obj.Object, "status", "numberReady")
+		if ready < desired {
+			return false, fmt.Sprintf("%d/%d pods are ready", ready, desired)
+		}
+		return true, ""
+	case "Job.batch":
+		if conditionStatus(obj, "Failed") == "True" {
+			return false, "job failed"
+		}
+		if conditionStatus(obj, "Complete") != "True" {
+			return false, "job is not complete"
+		}
+		return true, ""
+	case "PersistentVolumeClaim":
+		if phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase"); phase != "Bound" {
+			return false, fmt.Sprintf("claim is %s", phase)
+		}
+		return true, ""
+	case "Cluster.apps.kubeblocks.io":
+		if phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase"); phase != "Running" {
+			return false, fmt.Sprintf("cluster is %s", phase)
+		}
+		return true, ""
+	}
+
+	if status := conditionStatus(obj, "Ready"); status != "" && status != "True" {
+		return false, "Ready condition is " + status
+	}
+	return true, ""
+}
+
+// conditionStatus returns the status of the condition of the resource, or empty if it has none.
+func conditionStatus(obj *unstructured.Unstructured, conditionType string) string {
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok || condition["type"] != conditionType {
+			continue
+		}
+		status, _ := condition["status"].(string)
+		return status
+	}
+	return ""
+}
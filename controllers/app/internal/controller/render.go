@@ -0,0 +1,170 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/yaml"
+
+	appv1 "github.com/labring/sealos/controllers/app/api/v1"
+)
+
+// placeholderRegexp matches the placeholders of the templates, e.g. ${{ inputs.api_key }}
+var placeholderRegexp = regexp.MustCompile(`\$\{\{\s*(.*?)\s*\}\}`)
+
+const (
+	defaultsPrefix = "defaults."
+	inputsPrefix   = "inputs."
+)
+
+// renderVars returns the values of the placeholders of the instance: the defaults of the template
+// overridden by the ones of the instance, the validated inputs and the platform variables.
+func renderVars(tmpl *appv1.Template, instance *appv1.Instance, platform map[string]string) (map[string]string, error) {
+	vars := make(map[string]string, len(platform))
+	for k, v := range platform {
+		vars[k] = v
+	}
+	defaults, err := appv1.ResolveDefaults(tmpl, instance)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range defaults {
+		vars[defaultsPrefix+k] = v
+	}
+	inputs, err := tmpl.Spec.Inputs.Resolve(instance.Spec.Values)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range inputs {
+		vars[inputsPrefix+k] = v
+	}
+	return vars, nil
+}
+
+// render replaces the placeholders of the manifests with the vars and decodes the resources,
+// which are put into the namespace.
+func render(manifests string, vars map[string]string, namespace string) ([]*unstructured.Unstructured, error) {
+	rendered, err := replacePlaceholders(manifests, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	var objs []*unstructured.Unstructured
+	seen := map[string]bool{}
+	decoder := yaml.NewYAMLOrJSONDecoder(strings.NewReader(rendered), 4096)
+	for {
+		obj := map[string]interface{}{}
+		if err := decoder.Decode(&obj); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("decode manifests: %v", err)
+		}
+		if len(obj) == 0 {
+			continue
+		}
+		u := &unstructured.Unstructured{Object: obj}
+		if u.GetAPIVersion() == "" || u.GetKind() == "" || u.GetName() == "" {
+			return nil, fmt.Errorf("resource %s/%s must have apiVersion, kind and name", u.GetKind(), u.GetName())
+		}
+		if u.GetKind() == "Template" && u.GroupVersionKind().Group == appv1.GroupVersion.Group {
+			// the template itself, kept in the manifests of some templates
+			continue
+		}
+		if ns := u.GetNamespace(); ns != "" && ns != namespace {
+			return nil, fmt.Errorf("resource %s/%s must be in the namespace of the instance, got %s", u.GetKind(), u.GetName(), ns)
+		}
+		u.SetNamespace(namespace)
+		u.SetResourceVersion("")
+		u.SetUID("")
+		key := resourceKey(u.GroupVersionKind().GroupKind(), u.GetName())
+		if seen[key] {
+			return nil, fmt.Errorf("resource %s/%s is duplicated", u.GetKind(), u.GetName())
+		}
+		seen[key] = true
+		objs = append(objs, u)
+	}
+	return objs, nil
+}
+
+// resourceKey identifies a resource of the instance, the versions of a kind are the same resource
+func resourceKey(gk schema.GroupKind, name string) string {
+	return gk.String() + "/" + name
+}
+
+// replacePlaceholders replaces the placeholders in the scalars of the parsed manifests, so that a
+// value like `{a: b}` or `[x]` stays a string instead of changing the structure of the manifests.
+// An unquoted scalar which is only a placeholder takes the type of the value, e.g. the number of
+// `replicas: ${{ inputs.replicas }}`, the other scalars are quoted as strings if needed.
+func replacePlaceholders(manifests string, vars map[string]string) (string, error) {
+	var unknown []string
+	var replace func(node *yamlv3.Node)
+	replace = func(node *yamlv3.Node) {
+		for _, child := range node.Content {
+			replace(child)
+		}
+		if node.Kind != yamlv3.ScalarNode || !placeholderRegexp.MatchString(node.Value) {
+			return
+		}
+		whole := node.Style == 0 && placeholderRegexp.FindString(node.Value) == node.Value
+		node.Value = placeholderRegexp.ReplaceAllStringFunc(node.Value, func(match string) string {
+			key := placeholderRegexp.FindStringSubmatch(match)[1]
+			v, ok := vars[key]
+			if !ok {
+				unknown = append(unknown, key)
+				return match
+			}
+			return v
+		})
+		if whole {
+			// resolved again by the encoder, only as a scalar
+			node.Tag = ""
+		}
+	}
+
+	var buf bytes.Buffer
+	decoder := yamlv3.NewDecoder(strings.NewReader(manifests))
+	encoder := yamlv3.NewEncoder(&buf)
+	for {
+		doc := &yamlv3.Node{}
+		if err := decoder.Decode(doc); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return "", fmt.Errorf("decode manifests: %v", err)
+		}
+		replace(doc)
+		if err := encoder.Encode(doc); err != nil {
+			return "", fmt.Errorf("encode manifests: %v", err)
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		return "", fmt.Errorf("encode manifests: %v", err)
+	}
+	if len(unknown) > 0 {
+		return "", fmt.Errorf("unknown placeholders: %s", strings.Join(unknown, ", "))
+	}
+	return buf.String(), nil
+}
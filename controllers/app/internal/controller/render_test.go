@@ -0,0 +1,370 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"strings"
+	"testing"
+
+	appv1 "github.com/labring/sealos/controllers/app/api/v1"
+)
+
+func TestRender(t *testing.T) {
+	vars := map[string]string{
+		"defaults.app_name":   "demo",
+		"inputs.replicas":     "2",
+		"SEALOS_CLOUD_DOMAIN": "cloud.example.com",
+	}
+	tests := []struct {
+		name      string
+		manifests string
+		// names are the kind/name of the rendered resources
+		names   []string
+		wantErr string
+	}{
+		{
+			name: "placeholders are replaced",
+			manifests: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: ${{ defaults.app_name }}
+spec:
+  replicas: ${{inputs.replicas}}
+---
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: ${{ defaults.app_name }}
+  namespace: ns-user
+spec:
+  rules:
+  - host: ${{ defaults.app_name }}.${{ SEALOS_CLOUD_DOMAIN }}
+`,
+			names: []string{"Deployment/demo", "Ingress/demo"},
+		},
+		{
+			name: "empty documents are skipped",
+			manifests: `
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: demo
+---
+`,
+			names: []string{"Service/demo"},
+		},
+		{
+			name: "embedded template is skipped",
+			manifests: `
+apiVersion: app.sealos.io/v1
+kind: Template
+metadata:
+  name: demo
+spec:
+  title: demo
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: demo
+`,
+			names: []string{"Service/demo"},
+		},
+		{
+			name: "template of another group is kept",
+			manifests: `
+apiVersion: example.com/v1
+kind: Template
+metadata:
+  name: demo
+`,
+			names: []string{"Template/demo"},
+		},
+		{
+			name: "unknown placeholders",
+			manifests: `
+apiVersion: v1
+kind: Service
+metadata:
+  name: ${{ defaults.app_name }}-${{ inputs.missing }}-${{ SEALOS_UNKNOWN }}
+`,
+			wantErr: "unknown placeholders: inputs.missing, SEALOS_UNKNOWN",
+		},
+		{
+			name: "foreign namespace",
+			manifests: `
+apiVersion: v1
+kind: Service
+metadata:
+  name: demo
+  namespace: kube-system
+`,
+			wantErr: "must be in the namespace of the instance, got kube-system",
+		},
+		{
+			name: "duplicated names of a kind",
+			manifests: `
+apiVersion: v1
+kind: Service
+metadata:
+  name: demo
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: demo
+`,
+			wantErr: "resource Service/demo is duplicated",
+		},
+		{
+			name: "duplicated names across versions of a kind",
+			manifests: `
+apiVersion: autoscaling/v1
+kind: HorizontalPodAutoscaler
+metadata:
+  name: demo
+---
+apiVersion: autoscaling/v2
+kind: HorizontalPodAutoscaler
+metadata:
+  name: demo
+`,
+			wantErr: "resource HorizontalPodAutoscaler/demo is duplicated",
+		},
+		{
+			name: "same name of different kinds",
+			manifests: `
+apiVersion: v1
+kind: Service
+metadata:
+  name: demo
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: demo
+`,
+			names: []string{"Service/demo", "ConfigMap/demo"},
+		},
+		{
+			name: "missing name",
+			manifests: `
+apiVersion: v1
+kind: Service
+metadata:
+  namespace: ns-user
+`,
+			wantErr: "must have apiVersion, kind and name",
+		},
+		{
+			name:      "invalid yaml",
+			manifests: "apiVersion: v1\nkind: [Service\n",
+			wantErr:   "decode manifests",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objs, err := render(tt.manifests, vars, "ns-user")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expect error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var names []string
+			for _, obj := range objs {
+				names = append(names, obj.GetKind()+"/"+obj.GetName())
+				if obj.GetNamespace() != "ns-user" {
+					t.Errorf("expect %s/%s in namespace ns-user, got %q", obj.GetKind(), obj.GetName(), obj.GetNamespace())
+				}
+			}
+			if strings.Join(names, ",") != strings.Join(tt.names, ",") {
+				t.Errorf("expect resources %v, got %v", tt.names, names)
+			}
+		})
+	}
+}
+
+func TestRenderReplacesValues(t *testing.T) {
+	objs, err := render(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: ${{ defaults.app_name }}
+  resourceVersion: "42"
+  uid: 0b5e0d4e-7b3f-4a55-9c0d-3f0f6c0b8c11
+spec:
+  replicas: ${{ inputs.replicas }}
+`, map[string]string{"defaults.app_name": "demo", "inputs.replicas": "2"}, "ns-user")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(objs) != 1 {
+		t.Fatalf("expect 1 resource, got %d", len(objs))
+	}
+	obj := objs[0]
+	if obj.GetName() != "demo" {
+		t.Errorf("expect name demo, got %s", obj.GetName())
+	}
+	if obj.GetResourceVersion() != "" || obj.GetUID() != "" {
+		t.Errorf("expect resourceVersion and uid to be cleared, got %q and %q", obj.GetResourceVersion(), obj.GetUID())
+	}
+	// numbers are decoded as JSON numbers
+	if replicas := obj.Object["spec"].(map[string]interface{})["replicas"]; replicas != float64(2) {
+		t.Errorf("expect replicas 2, got %v (%T)", replicas, replicas)
+	}
+}
+
+func TestRenderQuotesValues(t *testing.T) {
+	vars := map[string]string{
+		"inputs.map":   "{a: b}",
+		"inputs.list":  "[x]",
+		"inputs.text":  "a: b # c",
+		"inputs.quote": `x", "evil": "y`,
+		"inputs.port":  "8080",
+		"inputs.debug": "true",
+	}
+	objs, err := render(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: demo
+data:
+  map: ${{ inputs.map }}
+  list: ${{ inputs.list }}
+  text: prefix ${{ inputs.text }}
+  quoted: "${{ inputs.quote }}"
+  single: '${{ inputs.text }}'
+  port: "${{ inputs.port }}"
+  literal: |
+    ${{ inputs.map }}
+spec:
+  port: ${{ inputs.port }}
+  debug: ${{ inputs.debug }}
+  url: http://demo:${{ inputs.port }}
+`, vars, "ns-user")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(objs) != 1 {
+		t.Fatalf("expect 1 resource, got %d", len(objs))
+	}
+	data := objs[0].Object["data"].(map[string]interface{})
+	want := map[string]interface{}{
+		"map":     "{a: b}",
+		"list":    "[x]",
+		"text":    "prefix a: b # c",
+		"quoted":  `x", "evil": "y`,
+		"single":  "a: b # c",
+		"port":    "8080",
+		"literal": "{a: b}\n",
+	}
+	if len(data) != len(want) {
+		t.Errorf("expect data %v, got %v", want, data)
+	}
+	for k, v := range want {
+		if data[k] != v {
+			t.Errorf("expect data.%s %q, got %v (%T)", k, v, data[k], data[k])
+		}
+	}
+	spec := objs[0].Object["spec"].(map[string]interface{})
+	if spec["port"] != float64(8080) || spec["debug"] != true || spec["url"] != "http://demo:8080" {
+		t.Errorf("expect typed port, debug and string url, got %v", spec)
+	}
+}
+
+func TestRenderVars(t *testing.T) {
+	tmpl := &appv1.Template{Spec: appv1.TemplateSpec{TemplateData: appv1.TemplateData{
+		Defaults: appv1.Defaults{
+			"app_name": {Type: "string", Value: "demo"},
+			"port":     {Type: "number", Value: "80"},
+		},
+		Inputs: appv1.Inputs{
+			"replicas": {Type: appv1.InputDataTypeNumber, Default: "1"},
+			"password": {Type: appv1.InputDataTypeSecret},
+		},
+	}}}
+	newInstance := func(values map[string]string, defaults appv1.Defaults) *appv1.Instance {
+		instance := &appv1.Instance{}
+		instance.Spec.Values = values
+		instance.Spec.Defaults = defaults
+		return instance
+	}
+	platform := map[string]string{"SEALOS_CLOUD_DOMAIN": "cloud.example.com"}
+
+	vars, err := renderVars(tmpl, newInstance(map[string]string{"password": "secret"},
+		appv1.Defaults{"app_name": {Type: "string", Value: "demo-abcd"}}), platform)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expect := map[string]string{
+		"SEALOS_CLOUD_DOMAIN": "cloud.example.com",
+		"defaults.app_name":   "demo-abcd",
+		"defaults.port":       "80",
+		"inputs.replicas":     "1",
+		"inputs.password":     "secret",
+	}
+	if len(vars) != len(expect) {
+		t.Errorf("expect vars %v, got %v", expect, vars)
+	}
+	for k, v := range expect {
+		if vars[k] != v {
+			t.Errorf("expect %s to be %q, got %q", k, v, vars[k])
+		}
+	}
+
+	tests := []struct {
+		name     string
+		instance *appv1.Instance
+		wantErr  string
+	}{
+		{
+			name:     "secret is required",
+			instance: newInstance(nil, nil),
+			wantErr:  "input password is required",
+		},
+		{
+			name:     "undefined input",
+			instance: newInstance(map[string]string{"password": "secret", "other": "x"}, nil),
+			wantErr:  "input other is not defined by the template",
+		},
+		{
+			name:     "invalid input",
+			instance: newInstance(map[string]string{"password": "secret", "replicas": "two"}, nil),
+			wantErr:  `input replicas: "two" is not a number`,
+		},
+		{
+			name: "unresolved default",
+			instance: newInstance(map[string]string{"password": "secret"},
+				appv1.Defaults{"app_name": {Type: "string", Value: "demo-${{ random(8) }}"}}),
+			wantErr: "default app_name must be resolved in the instance",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := renderVars(tmpl, tt.instance, platform)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expect error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
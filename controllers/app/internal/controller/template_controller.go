@@ -0,0 +1,78 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	appv1 "github.com/labring/sealos/controllers/app/api/v1"
+)
+
+// TemplateReconciler validates the inputs of a Template and publishes their JSON schema in its status.
+type TemplateReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+//+kubebuilder:rbac:groups=app.sealos.io,resources=templates,verbs=get;list;watch
+//+kubebuilder:rbac:groups=app.sealos.io,resources=templates/status,verbs=get;update;patch
+
+func (r *TemplateReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	tmpl := &appv1.Template{}
+	if err := r.Get(ctx, req.NamespacedName, tmpl); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if tmpl.Status.ObservedGeneration == tmpl.Generation {
+		return ctrl.Result{}, nil
+	}
+
+	condition := metav1.Condition{
+		Type:               appv1.TemplateConditionInputsValid,
+		Status:             metav1.ConditionTrue,
+		Reason:             "Valid",
+		Message:            "inputs are valid",
+		ObservedGeneration: tmpl.Generation,
+	}
+	tmpl.Status.InputsSchema = ""
+	if err := tmpl.Spec.Inputs.Validate(); err != nil {
+		condition.Status, condition.Reason, condition.Message = metav1.ConditionFalse, "Invalid", err.Error()
+	} else {
+		schema, err := tmpl.Spec.Inputs.JSONSchema()
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		tmpl.Status.InputsSchema = string(schema)
+	}
+	meta.SetStatusCondition(&tmpl.Status.Conditions, condition)
+	tmpl.Status.ObservedGeneration = tmpl.Generation
+	return ctrl.Result{}, r.Status().Update(ctx, tmpl)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *TemplateReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&appv1.Template{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
+}
@@ -26,6 +26,7 @@ const (
 	IngressFailedCnameCheck = 40300
 	IngressFailedOwnerCheck = 40301
 	IngressFailedIcpCheck   = 40302
+	IngressFailedTxtCheck   = 40303
 
 	IngressWebhookInternalError = 50000
 )
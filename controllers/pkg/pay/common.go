@@ -20,6 +20,7 @@ const (
 	PaymentFailed     = "failed"
 	PaymentExpired    = "expired"
 	PaymentSuccess    = "success"
+	PaymentRefunded   = "refunded"
 	PaymentUnknown    = "unknown"
 )
 
@@ -18,6 +18,8 @@ type Interface interface {
 	CreatePayment(amount int64, user string) (string, string, error)
 	GetPaymentDetails(sessionID string) (string, int64, error)
 	ExpireSession(payment string) error
+	// Refund refunds amount of the paid order, it returns the id of the refund.
+	Refund(payment string, amount int64, reason string) (string, error)
 }
 
 func NewPayHandler(paymentMethod string) (Interface, error) {
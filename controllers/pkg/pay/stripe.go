@@ -59,6 +59,9 @@ func (s StripePayment) GetPaymentDetails(sessionID string) (string, int64, error
 	}
 	switch ses.Status {
 	case stripe.CheckoutSessionStatusComplete:
+		if ses.PaymentIntent != nil && ses.PaymentIntent.LatestCharge != nil && ses.PaymentIntent.LatestCharge.Refunded {
+			return PaymentRefunded, ses.AmountTotal, nil
+		}
 		return PaymentSuccess, ses.AmountTotal, nil
 	case stripe.CheckoutSessionStatusExpired:
 		return PaymentExpired, 0, nil
@@ -80,3 +83,14 @@ func (s StripePayment) ExpireSession(sessionID string) error {
 	}
 	return nil
 }
+
+func (s StripePayment) Refund(sessionID string, amount int64, reason string) (string, error) {
+	r, err := CreateRefund(sessionID, amount, reason)
+	if err != nil {
+		return "", err
+	}
+	if r.Status == stripe.RefundStatusFailed || r.Status == stripe.RefundStatusCanceled {
+		return r.ID, fmt.Errorf("refund %s is %s", r.ID, r.Status)
+	}
+	return r.ID, nil
+}
@@ -15,14 +15,21 @@
 package pay
 
 import (
+	"fmt"
 	"os"
 	"time"
 
 	"github.com/stripe/stripe-go/v74"
 	"github.com/stripe/stripe-go/v74/checkout/session"
+	"github.com/stripe/stripe-go/v74/refund"
+	"github.com/stripe/stripe-go/v74/webhook"
 )
 
-const StripeAPIKEY = "STRIPE_API_KEY"
+const (
+	StripeAPIKEY = "STRIPE_API_KEY"
+	// StripeWebhookSecret is the signing secret of the webhook endpoint, used to verify the events
+	StripeWebhookSecret = "STRIPE_WEBHOOK_SECRET"
+)
 
 type StripePayment struct {
 }
@@ -71,11 +78,55 @@ func CreateCheckoutSession(amount int64, currency, successURL, cancelURL string)
 	return s, nil
 }
 
+// GetSession returns the session with the latest charge of its payment, which tells if the payment is refunded.
 func GetSession(sessionID string) (*stripe.CheckoutSession, error) {
-	return session.Get(sessionID, nil)
+	params := &stripe.CheckoutSessionParams{}
+	params.AddExpand("payment_intent.latest_charge")
+	return session.Get(sessionID, params)
+}
+
+// GetSessionByPaymentIntent returns the session which created the payment intent.
+func GetSessionByPaymentIntent(paymentIntentID string) (*stripe.CheckoutSession, error) {
+	iter := session.List(&stripe.CheckoutSessionListParams{PaymentIntent: stripe.String(paymentIntentID)})
+	for iter.Next() {
+		return iter.CheckoutSession(), nil
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("no session found for payment intent %s", paymentIntentID)
 }
 
 // ExpireSession
 func ExpireSession(sessionID string) (*stripe.CheckoutSession, error) {
 	return session.Expire(sessionID, nil)
 }
+
+// CreateRefund refunds amount of the payment of the completed session.
+func CreateRefund(sessionID string, amount int64, reason string) (*stripe.Refund, error) {
+	ses, err := GetSession(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if ses.Status != stripe.CheckoutSessionStatusComplete || ses.PaymentIntent == nil {
+		return nil, fmt.Errorf("session %s is not paid", sessionID)
+	}
+	params := &stripe.RefundParams{
+		PaymentIntent: stripe.String(ses.PaymentIntent.ID),
+		Amount:        stripe.Int64(amount),
+		Reason:        stripe.String(string(stripe.RefundReasonRequestedByCustomer)),
+	}
+	if reason != "" {
+		params.AddMetadata("reason", reason)
+	}
+	return refund.New(params)
+}
+
+// ConstructWebhookEvent verifies the signature of the webhook request and returns the event.
+func ConstructWebhookEvent(payload []byte, signature string) (stripe.Event, error) {
+	secret := os.Getenv(StripeWebhookSecret)
+	if secret == "" {
+		return stripe.Event{}, fmt.Errorf("env %s is not set", StripeWebhookSecret)
+	}
+	return webhook.ConstructEvent(payload, signature, secret)
+}
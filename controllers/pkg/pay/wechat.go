@@ -14,7 +14,11 @@
 
 package pay
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/wechatpay-apiv3/wechatpay-go/services/refunddomestic"
+)
 
 func (w WechatPayment) CreatePayment(amount int64, user string) (string, string, error) {
 	tradeNO := GetRandomString(32)
@@ -39,6 +43,10 @@ func (w WechatPayment) GetPaymentDetails(sessionID string) (string, int64, error
 		return PaymentNotPaid, 0, nil
 	case StatusFail:
 		return PaymentFailed, 0, fmt.Errorf("order failed")
+	case StatusRefund:
+		return PaymentRefunded, *orderResp.Amount.Total, nil
+	case StatusClosed:
+		return PaymentExpired, 0, nil
 	default:
 		return PaymentUnknown, 0, fmt.Errorf("unknown order status: %s", *orderResp.TradeState)
 	}
@@ -47,3 +55,21 @@ func (w WechatPayment) GetPaymentDetails(sessionID string) (string, int64, error
 func (w WechatPayment) ExpireSession(_ string) error {
 	return nil
 }
+
+func (w WechatPayment) Refund(tradeNO string, amount int64, reason string) (string, error) {
+	orderResp, err := QueryOrder(tradeNO)
+	if err != nil {
+		return "", err
+	}
+	if *orderResp.TradeState != StatusSuccess && *orderResp.TradeState != StatusRefund {
+		return "", fmt.Errorf("order %s is not paid: %s", tradeNO, *orderResp.TradeState)
+	}
+	refundResp, err := RefundOrder(tradeNO, GetRandomString(32), amount, *orderResp.Amount.Total, reason)
+	if err != nil {
+		return "", err
+	}
+	if refundResp.Status != nil && (*refundResp.Status == refunddomestic.STATUS_CLOSED || *refundResp.Status == refunddomestic.STATUS_ABNORMAL) {
+		return *refundResp.OutRefundNo, fmt.Errorf("refund %s is %s", *refundResp.OutRefundNo, *refundResp.Status)
+	}
+	return *refundResp.OutRefundNo, nil
+}
@@ -19,13 +19,19 @@ import (
 	"crypto/rand"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"time"
 
+	"github.com/labring/sealos/controllers/pkg/utils/env"
 	"github.com/wechatpay-apiv3/wechatpay-go/core"
+	"github.com/wechatpay-apiv3/wechatpay-go/core/auth/verifiers"
+	"github.com/wechatpay-apiv3/wechatpay-go/core/downloader"
+	"github.com/wechatpay-apiv3/wechatpay-go/core/notify"
 	"github.com/wechatpay-apiv3/wechatpay-go/core/option"
 	"github.com/wechatpay-apiv3/wechatpay-go/services/payments"
 	"github.com/wechatpay-apiv3/wechatpay-go/services/payments/native"
+	"github.com/wechatpay-apiv3/wechatpay-go/services/refunddomestic"
 	"github.com/wechatpay-apiv3/wechatpay-go/utils"
 )
 
@@ -45,6 +51,8 @@ const (
 	StatusProcessing   = "PROCESSING"
 	StatusNotPay       = "NOTPAY"
 	StatusFail         = "FAILED"
+	StatusRefund       = "REFUND"
+	StatusClosed       = "CLOSED"
 	DefaultCallbackURL = "https://sealos.io/payment/wechat/callback"
 )
 
@@ -98,7 +106,7 @@ func WechatPay(amount int64, user, tradeNO, describe, callback string) (string,
 		describe = "sealos cloud recharge"
 	}
 	if callback == "" {
-		callback = DefaultCallbackURL
+		callback = env.GetEnvWithDefault(NotifyCallbackURL, DefaultCallbackURL)
 	}
 	svc := native.NativeApiService{Client: client}
 	resp, _, err := svc.Prepay(ctx,
@@ -139,6 +147,49 @@ func WechatPay(amount int64, user, tradeNO, describe, callback string) (string,
 	return *resp.CodeUrl, nil
 }
 
+// RefundOrder refunds amount of the order, total is the amount paid for the order.
+func RefundOrder(tradeNO, refundNO string, amount, total int64, reason string) (*refunddomestic.Refund, error) {
+	ctx := context.Background()
+	client, err := NewClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("new wechat pay client err:%s", err)
+	}
+	req := refunddomestic.CreateRequest{
+		OutTradeNo:  core.String(tradeNO),
+		OutRefundNo: core.String(refundNO),
+		Amount: &refunddomestic.AmountReq{
+			Refund:   core.Int64(amount),
+			Total:    core.Int64(total),
+			Currency: core.String("CNY"),
+		},
+	}
+	if reason != "" {
+		req.Reason = core.String(reason)
+	}
+	svc := refunddomestic.RefundsApiService{Client: client}
+	resp, _, err := svc.Create(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("call Refund err:%s", err)
+	}
+	return resp, nil
+}
+
+// ParseNotify verifies the signature of the notification request with the platform certificates,
+// and decrypts its resource into content.
+func ParseNotify(request *http.Request, content interface{}) (*notify.Request, error) {
+	ctx := context.Background()
+	// the client registers the certificate downloader of the merchant
+	if _, err := NewClient(ctx); err != nil {
+		return nil, fmt.Errorf("new wechat pay client err:%s", err)
+	}
+	visitor := downloader.MgrInstance().GetCertificateVisitor(os.Getenv(MchID))
+	handler, err := notify.NewRSANotifyHandler(os.Getenv(MchAPIv3Key), verifiers.NewSHA256WithRSAVerifier(visitor))
+	if err != nil {
+		return nil, fmt.Errorf("new wechat notify handler err:%s", err)
+	}
+	return handler.ParseNotifyRequest(ctx, request, content)
+}
+
 func GetRandomString(n int) string {
 	randBytes := make([]byte, n/2)
 	if _, err := rand.Read(randBytes); err != nil {
@@ -51,12 +51,54 @@ type TerminalSpec struct {
 	//+kubebuilder:validation:Optional
 	//+kubebuilder:default=nginx
 	IngressType IngressType `json:"ingressType"`
+	// IdleTimeout scales the terminal to zero when it has no keepalive for the duration, e.g. 30m,
+	// the terminal is kept and scaled up again by the next keepalive. Idleness only comes from the
+	// keepalives of the client, not from the activity of the sessions. It must be shorter than
+	// Keepalived, otherwise it is ignored. Empty disables it.
+	//+kubebuilder:validation:Optional
+	IdleTimeout string `json:"idleTimeout,omitempty"`
+	// Recording records the sessions of the terminal in asciinema format, empty disables it.
+	//+kubebuilder:validation:Optional
+	Recording *RecordingSpec `json:"recording,omitempty"`
+}
+
+// RecordingSpec is where the recordings of the sessions are stored, one of PersistentVolumeClaim
+// and ObjectStorage must be set. The recordings are written from the tty container, so they are
+// not tamper-proof: the user of the terminal can modify or delete them.
+type RecordingSpec struct {
+	// PersistentVolumeClaim is the name of the claim in the namespace of the terminal, the recordings
+	// are stored in the directory named after the terminal.
+	//+kubebuilder:validation:Optional
+	PersistentVolumeClaim string `json:"persistentVolumeClaim,omitempty"`
+	// ObjectStorage uploads the recordings to a S3 compatible object storage.
+	//+kubebuilder:validation:Optional
+	ObjectStorage *ObjectStorage `json:"objectStorage,omitempty"`
+}
+
+type ObjectStorage struct {
+	// Endpoint of the object storage, e.g. https://s3.amazonaws.com
+	//+kubebuilder:validation:Required
+	Endpoint string `json:"endpoint"`
+	//+kubebuilder:validation:Required
+	Bucket string `json:"bucket"`
+	// Prefix of the objects, the recordings are uploaded under <prefix>/<terminal name>/
+	//+kubebuilder:validation:Optional
+	Prefix string `json:"prefix,omitempty"`
+	// SecretName is the name of the secret in the namespace of the terminal with the accessKey
+	// and secretKey of the object storage.
+	//+kubebuilder:validation:Required
+	SecretName string `json:"secretName"`
 }
 
 // TerminalStatus defines the observed state of Terminal
 type TerminalStatus struct {
 	AvailableReplicas int32  `json:"availableReplicas"`
 	Domain            string `json:"domain"`
+	// Idle is true when the terminal is scaled to zero by the idle timeout.
+	Idle bool `json:"idle,omitempty"`
+	// RecordingLocation is where the recordings are stored, e.g. pvc://<claim>/<terminal name>/
+	// or s3://<bucket>/<prefix>/<terminal name>/
+	RecordingLocation string `json:"recordingLocation,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -65,6 +107,7 @@ type TerminalStatus struct {
 //+kubebuilder:printcolumn:name="Keepalived",type=string,JSONPath=".spec.keepalived"
 //+kubebuilder:printcolumn:name="Domain",type=string,JSONPath=".status.domain"
 //+kubebuilder:printcolumn:name="APIServer",priority=1,type=string,JSONPath=".spec.apiServer"
+//+kubebuilder:printcolumn:name="Idle",priority=1,type=boolean,JSONPath=".status.idle"
 //+kubebuilder:printcolumn:name="LastUpdateTime",priority=1,type=string,JSONPath=".metadata.annotations.lastUpdateTime"
 //+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 
@@ -25,6 +25,41 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObjectStorage) DeepCopyInto(out *ObjectStorage) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectStorage.
+func (in *ObjectStorage) DeepCopy() *ObjectStorage {
+	if in == nil {
+		return nil
+	}
+	out := new(ObjectStorage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecordingSpec) DeepCopyInto(out *RecordingSpec) {
+	*out = *in
+	if in.ObjectStorage != nil {
+		in, out := &in.ObjectStorage, &out.ObjectStorage
+		*out = new(ObjectStorage)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecordingSpec.
+func (in *RecordingSpec) DeepCopy() *RecordingSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RecordingSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Terminal) DeepCopyInto(out *Terminal) {
 	*out = *in
@@ -92,6 +127,11 @@ func (in *TerminalSpec) DeepCopyInto(out *TerminalSpec) {
 		*out = new(int32)
 		**out = **in
 	}
+	if in.Recording != nil {
+		in, out := &in.Recording, &out.Recording
+		*out = new(RecordingSpec)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TerminalSpec.
@@ -0,0 +1,147 @@
+/*
+Copyright 2022 labring.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	terminalv1 "github.com/labring/sealos/controllers/terminal/api/v1"
+)
+
+const (
+	RecordingDir         = "/recordings"
+	RecordingVolumeName  = "recordings"
+	RecordingDirEnv      = "RECORDING_DIR"
+	UploaderName         = "uploader"
+	DefaultUploaderImage = "minio/mc:latest"
+)
+
+// request and limit for recording uploader container
+const (
+	UploaderCPURequest    = "0.01"
+	UploaderMemoryRequest = "16Mi"
+	UploaderCPULimit      = "0.1"
+	UploaderMemoryLimit   = "64Mi"
+)
+
+var ErrInvalidRecording = errors.New("recording must set exactly one of persistentVolumeClaim and objectStorage")
+
+// applyRecording mounts the recording directory into the tty container of the pod spec and
+// adds the volume and uploader sidecar it needs, it returns the location of the recordings.
+// The pod spec is left untouched when recording is disabled.
+// The recording directory has to be writable in the tty container since asciinema runs next to the
+// user's shell, so the recordings are an audit aid rather than tamper-proof evidence.
+func (r *TerminalReconciler) applyRecording(terminal *terminalv1.Terminal, spec *corev1.PodSpec) (string, error) {
+	recording := terminal.Spec.Recording
+	if recording == nil {
+		return "", nil
+	}
+	if (recording.PersistentVolumeClaim == "") == (recording.ObjectStorage == nil) {
+		return "", ErrInvalidRecording
+	}
+
+	tty := &spec.Containers[0]
+	tty.Env = append(tty.Env, corev1.EnvVar{Name: RecordingDirEnv, Value: RecordingDir})
+
+	if recording.PersistentVolumeClaim != "" {
+		spec.Volumes = append(spec.Volumes, corev1.Volume{
+			Name: RecordingVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: recording.PersistentVolumeClaim},
+			},
+		})
+		tty.VolumeMounts = append(tty.VolumeMounts, corev1.VolumeMount{
+			Name:      RecordingVolumeName,
+			MountPath: RecordingDir,
+			SubPath:   terminal.Name,
+		})
+		return "pvc://" + recording.PersistentVolumeClaim + "/" + terminal.Name + "/", nil
+	}
+
+	storage := recording.ObjectStorage
+	endpoint, err := url.Parse(storage.Endpoint)
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return "", fmt.Errorf("invalid object storage endpoint %q", storage.Endpoint)
+	}
+	objectPath := path.Join(storage.Bucket, storage.Prefix, terminal.Name)
+
+	// recordings are kept in an emptyDir until the uploader mirrors them to the object storage
+	spec.Volumes = append(spec.Volumes, corev1.Volume{
+		Name:         RecordingVolumeName,
+		VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+	})
+	tty.VolumeMounts = append(tty.VolumeMounts, corev1.VolumeMount{
+		Name:      RecordingVolumeName,
+		MountPath: RecordingDir,
+	})
+	spec.Containers = append(spec.Containers, corev1.Container{
+		Name:    UploaderName,
+		Image:   r.uploaderImage,
+		Command: []string{"mc", "mirror", "--watch", "--overwrite", RecordingDir, "store/" + objectPath},
+		// the watch may miss the last writes of the casts when the pod is terminated, mirror them once
+		// more before the emptyDir is removed
+		Lifecycle: &corev1.Lifecycle{
+			PreStop: &corev1.LifecycleHandler{
+				Exec: &corev1.ExecAction{Command: []string{"mc", "mirror", "--overwrite", RecordingDir, "store/" + objectPath}},
+			},
+		},
+		Env: []corev1.EnvVar{
+			{Name: "ACCESS_KEY", ValueFrom: secretKeyRef(storage.SecretName, "accessKey")},
+			{Name: "SECRET_KEY", ValueFrom: secretKeyRef(storage.SecretName, "secretKey")},
+			// dependent variables are expanded by kubelet, so the keys never show up in the spec
+			{Name: "MC_HOST_store", Value: endpoint.Scheme + "://$(ACCESS_KEY):$(SECRET_KEY)@" + endpoint.Host},
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: RecordingVolumeName, MountPath: RecordingDir, ReadOnly: true},
+		},
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				"cpu":    resource.MustParse(UploaderCPURequest),
+				"memory": resource.MustParse(UploaderMemoryRequest),
+			},
+			Limits: corev1.ResourceList{
+				"cpu":    resource.MustParse(UploaderCPULimit),
+				"memory": resource.MustParse(UploaderMemoryLimit),
+			},
+		},
+	})
+	return "s3://" + objectPath + "/", nil
+}
+
+func secretKeyRef(name, key string) *corev1.EnvVarSource {
+	return &corev1.EnvVarSource{
+		SecretKeyRef: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: name},
+			Key:                  key,
+		},
+	}
+}
+
+func getUploaderImage() string {
+	image := os.Getenv("RECORDING_UPLOADER_IMAGE")
+	if image == "" {
+		return DefaultUploaderImage
+	}
+	return image
+}
@@ -18,6 +18,7 @@ package controllers
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"time"
 
@@ -75,6 +76,7 @@ type TerminalReconciler struct {
 	terminalPort    string
 	secretName      string
 	secretNamespace string
+	uploaderImage   string
 }
 
 //+kubebuilder:rbac:groups=terminal.sealos.io,resources=terminals,verbs=get;list;watch;create;update;patch;delete
@@ -124,6 +126,10 @@ func (r *TerminalReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 		return ctrl.Result{}, err
 	}
 
+	if _, err := idleTimeout(terminal); err != nil {
+		r.recorder.Eventf(terminal, corev1.EventTypeWarning, "InvalidIdleTimeout", "%v, the idle timeout is ignored", err)
+	}
+
 	if isExpired(terminal) {
 		if err := r.Delete(ctx, terminal); err != nil {
 			return ctrl.Result{}, err
@@ -152,8 +158,7 @@ func (r *TerminalReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 	}
 
 	r.recorder.Eventf(terminal, corev1.EventTypeNormal, "Created", "create terminal success: %v", terminal.Name)
-	duration, _ := time.ParseDuration(terminal.Spec.Keepalived)
-	return ctrl.Result{RequeueAfter: duration}, nil
+	return ctrl.Result{RequeueAfter: requeueAfter(terminal)}, nil
 }
 
 func (r *TerminalReconciler) syncIngress(ctx context.Context, terminal *terminalv1.Terminal, hostname string) error {
@@ -344,10 +349,17 @@ func (r *TerminalReconciler) syncDeployment(ctx context.Context, terminal *termi
 		},
 	}
 
+	replicas := terminal.Spec.Replicas
+	idle := isIdle(terminal)
+	if idle {
+		// keep the terminal and scale it to zero, the next keepalive scales it up again
+		replicas = new(int32)
+	}
+
 	expectDeployment := &appsv1.Deployment{
 		ObjectMeta: objectMeta,
 		Spec: appsv1.DeploymentSpec{
-			Replicas: terminal.Spec.Replicas,
+			Replicas: replicas,
 			Selector: selector,
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: templateObjMeta,
@@ -358,6 +370,12 @@ func (r *TerminalReconciler) syncDeployment(ctx context.Context, terminal *termi
 		},
 	}
 
+	recordingLocation, err := r.applyRecording(terminal, &expectDeployment.Spec.Template.Spec)
+	if err != nil {
+		return err
+	}
+	containers = expectDeployment.Spec.Template.Spec.Containers
+
 	deployment := &appsv1.Deployment{
 		ObjectMeta: objectMeta,
 	}
@@ -375,7 +393,10 @@ func (r *TerminalReconciler) syncDeployment(ctx context.Context, terminal *termi
 			deployment.Spec.Template.Spec.Containers[0].Ports = containers[0].Ports
 			deployment.Spec.Template.Spec.Containers[0].Env = containers[0].Env
 			deployment.Spec.Template.Spec.Containers[0].Resources = containers[0].Resources
+			deployment.Spec.Template.Spec.Containers[0].VolumeMounts = containers[0].VolumeMounts
+			syncSidecar(deployment, containers)
 		}
+		deployment.Spec.Template.Spec.Volumes = expectDeployment.Spec.Template.Spec.Volumes
 
 		if deployment.Spec.Template.Spec.Hostname == "" {
 			letterID, err := nanoid.CustomASCII(LetterBytes, HostnameLength)
@@ -394,14 +415,42 @@ func (r *TerminalReconciler) syncDeployment(ctx context.Context, terminal *termi
 		return err
 	}
 
-	if terminal.Status.AvailableReplicas != deployment.Status.AvailableReplicas {
+	if idle && !terminal.Status.Idle {
+		r.recorder.Eventf(terminal, corev1.EventTypeNormal, "Idle", "scale terminal to zero after idle timeout %v", terminal.Spec.IdleTimeout)
+	}
+
+	if terminal.Status.AvailableReplicas != deployment.Status.AvailableReplicas ||
+		terminal.Status.Idle != idle || terminal.Status.RecordingLocation != recordingLocation {
 		terminal.Status.AvailableReplicas = deployment.Status.AvailableReplicas
+		terminal.Status.Idle = idle
+		terminal.Status.RecordingLocation = recordingLocation
 		return r.Status().Update(ctx, terminal)
 	}
 
 	return nil
 }
 
+// syncSidecar updates the containers after the tty container to the expected ones,
+// only some specific fields of an existing sidecar are updated
+func syncSidecar(deployment *appsv1.Deployment, containers []corev1.Container) {
+	current := deployment.Spec.Template.Spec.Containers
+	if len(containers) == 1 {
+		deployment.Spec.Template.Spec.Containers = current[:1]
+		return
+	}
+	if len(current) == 1 || current[1].Name != containers[1].Name {
+		deployment.Spec.Template.Spec.Containers = append(current[:1], containers[1])
+		return
+	}
+	current[1].Image = containers[1].Image
+	current[1].Command = containers[1].Command
+	current[1].Env = containers[1].Env
+	current[1].VolumeMounts = containers[1].VolumeMounts
+	current[1].Resources = containers[1].Resources
+	current[1].Lifecycle = containers[1].Lifecycle
+	deployment.Spec.Template.Spec.Containers = current[:2]
+}
+
 func (r *TerminalReconciler) fillDefaultValue(ctx context.Context, terminal *terminalv1.Terminal) error {
 	hasUpdate := false
 	if terminal.Spec.APIServer == "" {
@@ -434,6 +483,61 @@ func isExpired(terminal *terminalv1.Terminal) bool {
 	return lastUpdateTime.Add(duration).Before(time.Now())
 }
 
+// idleTimeout return the idle timeout of the terminal, zero means disabled. It must be shorter than
+// the keepalived duration, otherwise the terminal would be deleted before it becomes idle.
+func idleTimeout(terminal *terminalv1.Terminal) (time.Duration, error) {
+	if terminal.Spec.IdleTimeout == "" {
+		return 0, nil
+	}
+	duration, err := time.ParseDuration(terminal.Spec.IdleTimeout)
+	if err != nil {
+		return 0, fmt.Errorf("invalid idle timeout %q: %v", terminal.Spec.IdleTimeout, err)
+	}
+	if duration <= 0 {
+		return 0, nil
+	}
+	if keepalived, err := time.ParseDuration(terminal.Spec.Keepalived); err == nil && duration >= keepalived {
+		return 0, fmt.Errorf("idle timeout %v must be shorter than keepalived %v", duration, keepalived)
+	}
+	return duration, nil
+}
+
+// idleDeadline return when the terminal becomes idle, ok is false if the idle timeout is disabled
+// or invalid
+func idleDeadline(terminal *terminalv1.Terminal) (deadline time.Time, ok bool) {
+	duration, err := idleTimeout(terminal)
+	if err != nil || duration == 0 {
+		return time.Time{}, false
+	}
+	anno := terminal.ObjectMeta.Annotations
+	lastUpdateTime, err := time.Parse(time.RFC3339, anno[KeepaliveAnnotation])
+	if err != nil {
+		return time.Time{}, false
+	}
+	return lastUpdateTime.Add(duration), true
+}
+
+// isIdle return true if the terminal has no keepalive within the idle timeout. Like the expiry it
+// only depends on the keepalives of the client, the activity of the sessions is not tracked.
+func isIdle(terminal *terminalv1.Terminal) bool {
+	deadline, ok := idleDeadline(terminal)
+	// treat parse errors as not idle
+	return ok && deadline.Before(time.Now())
+}
+
+// requeueAfter return the duration to the next check of the terminal, which is the keepalived
+// duration or the time until the terminal becomes idle if that comes first
+func requeueAfter(terminal *terminalv1.Terminal) time.Duration {
+	duration, _ := time.ParseDuration(terminal.Spec.Keepalived)
+	if deadline, ok := idleDeadline(terminal); ok {
+		if untilIdle := time.Until(deadline); untilIdle > 0 && untilIdle < duration {
+			// requeue right after the deadline so the terminal is already idle
+			duration = untilIdle + time.Second
+		}
+	}
+	return duration
+}
+
 func buildLabelsMap(terminal *terminalv1.Terminal) map[string]string {
 	labelsMap := map[string]string{
 		"TerminalID": terminal.Name,
@@ -487,6 +591,7 @@ func (r *TerminalReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	r.terminalPort = getPort()
 	r.secretName = getSecretName()
 	r.secretNamespace = getSecretNamespace()
+	r.uploaderImage = getUploaderImage()
 	r.Config = mgr.GetConfig()
 	owner := &handler.EnqueueRequestForOwner{OwnerType: &terminalv1.Terminal{}, IsController: false}
 	return ctrl.NewControllerManagedBy(mgr).
@@ -10,5 +10,12 @@ kubectl config use-context kubernetes
 
 cat ~/.kube/config
 
+# record every session in asciinema format when the controller sets RECORDING_DIR
+if [ -n "${RECORDING_DIR}" ]; then
+  mkdir -p ${RECORDING_DIR}
+  ttyd -p 8080 sh -c 'asciinema rec -q -c zsh "${RECORDING_DIR}/$(date +%Y%m%d%H%M%S)-$$.cast"'
+  exit
+fi
+
 # ttyd -p 8080 bash
 ttyd -p 8080 zsh
\ No newline at end of file
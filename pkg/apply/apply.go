@@ -50,7 +50,7 @@ func NewApplierFromFile(cmd *cobra.Command, path string, args *Args) (applydrive
 		return nil, fmt.Errorf("cluster name cannot be empty, make sure %s file is correct", path)
 	}
 
-	if err := CheckAndInitialize(cluster); err != nil {
+	if err := CheckAndInitialize(cluster, args.DryRun.Enabled); err != nil {
 		return nil, err
 	}
 
@@ -70,5 +70,7 @@ func NewApplierFromFile(cmd *cobra.Command, path string, args *Args) (applydrive
 		ClusterFile:    Clusterfile,
 		ClusterCurrent: currentCluster,
 		RunNewImages:   GetNewImages(currentCluster, cluster),
+		DryRun:         args.DryRun.Enabled,
+		PlanOutput:     args.DryRun.Output,
 	}, nil
 }
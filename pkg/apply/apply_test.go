@@ -17,12 +17,15 @@ limitations under the License.
 package apply
 
 import (
+	"errors"
 	"reflect"
 	"testing"
 
 	"github.com/spf13/cobra"
 
 	"github.com/labring/sealos/pkg/apply/applydrivers"
+	"github.com/labring/sealos/pkg/exec"
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
 )
 
 func Test_NewApplierFromFile(t *testing.T) {
@@ -120,3 +123,39 @@ func TestNewApplierFromFile(t *testing.T) {
 		})
 	}
 }
+
+func TestNewApplierFromFileDryRun(t *testing.T) {
+	connected := 0
+	defer func(f func(*v2.SSH) (exec.Interface, error)) { newExecer = f }(newExecer)
+	newExecer = func(*v2.SSH) (exec.Interface, error) {
+		connected++
+		return nil, errors.New("connecting to hosts")
+	}
+	newArgs := func(dryRun bool) *Args {
+		return &Args{
+			Sets:   []string{"clusterName=default"},
+			Values: []string{"../clusterfile/testdata/emptyHostsAndSSH.values.yaml"},
+			DryRun: DryRun{Enabled: dryRun, Output: applydrivers.PlanOutputJSON},
+		}
+	}
+
+	applier, err := NewApplierFromFile(&cobra.Command{Use: "mock"}, "../clusterfile/testdata/clusterfile.yaml", newArgs(true))
+	if err != nil {
+		t.Fatalf("NewApplierFromFile() error = %v", err)
+	}
+	if connected != 0 {
+		t.Errorf("expect no host to be connected to in dry run mode, got %d connections", connected)
+	}
+	a := applier.(*applydrivers.Applier)
+	if !a.DryRun || a.PlanOutput != applydrivers.PlanOutputJSON {
+		t.Errorf("expect a dry run with %s output, got %v and %s", applydrivers.PlanOutputJSON, a.DryRun, a.PlanOutput)
+	}
+	// the local host is the master, whose arch is unknown without connecting to it
+	if hosts := a.ClusterDesired.Spec.Hosts; len(hosts) != 1 || !reflect.DeepEqual(hosts[0].Roles, []string{v2.MASTER}) {
+		t.Errorf("expect the local host to be the only master, got %+v", hosts)
+	}
+
+	if _, err = NewApplierFromFile(&cobra.Command{Use: "mock"}, "../clusterfile/testdata/clusterfile.yaml", newArgs(false)); err == nil || connected != 1 {
+		t.Errorf("expect the local host to be connected to without dry run, got %d connections, error %v", connected, err)
+	}
+}
@@ -27,10 +27,12 @@ import (
 	"golang.org/x/sync/errgroup"
 
 	"github.com/labring/sealos/pkg/apply/processor"
+	"github.com/labring/sealos/pkg/buildah"
 	"github.com/labring/sealos/pkg/client-go/kubernetes"
 	"github.com/labring/sealos/pkg/clusterfile"
 	"github.com/labring/sealos/pkg/constants"
 	"github.com/labring/sealos/pkg/exec"
+	"github.com/labring/sealos/pkg/runtime"
 	"github.com/labring/sealos/pkg/ssh"
 	"github.com/labring/sealos/pkg/system"
 	v2 "github.com/labring/sealos/pkg/types/v1beta1"
@@ -61,6 +63,31 @@ func NewDefaultApplier(ctx context.Context, cluster *v2.Cluster, cf clusterfile.
 	}, nil
 }
 
+// NewDefaultUninstallApplier returns an applier which uninstalls the application images from the cluster.
+func NewDefaultUninstallApplier(ctx context.Context, cluster *v2.Cluster, cf clusterfile.Interface, images []string) (Interface, error) {
+	if len(images) == 0 {
+		return nil, fmt.Errorf("images to uninstall cannot be empty")
+	}
+	return &Applier{
+		Context:            ctx,
+		ClusterDesired:     cluster,
+		ClusterFile:        cf,
+		ClusterCurrent:     cf.GetCluster(),
+		RunUninstallImages: images,
+	}, nil
+}
+
+// NewDefaultRollbackApplier returns an applier which rolls back an application to a previous revision.
+func NewDefaultRollbackApplier(ctx context.Context, cluster *v2.Cluster, cf clusterfile.Interface, opts processor.RollbackOptions) (Interface, error) {
+	return &Applier{
+		Context:        ctx,
+		ClusterDesired: cluster,
+		ClusterFile:    cf,
+		ClusterCurrent: cf.GetCluster(),
+		Rollback:       &opts,
+	}, nil
+}
+
 func NewDefaultScaleApplier(ctx context.Context, current, cluster *v2.Cluster) (Interface, error) {
 	if cluster.Name == "" {
 		cluster.Name = current.Name
@@ -82,9 +109,23 @@ type Applier struct {
 	Client             kubernetes.Client
 	CurrentClusterInfo *version.Info
 	RunNewImages       []string
+	// RunUninstallImages are the application images to be removed from the cluster
+	RunUninstallImages []string
+	// Rollback rolls back an application to a previous revision if not nil
+	Rollback *processor.RollbackOptions
+	// DryRun prints the plan in the format of PlanOutput instead of applying it
+	DryRun     bool
+	PlanOutput string
+	// DrainOptions changes how nodes are drained before they are deleted if not nil
+	DrainOptions *runtime.DrainOptions
+	// checkpoint of the last failed run to resume from
+	checkpoint *processor.Checkpoint
 }
 
 func (c *Applier) Apply() error {
+	if c.DryRun {
+		return c.dryRun()
+	}
 	// clusterErr and appErr should not appear in the same time
 	var clusterErr, appErr error
 	defer func() {
@@ -95,13 +136,21 @@ func (c *Applier) Apply() error {
 		c.applyAfter()
 	}()
 	c.initStatus()
-	if c.ClusterCurrent == nil || c.ClusterCurrent.CreationTimestamp.IsZero() {
+	if clusterErr = c.loadCheckpoint(); clusterErr != nil {
+		clusterErr = processor.NewPreProcessError(clusterErr)
+		return clusterErr
+	}
+	if c.ClusterCurrent == nil || c.ClusterCurrent.CreationTimestamp.IsZero() || c.resuming(processor.CreateProcessorName) {
 		if !c.ClusterDesired.CreationTimestamp.IsZero() {
 			if yes, _ := confirm.Confirm("Desired cluster CreationTimestamp is not zero, do you want to initialize it again?", "you have canceled to create cluster"); !yes {
 				clusterErr = processor.NewPreProcessError(fmt.Errorf("canceled to create cluster"))
 				return clusterErr
 			}
 		}
+		if c.resuming(processor.CreateProcessorName) && c.ClusterCurrent != nil && c.ClusterDesired.Status.Mounts == nil {
+			// reuse the containers created by the last failed run
+			c.ClusterDesired.Status.Mounts = c.ClusterCurrent.Status.Mounts
+		}
 		clusterErr = c.initCluster()
 		if clusterErr != nil && processor.IsRunGuestFailed(clusterErr) {
 			appErr = errors.Unwrap(clusterErr)
@@ -180,6 +229,16 @@ func (c *Applier) updateStatus(clusterErr error, appErr error) {
 func (c *Applier) reconcileCluster() (clusterErr error, appErr error) {
 	// sync newVersion pki and etc dir in `.sealos/default/pki` and `.sealos/default/etc`
 	processor.SyncNewVersionConfig(c.ClusterDesired.Name)
+	if c.Rollback != nil {
+		return nil, c.rollbackApp()
+	}
+	if len(c.RunUninstallImages) != 0 {
+		logger.Debug("uninstall images: %+v", c.RunUninstallImages)
+		return nil, c.uninstallApp(c.RunUninstallImages)
+	}
+	if len(c.RunNewImages) == 0 && c.resuming(processor.InstallProcessorName) {
+		c.RunNewImages = c.checkpoint.Images
+	}
 	if len(c.RunNewImages) != 0 {
 		logger.Debug("run new images: %+v", c.RunNewImages)
 		if appErr = c.installApp(c.RunNewImages); appErr != nil {
@@ -188,9 +247,56 @@ func (c *Applier) reconcileCluster() (clusterErr error, appErr error) {
 	}
 	mj, md := iputils.GetDiffHosts(c.ClusterCurrent.GetMasterIPAndPortList(), c.ClusterDesired.GetMasterIPAndPortList())
 	nj, nd := iputils.GetDiffHosts(c.ClusterCurrent.GetNodeIPAndPortList(), c.ClusterDesired.GetNodeIPAndPortList())
+	// hosts of the last failed run have been saved into the Clusterfile, take them from the checkpoint instead
+	if len(mj) == 0 && len(md) == 0 && len(nj) == 0 && len(nd) == 0 && c.resuming(processor.ScaleProcessorName) {
+		mj, md, nj, nd = c.checkpoint.MastersToJoin, c.checkpoint.MastersToDelete, c.checkpoint.NodesToJoin, c.checkpoint.NodesToDelete
+	}
 	return c.scaleCluster(mj, md, nj, nd), nil
 }
 
+func (c *Applier) loadCheckpoint() error {
+	if !processor.Resume {
+		return nil
+	}
+	cp, err := processor.LoadCheckpoint(c.ClusterDesired.Name)
+	if err != nil {
+		return err
+	}
+	if cp == nil {
+		logger.Info("no checkpoint found, nothing to resume")
+		return nil
+	}
+	logger.Info("resume %s from the last failed run, %d stages have been completed", cp.Processor, len(cp.Stages))
+	c.checkpoint = cp
+	return nil
+}
+
+func (c *Applier) resuming(processorName string) bool {
+	return c.checkpoint != nil && c.checkpoint.Processor == processorName
+}
+
+func (c *Applier) dryRun() error {
+	bder, err := buildah.New(c.ClusterDesired.Name)
+	if err != nil {
+		return err
+	}
+	p := &planner{
+		inspector: bder,
+		extraEnvs: processor.GetEnvs(c.Context),
+		configs:   c.ClusterFile.GetConfigs(),
+	}
+	var plan *Plan
+	if c.ClusterCurrent == nil || c.ClusterCurrent.CreationTimestamp.IsZero() {
+		plan, err = p.planCreate(c.ClusterDesired)
+	} else {
+		plan, err = p.planReconcile(c.ClusterCurrent, c.ClusterDesired, c.RunNewImages)
+	}
+	if err != nil {
+		return err
+	}
+	return plan.Print(os.Stdout, c.PlanOutput)
+}
+
 func (c *Applier) initCluster() error {
 	logger.Info("Start to create a new cluster: master %s, worker %s, registry %s", c.ClusterDesired.GetMasterIPList(), c.ClusterDesired.GetNodeIPList(), c.ClusterDesired.GetRegistryIP())
 	createProcessor, err := processor.NewCreateProcessor(c.Context, c.ClusterDesired.Name, c.ClusterFile)
@@ -224,6 +330,24 @@ func (c *Applier) installApp(images []string) error {
 	return nil
 }
 
+func (c *Applier) uninstallApp(images []string) error {
+	logger.Info("start to uninstall app in this cluster")
+	uninstallProcessor, err := processor.NewUninstallProcessor(c.ClusterFile, images)
+	if err != nil {
+		return err
+	}
+	return uninstallProcessor.Execute(c.ClusterDesired)
+}
+
+func (c *Applier) rollbackApp() error {
+	logger.Info("start to roll back app in this cluster")
+	rollbackProcessor, err := processor.NewRollbackProcessor(c.ClusterFile, *c.Rollback)
+	if err != nil {
+		return err
+	}
+	return rollbackProcessor.Execute(c.ClusterDesired)
+}
+
 func (c *Applier) scaleCluster(mj, md, nj, nd []string) error {
 	if len(mj) == 0 && len(md) == 0 && len(nj) == 0 && len(nd) == 0 {
 		logger.Info("no nodes that need to be scaled")
@@ -232,7 +356,7 @@ func (c *Applier) scaleCluster(mj, md, nj, nd []string) error {
 	logger.Info("start to scale this cluster")
 	logger.Debug("current cluster: master %s, worker %s", c.ClusterCurrent.GetMasterIPAndPortList(), c.ClusterCurrent.GetNodeIPAndPortList())
 	logger.Debug("desired cluster: master %s, worker %s", c.ClusterDesired.GetMasterIPAndPortList(), c.ClusterDesired.GetNodeIPAndPortList())
-	scaleProcessor, err := processor.NewScaleProcessor(c.ClusterFile, c.ClusterDesired.Name, c.ClusterDesired.Spec.Image, mj, md, nj, nd)
+	scaleProcessor, err := processor.NewScaleProcessor(c.ClusterFile, c.ClusterDesired.Name, c.ClusterDesired.Spec.Image, mj, md, nj, nd, c.DrainOptions)
 	if err != nil {
 		return err
 	}
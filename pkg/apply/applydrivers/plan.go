@@ -0,0 +1,266 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package applydrivers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/labring/sealos/pkg/apply/processor"
+	"github.com/labring/sealos/pkg/buildah"
+	"github.com/labring/sealos/pkg/guest"
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+	"github.com/labring/sealos/pkg/utils/iputils"
+	"github.com/labring/sealos/pkg/utils/maps"
+)
+
+const (
+	PlanOutputText = "text"
+	PlanOutputJSON = "json"
+
+	PlanActionCreate    = "create"
+	PlanActionReconcile = "reconcile"
+
+	// name of the container is unknown until the image is mounted
+	pendingContainerName = "<pending>"
+)
+
+// Plan describes the changes that an apply would make to the cluster.
+type Plan struct {
+	ClusterName     string              `json:"clusterName"`
+	Action          string              `json:"action"`
+	MastersToJoin   []string            `json:"mastersToJoin,omitempty"`
+	MastersToDelete []string            `json:"mastersToDelete,omitempty"`
+	NodesToJoin     []string            `json:"nodesToJoin,omitempty"`
+	NodesToDelete   []string            `json:"nodesToDelete,omitempty"`
+	ImagesToMount   []string            `json:"imagesToMount,omitempty"`
+	Upgrade         *UpgradePlan        `json:"upgrade,omitempty"`
+	GuestCommands   []guest.HostCommand `json:"guestCommands,omitempty"`
+	Configs         []ConfigPatch       `json:"configs,omitempty"`
+}
+
+type UpgradePlan struct {
+	Image string `json:"image"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+type ConfigPatch struct {
+	Name     string `json:"name"`
+	Image    string `json:"image"`
+	Path     string `json:"path"`
+	Strategy string `json:"strategy,omitempty"`
+}
+
+type imageInspector interface {
+	InspectImage(imgName string, opts ...string) (*buildah.InspectOutput, error)
+}
+
+// IsEmpty returns true if nothing would be changed.
+func (p *Plan) IsEmpty() bool {
+	return len(p.MastersToJoin) == 0 && len(p.MastersToDelete) == 0 &&
+		len(p.NodesToJoin) == 0 && len(p.NodesToDelete) == 0 &&
+		len(p.ImagesToMount) == 0 && p.Upgrade == nil
+}
+
+// Print writes the plan in the given format, text or json.
+func (p *Plan) Print(w io.Writer, format string) error {
+	switch format {
+	case PlanOutputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(p)
+	case PlanOutputText, "":
+		p.printText(w)
+		return nil
+	}
+	return fmt.Errorf("unsupported output format %s, only %s and %s are supported", format, PlanOutputText, PlanOutputJSON)
+}
+
+func (p *Plan) printText(w io.Writer) {
+	fmt.Fprintf(w, "Plan for cluster %q (%s):\n", p.ClusterName, p.Action)
+	if p.IsEmpty() {
+		fmt.Fprintln(w, "  no changes")
+		return
+	}
+	printList := func(title string, items []string) {
+		if len(items) == 0 {
+			return
+		}
+		fmt.Fprintf(w, "  %s:\n", title)
+		for _, item := range items {
+			fmt.Fprintf(w, "    - %s\n", item)
+		}
+	}
+	printList("masters to join", p.MastersToJoin)
+	printList("masters to delete", p.MastersToDelete)
+	printList("nodes to join", p.NodesToJoin)
+	printList("nodes to delete", p.NodesToDelete)
+	printList("images to mount", p.ImagesToMount)
+	if p.Upgrade != nil {
+		fmt.Fprintf(w, "  upgrade:\n    - %s: %s -> %s\n", p.Upgrade.Image, p.Upgrade.From, p.Upgrade.To)
+	}
+	if len(p.Configs) > 0 {
+		fmt.Fprintln(w, "  config patches:")
+		for _, c := range p.Configs {
+			strategy := c.Strategy
+			if strategy == "" {
+				strategy = "override"
+			}
+			fmt.Fprintf(w, "    - %s: %s in %s (%s)\n", c.Name, c.Path, c.Image, strategy)
+		}
+	}
+	if len(p.GuestCommands) > 0 {
+		fmt.Fprintln(w, "  guest commands:")
+		for _, c := range p.GuestCommands {
+			fmt.Fprintf(w, "    - [%s] %s: %s\n", c.Host, c.Image, c.Command)
+		}
+	}
+}
+
+type planner struct {
+	inspector imageInspector
+	extraEnvs map[string]string
+	configs   []v2.Config
+}
+
+// planCreate plans to create a new cluster with all desired hosts and images.
+func (p *planner) planCreate(desired *v2.Cluster) (*Plan, error) {
+	plan := &Plan{
+		ClusterName:   desired.Name,
+		Action:        PlanActionCreate,
+		MastersToJoin: desired.GetMasterIPAndPortList(),
+		NodesToJoin:   desired.GetNodeIPAndPortList(),
+		ImagesToMount: desired.Spec.Image,
+	}
+	cluster := desired.DeepCopy()
+	mounts, err := p.mountImages(cluster, desired.Spec.Image)
+	if err != nil {
+		return nil, err
+	}
+	cluster.Status.Mounts = mounts
+	plan.Configs = p.configPatches(mounts)
+	plan.GuestCommands = guest.Commands(cluster, mounts, cluster.GetAllIPS())
+	return plan, nil
+}
+
+// planReconcile plans to install the new images into and then scale the current cluster.
+func (p *planner) planReconcile(current, desired *v2.Cluster, newImages []string) (*Plan, error) {
+	plan := &Plan{
+		ClusterName: desired.Name,
+		Action:      PlanActionReconcile,
+	}
+	cluster := desired.DeepCopy()
+	cluster.Status = *current.Status.DeepCopy()
+	currentRootfs := current.GetRootfsImage()
+	if len(newImages) > 0 {
+		toMount := make([]string, 0)
+		for _, img := range newImages {
+			// mounted images are skipped unless they are forced to be overridden
+			if _, m := cluster.FindImage(img); m != nil && !processor.ForceOverride {
+				continue
+			}
+			toMount = append(toMount, img)
+		}
+		mounts, err := p.mountImages(cluster, toMount)
+		if err != nil {
+			return nil, err
+		}
+		plan.ImagesToMount = toMount
+		plan.Upgrade = planUpgrade(currentRootfs, mounts)
+		plan.Configs = p.configPatches(mounts)
+		plan.GuestCommands = guest.Commands(cluster, mounts, cluster.GetAllIPS())
+	}
+
+	plan.MastersToJoin, plan.MastersToDelete = iputils.GetDiffHosts(current.GetMasterIPAndPortList(), desired.GetMasterIPAndPortList())
+	plan.NodesToJoin, plan.NodesToDelete = iputils.GetDiffHosts(current.GetNodeIPAndPortList(), desired.GetNodeIPAndPortList())
+	if joined := append(plan.MastersToJoin, plan.NodesToJoin...); len(joined) > 0 {
+		var mounts []v2.MountImage
+		for _, m := range cluster.Status.Mounts {
+			if !m.IsApplication() {
+				mounts = append(mounts, m)
+			}
+		}
+		plan.GuestCommands = append(plan.GuestCommands, guest.Commands(cluster, mounts, joined)...)
+	}
+	return plan, nil
+}
+
+func (p *planner) mountImages(cluster *v2.Cluster, images []string) ([]v2.MountImage, error) {
+	mounts := make([]v2.MountImage, 0, len(images))
+	for _, img := range images {
+		mount := &v2.MountImage{
+			Name:      pendingContainerName,
+			ImageName: img,
+		}
+		if err := processor.OCIToImageMount(p.inspector, mount); err != nil {
+			return nil, fmt.Errorf("failed to inspect image %s: %v", img, err)
+		}
+		mount.Env = maps.Merge(mount.Env, p.extraEnvs)
+		mounts = append(mounts, *mount)
+	}
+	for _, m := range mounts {
+		if index, _ := cluster.FindImage(m.ImageName); index >= 0 {
+			cluster.Status.Mounts[index] = m
+		} else {
+			cluster.Status.Mounts = append(cluster.Status.Mounts, m)
+		}
+	}
+	return mounts, nil
+}
+
+func (p *planner) configPatches(mounts []v2.MountImage) []ConfigPatch {
+	images := sets.NewString()
+	for _, m := range mounts {
+		images.Insert(m.ImageName)
+	}
+	ret := make([]ConfigPatch, 0)
+	for _, cfg := range p.configs {
+		targets := images.List()
+		if cfg.Spec.Match != "" {
+			if !images.Has(cfg.Spec.Match) {
+				continue
+			}
+			targets = []string{cfg.Spec.Match}
+		}
+		for _, img := range targets {
+			ret = append(ret, ConfigPatch{
+				Name:     cfg.Name,
+				Image:    img,
+				Path:     cfg.Spec.Path,
+				Strategy: string(cfg.Spec.Strategy),
+			})
+		}
+	}
+	return ret
+}
+
+func planUpgrade(current *v2.MountImage, mounts []v2.MountImage) *UpgradePlan {
+	if current == nil {
+		return nil
+	}
+	for _, m := range mounts {
+		version := m.KubeVersion()
+		if version == "" || strings.EqualFold(version, current.KubeVersion()) {
+			continue
+		}
+		return &UpgradePlan{Image: m.ImageName, From: current.KubeVersion(), To: version}
+	}
+	return nil
+}
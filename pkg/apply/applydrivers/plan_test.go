@@ -0,0 +1,127 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package applydrivers
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+)
+
+func newRootfsMount(image, version string) v2.MountImage {
+	return v2.MountImage{
+		ImageName: image,
+		Type:      v2.RootfsImage,
+		Labels:    map[string]string{v2.ImageKubeVersionKey: version},
+	}
+}
+
+func TestPlanUpgrade(t *testing.T) {
+	current := newRootfsMount("labring/kubernetes:v1.25.0", "v1.25.0")
+	tests := []struct {
+		name   string
+		mounts []v2.MountImage
+		want   *UpgradePlan
+	}{
+		{
+			name:   "same version",
+			mounts: []v2.MountImage{newRootfsMount("labring/kubernetes:v1.25.0", "v1.25.0")},
+		},
+		{
+			name:   "application only",
+			mounts: []v2.MountImage{{ImageName: "labring/helm:v3.8.2", Type: v2.AppImage}},
+		},
+		{
+			name:   "newer version",
+			mounts: []v2.MountImage{newRootfsMount("labring/kubernetes:v1.26.0", "v1.26.0")},
+			want:   &UpgradePlan{Image: "labring/kubernetes:v1.26.0", From: "v1.25.0", To: "v1.26.0"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := planUpgrade(&current, tt.mounts); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("planUpgrade() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlanner_configPatches(t *testing.T) {
+	p := &planner{
+		configs: []v2.Config{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "all"},
+				Spec:       v2.ConfigSpec{Path: "etc/all.yaml"},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "helm"},
+				Spec:       v2.ConfigSpec{Match: "labring/helm:v3.8.2", Path: "etc/helm.yaml", Strategy: v2.Merge},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "unmatched"},
+				Spec:       v2.ConfigSpec{Match: "labring/calico:v3.24.1", Path: "etc/calico.yaml"},
+			},
+		},
+	}
+	got := p.configPatches([]v2.MountImage{{ImageName: "labring/helm:v3.8.2"}})
+	want := []ConfigPatch{
+		{Name: "all", Image: "labring/helm:v3.8.2", Path: "etc/all.yaml"},
+		{Name: "helm", Image: "labring/helm:v3.8.2", Path: "etc/helm.yaml", Strategy: string(v2.Merge)},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("configPatches() = %+v, want %+v", got, want)
+	}
+}
+
+func TestPlan_Print(t *testing.T) {
+	plan := &Plan{
+		ClusterName:   "default",
+		Action:        PlanActionReconcile,
+		NodesToJoin:   []string{"192.168.0.3:22"},
+		ImagesToMount: []string{"labring/helm:v3.8.2"},
+	}
+
+	buf := &bytes.Buffer{}
+	if err := plan.Print(buf, PlanOutputText); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"nodes to join", "192.168.0.3:22", "labring/helm:v3.8.2"} {
+		if !strings.Contains(buf.String(), s) {
+			t.Errorf("text output %q does not contain %q", buf.String(), s)
+		}
+	}
+
+	buf.Reset()
+	if err := plan.Print(buf, PlanOutputJSON); err != nil {
+		t.Fatal(err)
+	}
+	got := &Plan{}
+	if err := json.Unmarshal(buf.Bytes(), got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, plan) {
+		t.Errorf("json output = %+v, want %+v", got, plan)
+	}
+
+	if err := plan.Print(buf, "yaml"); err == nil {
+		t.Errorf("expect error for unsupported output format")
+	}
+}
@@ -22,7 +22,9 @@ import (
 
 	"github.com/spf13/pflag"
 
+	"github.com/labring/sealos/pkg/apply/applydrivers"
 	"github.com/labring/sealos/pkg/constants"
+	"github.com/labring/sealos/pkg/runtime"
 )
 
 type Cluster struct {
@@ -70,11 +72,22 @@ func (arg *RunArgs) RegisterFlags(fs *pflag.FlagSet) {
 	fs.StringSliceVar(&arg.CustomConfigFiles, "config-file", []string{}, "path of custom config files, to use to replace the resource")
 }
 
+type DryRun struct {
+	Enabled bool
+	Output  string
+}
+
+func (d *DryRun) RegisterFlags(fs *pflag.FlagSet) {
+	fs.BoolVar(&d.Enabled, "dry-run", false, "print the plan of changes without touching any host")
+	fs.StringVarP(&d.Output, "output", "o", applydrivers.PlanOutputText, "output format of the dry run plan, one of text|json")
+}
+
 type Args struct {
 	Values            []string
 	Sets              []string
 	CustomEnv         []string
 	CustomConfigFiles []string
+	DryRun            DryRun
 }
 
 func (arg *Args) RegisterFlags(fs *pflag.FlagSet) {
@@ -82,6 +95,7 @@ func (arg *Args) RegisterFlags(fs *pflag.FlagSet) {
 	fs.StringSliceVar(&arg.Sets, "set", []string{}, "set values on the command line")
 	fs.StringSliceVar(&arg.CustomEnv, "env", []string{}, "environment variables to be set for images")
 	fs.StringSliceVar(&arg.CustomConfigFiles, "config-file", []string{}, "path of custom config files, to use to replace the resource")
+	arg.DryRun.RegisterFlags(fs)
 }
 
 type ResetArgs struct {
@@ -94,15 +108,46 @@ func (arg *ResetArgs) RegisterFlags(fs *pflag.FlagSet) {
 	arg.SSH.RegisterFlags(fs)
 }
 
+type UninstallArgs struct {
+	ClusterName string
+	*SSH
+}
+
+func (arg *UninstallArgs) RegisterFlags(fs *pflag.FlagSet) {
+	fs.StringVarP(&arg.ClusterName, "cluster", "c", "default", "name of cluster to applied uninstall action")
+	arg.SSH.RegisterFlags(fs)
+}
+
+type RollbackArgs struct {
+	ClusterName string
+	ToRevision  int64
+	*SSH
+}
+
+func (arg *RollbackArgs) RegisterFlags(fs *pflag.FlagSet) {
+	fs.StringVarP(&arg.ClusterName, "cluster", "c", "default", "name of cluster to applied rollback action")
+	fs.Int64Var(&arg.ToRevision, "to-revision", 0, "the revision to roll back to, default to the previous revision")
+	arg.SSH.RegisterFlags(fs)
+}
+
 type ScaleArgs struct {
 	*Cluster
 	*SSH
+	DryRun DryRun
+	// Drain is only set for the delete cmd, which drains the nodes before removing them
+	Drain *runtime.DrainOptions
 }
 
 func (arg *ScaleArgs) RegisterFlags(fs *pflag.FlagSet, verb, action string) {
 	arg.Cluster.RegisterFlags(fs, verb, action)
+	arg.DryRun.RegisterFlags(fs)
 	// delete cmd does not support setting ssh, it reads from clusterfile
 	if arg.SSH != nil {
 		arg.SSH.RegisterFlags(fs)
 	}
+	if arg.Drain != nil {
+		fs.DurationVar(&arg.Drain.Timeout, "drain-timeout", arg.Drain.Timeout, "the length of time to wait before giving up draining a node, zero means infinite")
+		fs.BoolVar(&arg.Drain.IgnoreErrors, "ignore-drain-errors", false, "remove the nodes even if draining them failed")
+		fs.BoolVar(&arg.Drain.Force, "force-drain", false, "also delete the pods not managed by any controller when draining nodes, these pods are lost")
+	}
 }
@@ -148,6 +148,20 @@ func TestParseArgsFlagsCorrect(t *testing.T) {
 				CustomEnv:         []string{"testk=testv"},
 				Sets:              []string{"test.enabled=true"},
 				CustomConfigFiles: []string{},
+				DryRun:            DryRun{Output: "text"},
+			},
+		},
+		{
+			[]string{
+				"--dry-run", "-o", "json",
+			},
+			&Args{},
+			&Args{
+				Values:            []string{},
+				CustomEnv:         []string{},
+				Sets:              []string{},
+				CustomConfigFiles: []string{},
+				DryRun:            DryRun{Enabled: true, Output: "json"},
 			},
 		},
 	}
@@ -222,6 +236,21 @@ func TestParseScaleArgsFlagsCorrect(t *testing.T) {
 			&ScaleArgs{
 				Cluster: &Cluster{Masters: "10.74.22.22:22", Nodes: "10.74.22.44:22", ClusterName: "default"},
 				SSH:     &SSH{User: "root", Password: "passwd", Port: 22, Pk: path.Join(constants.GetHomeDir(), ".ssh", "id_rsa")},
+				DryRun:  DryRun{Output: "text"},
+			},
+		},
+		{
+			[]string{
+				"--nodes", "10.74.22.44:22", "--dry-run", "--output", "json",
+			},
+			&ScaleArgs{
+				Cluster: &Cluster{},
+				SSH:     &SSH{},
+			},
+			&ScaleArgs{
+				Cluster: &Cluster{Nodes: "10.74.22.44:22", ClusterName: "default"},
+				SSH:     &SSH{Port: 22, Pk: path.Join(constants.GetHomeDir(), ".ssh", "id_rsa")},
+				DryRun:  DryRun{Enabled: true, Output: "json"},
 			},
 		},
 	}
@@ -0,0 +1,256 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/exp/slices"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/labring/sealos/pkg/constants"
+	"github.com/labring/sealos/pkg/ssh"
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+	"github.com/labring/sealos/pkg/utils/file"
+	"github.com/labring/sealos/pkg/utils/logger"
+	"github.com/labring/sealos/pkg/utils/yaml"
+)
+
+// Resume skips the pipeline stages recorded in the checkpoint of the last failed run.
+var Resume bool
+
+const (
+	CreateProcessorName  = "CreateProcessor"
+	InstallProcessorName = "InstallProcessor"
+	ScaleProcessorName   = "ScaleProcessor"
+)
+
+// PipelineStage is a named stage of a processor pipeline. The name identifies the stage in the
+// checkpoint file, so it must stay the same across versions.
+type PipelineStage struct {
+	Name string
+	Run  func(cluster *v2.Cluster) error
+}
+
+// preparingStages only prepare the in-memory state or check the hosts,
+// they are always executed even if they are recorded in the checkpoint.
+var preparingStages = sets.NewString(
+	"Check",
+	"JoinCheck",
+	"DeleteCheck",
+	"SyncStatusAndCheck",
+	"ConfirmOverrideApps",
+	"PreProcess",
+	"PreProcessImage",
+	"RunConfig",
+	"PostProcess",
+)
+
+// Checkpoint records the stages of a processor pipeline that have been completed,
+// it is stored in the cluster workdir and removed once the pipeline succeeds.
+type Checkpoint struct {
+	Processor       string   `json:"processor"`
+	Images          []string `json:"images,omitempty"`
+	MastersToJoin   []string `json:"mastersToJoin,omitempty"`
+	MastersToDelete []string `json:"mastersToDelete,omitempty"`
+	NodesToJoin     []string `json:"nodesToJoin,omitempty"`
+	NodesToDelete   []string `json:"nodesToDelete,omitempty"`
+	Stages          []Stage  `json:"stages,omitempty"`
+
+	clusterName string
+	resumed     bool
+}
+
+// Stage is a finished pipeline stage. The stages executed on many hosts, like Join and
+// RunGuest, record the hosts they have finished on, a partial stage failed on the other
+// hosts and is resumed on them only.
+type Stage struct {
+	Name       string      `json:"name"`
+	Hosts      []string    `json:"hosts,omitempty"`
+	Partial    bool        `json:"partial,omitempty"`
+	FinishedAt metav1.Time `json:"finishedAt"`
+}
+
+// LoadCheckpoint returns the checkpoint of the last failed run, or nil if there is none.
+func LoadCheckpoint(clusterName string) (*Checkpoint, error) {
+	fp := constants.Checkpoint(clusterName)
+	if !file.IsExist(fp) {
+		return nil, nil
+	}
+	cp := &Checkpoint{}
+	if err := yaml.UnmarshalFile(fp, cp); err != nil {
+		return nil, fmt.Errorf("failed to load checkpoint %s: %v", fp, err)
+	}
+	cp.clusterName = clusterName
+	return cp, nil
+}
+
+func newCheckpoint(clusterName, processor string, images, mastersToJoin, mastersToDelete, nodesToJoin, nodesToDelete []string) *Checkpoint {
+	return &Checkpoint{
+		Processor:       processor,
+		Images:          images,
+		MastersToJoin:   mastersToJoin,
+		MastersToDelete: mastersToDelete,
+		NodesToJoin:     nodesToJoin,
+		NodesToDelete:   nodesToDelete,
+		clusterName:     clusterName,
+	}
+}
+
+func (cp *Checkpoint) sameInputs(o *Checkpoint) bool {
+	return cp.Processor == o.Processor &&
+		slices.Equal(cp.Images, o.Images) &&
+		slices.Equal(cp.MastersToJoin, o.MastersToJoin) &&
+		slices.Equal(cp.MastersToDelete, o.MastersToDelete) &&
+		slices.Equal(cp.NodesToJoin, o.NodesToJoin) &&
+		slices.Equal(cp.NodesToDelete, o.NodesToDelete)
+}
+
+// Resumed returns true if the completed stages of the last failed run are going to be skipped.
+func (cp *Checkpoint) Resumed() bool {
+	return cp.resumed
+}
+
+func (cp *Checkpoint) stage(name string) *Stage {
+	for i := range cp.Stages {
+		if cp.Stages[i].Name == name {
+			return &cp.Stages[i]
+		}
+	}
+	return nil
+}
+
+func (cp *Checkpoint) completed(name string) bool {
+	s := cp.stage(name)
+	return s != nil && !s.Partial
+}
+
+// record replaces the record of the stage, the hosts are kept if it's nil.
+func (cp *Checkpoint) record(name string, hosts []string, partial bool) {
+	s := cp.stage(name)
+	if s == nil {
+		cp.Stages = append(cp.Stages, Stage{Name: name})
+		s = &cp.Stages[len(cp.Stages)-1]
+	}
+	if hosts != nil {
+		s.Hosts = hosts
+	}
+	s.Partial = partial
+	s.FinishedAt = metav1.Now()
+	if err := cp.save(); err != nil {
+		logger.Warn("failed to save checkpoint: %v", err)
+	}
+}
+
+// runOnHosts runs the stage f only on the hosts it has not finished on in the last failed run,
+// and records the hosts it finishes on, including the ones of a *ssh.HostsError if it fails
+// on some of the hosts.
+func (cp *Checkpoint) runOnHosts(name string, hosts []string, f func(hosts []string) error) error {
+	var finished []string
+	if s := cp.stage(name); s != nil {
+		finished = s.Hosts
+	}
+	pending := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		if !slices.Contains(finished, host) {
+			pending = append(pending, host)
+		}
+	}
+	if len(finished) > 0 {
+		logger.Info("Resuming pipeline %s in %s on hosts %v, it has been finished on hosts %v", name, cp.Processor, pending, finished)
+	}
+
+	err := f(pending)
+	var hostsErr *ssh.HostsError
+	switch {
+	case err == nil:
+		finished = append(finished, pending...)
+	case errors.As(err, &hostsErr):
+		finished = append(finished, hostsErr.Succeeded...)
+	default:
+		return err
+	}
+	cp.record(name, finished, err != nil)
+	return err
+}
+
+func (cp *Checkpoint) save() error {
+	return yaml.MarshalFile(constants.Checkpoint(cp.clusterName), cp)
+}
+
+func (cp *Checkpoint) remove() error {
+	if err := os.Remove(constants.Checkpoint(cp.clusterName)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// load picks up the completed stages of the last failed run if it was running with the same inputs.
+func (cp *Checkpoint) load() error {
+	if !Resume {
+		return nil
+	}
+	last, err := LoadCheckpoint(cp.clusterName)
+	if err != nil || last == nil {
+		return err
+	}
+	if !cp.sameInputs(last) {
+		logger.Warn("checkpoint of %s does not match the current %s, start over", last.Processor, cp.Processor)
+		return nil
+	}
+	cp.Stages = last.Stages
+	cp.resumed = true
+	return nil
+}
+
+// run executes the pipeline, skips the stages that have been completed in the last
+// failed run and records each completed stage into the checkpoint file.
+func (cp *Checkpoint) run(cluster *v2.Cluster, pipeline []PipelineStage) error {
+	if err := cp.load(); err != nil {
+		return err
+	}
+	for _, stage := range pipeline {
+		name := stage.Name
+		resumable := !preparingStages.Has(name)
+		if resumable && cp.resumed && cp.completed(name) {
+			logger.Info("Skipping pipeline %s in %s, it has been completed", name, cp.Processor)
+			continue
+		}
+		if err := stage.Run(cluster); err != nil {
+			return err
+		}
+		if !resumable {
+			continue
+		}
+		cp.record(name, nil, false)
+		cluster.Status.Conditions = v2.UpdateCondition(cluster.Status.Conditions,
+			v2.NewStageCompletedClusterCondition(cp.Processor, name, cp.stage(name).Hosts))
+	}
+	return cp.remove()
+}
+
+// filterHosts returns the hosts of the list which are in the given hosts.
+func filterHosts(list, hosts []string) []string {
+	var ret []string
+	for _, host := range list {
+		if slices.Contains(hosts, host) {
+			ret = append(ret, host)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,144 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/labring/sealos/pkg/constants"
+	"github.com/labring/sealos/pkg/ssh"
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+)
+
+type fakeProcessor struct {
+	cp       *Checkpoint
+	hosts    []string
+	executed []string
+	joinedOn []string
+	failAt   string
+	failHost string
+}
+
+func (f *fakeProcessor) exec(name string) error {
+	f.executed = append(f.executed, name)
+	if name == f.failAt {
+		return errors.New("ssh: connection reset by peer")
+	}
+	return nil
+}
+
+func (f *fakeProcessor) PreProcess(*v2.Cluster) error { return f.exec("PreProcess") }
+func (f *fakeProcessor) Bootstrap(*v2.Cluster) error  { return f.exec("Bootstrap") }
+
+func (f *fakeProcessor) Join(*v2.Cluster) error {
+	f.executed = append(f.executed, "Join")
+	return f.cp.runOnHosts("Join", f.hosts, func(hosts []string) error {
+		f.joinedOn = append(f.joinedOn, hosts...)
+		var succeeded []string
+		for _, host := range hosts {
+			if host != f.failHost {
+				succeeded = append(succeeded, host)
+			}
+		}
+		if len(succeeded) == len(hosts) {
+			return nil
+		}
+		return ssh.NewHostsError(succeeded, fmt.Errorf("failed to join node %s", f.failHost))
+	})
+}
+
+func (f *fakeProcessor) RunGuest(*v2.Cluster) error {
+	f.executed = append(f.executed, "RunGuest")
+	return f.cp.runOnHosts("RunGuest", f.hosts, func([]string) error { return nil })
+}
+
+func (f *fakeProcessor) run(cluster *v2.Cluster, nodes []string) error {
+	f.hosts = nodes
+	f.cp = newCheckpoint(cluster.Name, ScaleProcessorName, nil, nil, nil, nodes, nil)
+	return f.cp.run(cluster, []PipelineStage{
+		{"PreProcess", f.PreProcess},
+		{"Bootstrap", f.Bootstrap},
+		{"Join", f.Join},
+		{"RunGuest", f.RunGuest},
+	})
+}
+
+func TestCheckpoint_run(t *testing.T) {
+	defer func(dir string) { constants.DefaultRuntimeRootDir = dir }(constants.DefaultRuntimeRootDir)
+	defer func(resume bool) { Resume = resume }(Resume)
+	constants.DefaultRuntimeRootDir = t.TempDir()
+
+	cluster := &v2.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "default"}}
+	nodes := []string{"192.168.0.3:22", "192.168.0.4:22"}
+
+	// the first run fails in Join on one of the nodes
+	f := &fakeProcessor{failHost: "192.168.0.4:22"}
+	if err := f.run(cluster, nodes); err == nil {
+		t.Fatalf("expect the first run to fail")
+	}
+	cp, err := LoadCheckpoint(cluster.Name)
+	if err != nil || cp == nil {
+		t.Fatalf("failed to load checkpoint: %v", err)
+	}
+	if len(cp.Stages) != 2 || cp.Stages[0].Name != "Bootstrap" || len(cp.Stages[0].Hosts) != 0 || cp.Stages[0].Partial {
+		t.Errorf("unexpected stages in checkpoint: %+v", cp.Stages)
+	}
+	if join := cp.Stages[1]; join.Name != "Join" || !join.Partial || !reflect.DeepEqual(join.Hosts, nodes[:1]) {
+		t.Errorf("unexpected Join stage in checkpoint: %+v", join)
+	}
+	if len(cluster.Status.Conditions) != 1 || cluster.Status.Conditions[0].Type != v2.ClusterConditionTypeStage {
+		t.Errorf("unexpected conditions: %+v", cluster.Status.Conditions)
+	}
+
+	// a resumed run with different inputs starts over
+	Resume = true
+	f = &fakeProcessor{failAt: "Bootstrap"}
+	_ = f.run(cluster, []string{"192.168.0.5:22"})
+	if want := []string{"PreProcess", "Bootstrap"}; !reflect.DeepEqual(f.executed, want) {
+		t.Errorf("executed %v, want %v", f.executed, want)
+	}
+
+	// a failed run of the same inputs leaves the checkpoint to resume from
+	Resume = false
+	f = &fakeProcessor{failHost: "192.168.0.4:22"}
+	_ = f.run(cluster, nodes)
+
+	// the resumed run skips the completed stages and the hosts Join has finished on, but always prepares
+	Resume = true
+	f = &fakeProcessor{}
+	if err = f.run(cluster, nodes); err != nil {
+		t.Fatal(err)
+	}
+	if !f.cp.Resumed() {
+		t.Errorf("checkpoint should be resumed")
+	}
+	if want := []string{"PreProcess", "Join", "RunGuest"}; !reflect.DeepEqual(f.executed, want) {
+		t.Errorf("executed %v, want %v", f.executed, want)
+	}
+	if want := nodes[1:]; !reflect.DeepEqual(f.joinedOn, want) {
+		t.Errorf("joined on %v, want %v", f.joinedOn, want)
+	}
+	if cp, _ = LoadCheckpoint(cluster.Name); cp != nil {
+		t.Errorf("checkpoint should be removed after succeeded")
+	}
+	if msg := cluster.Status.Conditions[0].Message; msg != "Stage RunGuest completed on hosts 192.168.0.3:22,192.168.0.4:22" {
+		t.Errorf("unexpected condition message: %s", msg)
+	}
+}
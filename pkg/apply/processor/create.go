@@ -42,6 +42,7 @@ type CreateProcessor struct {
 	Runtime     runtime.Interface
 	Guest       guest.Interface
 	ExtraEnvs   map[string]string // parsing from CLI arguments
+	checkpoint  *Checkpoint
 }
 
 func (c *CreateProcessor) Execute(cluster *v2.Cluster) error {
@@ -49,32 +50,27 @@ func (c *CreateProcessor) Execute(cluster *v2.Cluster) error {
 	if err != nil {
 		return err
 	}
-	for _, f := range pipeLine {
-		if err = f(cluster); err != nil {
-			return err
-		}
-	}
-
-	return nil
+	c.checkpoint = newCheckpoint(cluster.Name, CreateProcessorName, cluster.Spec.Image,
+		cluster.GetMasterIPAndPortList(), nil, cluster.GetNodeIPAndPortList(), nil)
+	return c.checkpoint.run(cluster, pipeLine)
 }
 
-func (c *CreateProcessor) GetPipeLine() ([]func(cluster *v2.Cluster) error, error) {
-	var todoList []func(cluster *v2.Cluster) error
-	todoList = append(todoList,
+func (c *CreateProcessor) GetPipeLine() ([]PipelineStage, error) {
+	todoList := []PipelineStage{
 		// c.GetPhasePluginFunc(plugin.PhaseOriginally),
-		c.Check,
-		c.PreProcess,
-		c.RunConfig,
-		c.MountRootfs,
-		c.MirrorRegistry,
-		c.Bootstrap,
+		{"Check", c.Check},
+		{"PreProcess", c.PreProcess},
+		{"RunConfig", c.RunConfig},
+		{"MountRootfs", c.MountRootfs},
+		{"MirrorRegistry", c.MirrorRegistry},
+		{"Bootstrap", c.Bootstrap},
 		// c.GetPhasePluginFunc(plugin.PhasePreInit),
-		c.Init,
-		c.Join,
+		{"Init", c.Init},
+		{"Join", c.Join},
 		// c.GetPhasePluginFunc(plugin.PhasePreGuest),
-		c.RunGuest,
+		{"RunGuest", c.RunGuest},
 		// c.GetPhasePluginFunc(plugin.PhasePostInstall),
-	)
+	}
 
 	return todoList, nil
 }
@@ -155,7 +151,10 @@ func (c *CreateProcessor) Init(_ *v2.Cluster) error {
 
 func (c *CreateProcessor) Join(cluster *v2.Cluster) error {
 	logger.Info("Executing pipeline Join in CreateProcessor.")
-	err := c.Runtime.ScaleUp(cluster.GetMasterIPAndPortList()[1:], cluster.GetNodeIPAndPortList())
+	masters, nodes := cluster.GetMasterIPAndPortList()[1:], cluster.GetNodeIPAndPortList()
+	err := c.checkpoint.runOnHosts("Join", append(append([]string{}, masters...), nodes...), func(hosts []string) error {
+		return c.Runtime.ScaleUp(filterHosts(masters, hosts), filterHosts(nodes, hosts))
+	})
 	if err != nil {
 		return err
 	}
@@ -168,10 +167,13 @@ func (c *CreateProcessor) Join(cluster *v2.Cluster) error {
 
 func (c *CreateProcessor) RunGuest(cluster *v2.Cluster) error {
 	logger.Info("Executing pipeline RunGuest in CreateProcessor.")
-	err := c.Guest.Apply(cluster, cluster.Status.Mounts, cluster.GetAllIPS())
+	err := c.checkpoint.runOnHosts("RunGuest", cluster.GetAllIPS(), func(hosts []string) error {
+		return c.Guest.Apply(cluster, cluster.Status.Mounts, hosts)
+	})
 	if err != nil {
 		return fmt.Errorf("%s: %w", RunGuestFailed, err)
 	}
+	recordRevisions(cluster, c.Buildah, cluster.Status.Mounts)
 	return nil
 }
 
@@ -49,6 +49,7 @@ type InstallProcessor struct {
 	NewImages        []string
 	ExtraEnvs        map[string]string // parsing from CLI arguments
 	imagesToOverride []string
+	checkpoint       *Checkpoint
 }
 
 func (c *InstallProcessor) Execute(cluster *v2.Cluster) error {
@@ -56,31 +57,24 @@ func (c *InstallProcessor) Execute(cluster *v2.Cluster) error {
 	if err != nil {
 		return err
 	}
-
-	for _, f := range pipLine {
-		if err = f(cluster); err != nil {
-			return err
-		}
-	}
-
-	return nil
+	c.checkpoint = newCheckpoint(cluster.Name, InstallProcessorName, c.NewImages, nil, nil, nil, nil)
+	return c.checkpoint.run(cluster, pipLine)
 }
 
-func (c *InstallProcessor) GetPipeLine() ([]func(cluster *v2.Cluster) error, error) {
-	var todoList []func(cluster *v2.Cluster) error
-	todoList = append(todoList,
-		c.SyncStatusAndCheck,
-		c.ConfirmOverrideApps,
-		c.PreProcess,
-		c.RunConfig,
-		c.MountRootfs,
-		c.MirrorRegistry,
-		c.UpgradeIfNeed,
+func (c *InstallProcessor) GetPipeLine() ([]PipelineStage, error) {
+	todoList := []PipelineStage{
+		{"SyncStatusAndCheck", c.SyncStatusAndCheck},
+		{"ConfirmOverrideApps", c.ConfirmOverrideApps},
+		{"PreProcess", c.PreProcess},
+		{"RunConfig", c.RunConfig},
+		{"MountRootfs", c.MountRootfs},
+		{"MirrorRegistry", c.MirrorRegistry},
+		{"UpgradeIfNeed", c.UpgradeIfNeed},
 		// i.GetPhasePluginFunc(plugin.PhasePreGuest),
-		c.RunGuest,
-		c.PostProcess,
+		{"RunGuest", c.RunGuest},
+		{"PostProcess", c.PostProcess},
 		// i.GetPhasePluginFunc(plugin.PhasePostInstall),
-	)
+	}
 	return todoList, nil
 }
 
@@ -94,6 +88,10 @@ func (c *InstallProcessor) SyncStatusAndCheck(_ *v2.Cluster) error {
 	if err = SyncClusterStatus(current, c.Buildah, false); err != nil {
 		return err
 	}
+	// images of a resumed run have been saved into the Clusterfile by the last failed run
+	if c.checkpoint.Resumed() {
+		return nil
+	}
 	imageList := sets.NewString(current.Spec.Image...)
 	for _, img := range c.NewImages {
 		if imageList.Has(img) {
@@ -163,6 +161,14 @@ func (c *InstallProcessor) PreProcess(cluster *v2.Cluster) error {
 		index, mount := cluster.FindImage(img)
 		var ctrName string
 		if mount != nil {
+			if c.checkpoint.Resumed() {
+				// reuse the image mounted by the last failed run
+				m := mount.DeepCopy()
+				m.Env = maps.Merge(m.Env, c.ExtraEnvs)
+				cluster.Status.Mounts[index] = *m
+				c.NewMounts = append(c.NewMounts, *m)
+				continue
+			}
 			if !ForceOverride {
 				continue
 			}
@@ -267,7 +273,13 @@ func (c *InstallProcessor) RunGuest(cluster *v2.Cluster) error {
 	if len(c.NewMounts) == 0 {
 		return nil
 	}
-	return c.Guest.Apply(cluster, c.NewMounts, cluster.GetAllIPS())
+	if err := c.checkpoint.runOnHosts("RunGuest", cluster.GetAllIPS(), func(hosts []string) error {
+		return c.Guest.Apply(cluster, c.NewMounts, hosts)
+	}); err != nil {
+		return err
+	}
+	recordRevisions(cluster, c.Buildah, c.NewMounts)
+	return nil
 }
 
 func NewInstallProcessor(ctx context.Context, clusterFile clusterfile.Interface, images []string) (Interface, error) {
@@ -0,0 +1,366 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/labring/sealos/pkg/buildah"
+	"github.com/labring/sealos/pkg/clusterfile"
+	"github.com/labring/sealos/pkg/config"
+	"github.com/labring/sealos/pkg/filesystem/rootfs"
+	"github.com/labring/sealos/pkg/guest"
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+	"github.com/labring/sealos/pkg/utils/confirm"
+	"github.com/labring/sealos/pkg/utils/logger"
+	"github.com/labring/sealos/pkg/utils/maps"
+	"github.com/labring/sealos/pkg/utils/rand"
+	stringsutil "github.com/labring/sealos/pkg/utils/strings"
+)
+
+var ForceRollback bool
+
+type RollbackOptions struct {
+	// Image is the image or repository of the application to roll back, the images of
+	// the last failed command are used if empty.
+	Image string
+	// ToRevision is the revision to roll back to, the previous one if zero.
+	ToRevision int64
+}
+
+// RollbackProcessor re-mounts a previous revision of an application recorded in the
+// image history of the cluster status and runs its CMD again.
+type RollbackProcessor struct {
+	ClusterFile clusterfile.Interface
+	Buildah     buildah.Interface
+	Guest       guest.Interface
+	Options     RollbackOptions
+
+	app string
+	// current is the last mounted image of the app, e.g. the one failed to be installed
+	current *v2.MountImage
+	// stale are the other mounted images of the app, they are removed after rolling back
+	stale    []v2.MountImage
+	target   *v2.ImageRevision
+	newMount *v2.MountImage
+	newID    string
+	done     bool
+}
+
+func (c *RollbackProcessor) Execute(cluster *v2.Cluster) error {
+	pipLine, err := c.GetPipeLine()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// the container of the target revision is useless if it has not been applied
+		if c.newMount != nil && !c.done {
+			if err := c.Buildah.Delete(c.newMount.Name); err != nil {
+				logger.Warn("failed to delete container %s: %v", c.newMount.Name, err)
+			}
+		}
+	}()
+	for _, f := range pipLine {
+		if err = f(cluster); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *RollbackProcessor) GetPipeLine() ([]func(cluster *v2.Cluster) error, error) {
+	var todoList []func(cluster *v2.Cluster) error
+	todoList = append(todoList,
+		c.SyncStatusAndCheck,
+		c.ConfirmRollback,
+		c.PreProcess,
+		c.RunConfig,
+		c.MountRootfs,
+		c.MirrorRegistry,
+		c.RunGuest,
+		c.PostProcess,
+	)
+	return todoList, nil
+}
+
+func (c *RollbackProcessor) SyncStatusAndCheck(cluster *v2.Cluster) error {
+	logger.Info("Executing SyncStatusAndCheck Pipeline in RollbackProcessor")
+	if err := SyncClusterStatus(cluster, c.Buildah, false); err != nil {
+		return err
+	}
+	app, err := rollbackApp(cluster, c.Options.Image)
+	if err != nil {
+		return err
+	}
+	c.app = app
+	// a failed image is appended after the installed one of the same app, so the last one
+	// is the current revision
+	var mounts []v2.MountImage
+	for _, m := range cluster.Status.Mounts {
+		if v2.ImageRepository(m.ImageName) != app {
+			continue
+		}
+		if !m.IsApplication() {
+			return fmt.Errorf("image %s is a %s image, only application images can be rolled back", m.ImageName, m.Type)
+		}
+		mounts = append(mounts, m)
+	}
+	var currentID string
+	if len(mounts) > 0 {
+		c.current = &mounts[len(mounts)-1]
+		c.stale = mounts[:len(mounts)-1]
+		if info, err := c.Buildah.InspectContainer(c.current.Name); err == nil {
+			currentID = info.FromImageID
+		}
+	}
+	target, err := rollbackRevision(cluster.GetImageHistory(app), c.current, currentID, c.Options.ToRevision)
+	if err != nil {
+		return err
+	}
+	c.target = target
+	return nil
+}
+
+func (c *RollbackProcessor) ConfirmRollback(_ *v2.Cluster) error {
+	logger.Info("Executing ConfirmRollback Pipeline in RollbackProcessor")
+	if ForceRollback {
+		return nil
+	}
+	from := "<none>"
+	if c.current != nil {
+		from = c.current.ImageName
+	}
+	prompt := fmt.Sprintf("are you sure to roll back app %s from %s to revision %d (%s)?", c.app, from, c.target.Revision, c.target.ImageName)
+	if len(c.stale) > 0 {
+		stale := make([]string, 0, len(c.stale))
+		for _, m := range c.stale {
+			stale = append(stale, m.ImageName)
+		}
+		prompt = fmt.Sprintf("%s the other images %v of the app will be removed as well.", prompt, stale)
+	}
+	pass, err := confirm.Confirm(prompt, "you have canceled to roll back this app")
+	if err != nil {
+		return err
+	}
+	if !pass {
+		return ErrCancelled
+	}
+	return nil
+}
+
+func (c *RollbackProcessor) PreProcess(_ *v2.Cluster) error {
+	logger.Info("Executing PreProcess Pipeline in RollbackProcessor")
+	ref := c.target.ImageID
+	if ref == "" || !c.imageExists(ref) {
+		// the image has been removed from local storage, pull it by digest to get the same content
+		ref = c.target.ImageName
+		if c.target.Digest != "" {
+			ref = fmt.Sprintf("%s@%s", v2.ImageRepository(c.target.ImageName), c.target.Digest)
+		}
+		if err := c.Buildah.Pull([]string{ref}, buildah.WithPullPolicyOption(buildah.PullIfMissing.String())); err != nil {
+			return err
+		}
+	}
+	bderInfo, err := c.Buildah.Create(rand.Generator(8), ref)
+	if err != nil {
+		return err
+	}
+	mount := &v2.MountImage{
+		Name:       bderInfo.Container,
+		MountPoint: bderInfo.MountPoint,
+		ImageName:  ref,
+	}
+	c.newMount = mount
+	c.newID = bderInfo.FromImageID
+	if err = OCIToImageMount(c.Buildah, mount); err != nil {
+		return err
+	}
+	mount.ImageName = c.target.ImageName
+	mount.Env = maps.Merge(mount.Env, c.target.Env)
+	return nil
+}
+
+func (c *RollbackProcessor) imageExists(ref string) bool {
+	_, err := c.Buildah.InspectImage(ref)
+	return err == nil
+}
+
+func (c *RollbackProcessor) RunConfig(_ *v2.Cluster) error {
+	cfg := config.NewConfiguration(c.newMount.ImageName, c.newMount.MountPoint, c.ClusterFile.GetConfigs())
+	return cfg.Dump()
+}
+
+func (c *RollbackProcessor) MountRootfs(cluster *v2.Cluster) error {
+	logger.Info("Executing pipeline MountRootfs in RollbackProcessor.")
+	hosts := append(cluster.GetMasterIPAndPortList(), cluster.GetNodeIPAndPortList()...)
+	fs, err := rootfs.NewRootfsMounter([]v2.MountImage{*c.newMount})
+	if err != nil {
+		return err
+	}
+	return fs.MountRootfs(cluster, hosts)
+}
+
+func (c *RollbackProcessor) MirrorRegistry(cluster *v2.Cluster) error {
+	logger.Info("Executing pipeline MirrorRegistry in RollbackProcessor.")
+	return MirrorRegistry(cluster, []v2.MountImage{*c.newMount})
+}
+
+func (c *RollbackProcessor) RunGuest(cluster *v2.Cluster) error {
+	return c.Guest.Apply(cluster, []v2.MountImage{*c.newMount}, cluster.GetAllIPS())
+}
+
+func (c *RollbackProcessor) PostProcess(cluster *v2.Cluster) error {
+	// the mount of the target revision takes the place of the current one, the other
+	// mounts of the app are removed
+	replaced := false
+	mounts := make([]v2.MountImage, 0, len(cluster.Status.Mounts))
+	for _, m := range cluster.Status.Mounts {
+		if v2.ImageRepository(m.ImageName) != c.app {
+			mounts = append(mounts, m)
+			continue
+		}
+		if err := c.Buildah.Delete(m.Name); err != nil {
+			logger.Warn("failed to delete container %s of image %s: %v", m.Name, m.ImageName, err)
+		}
+		if c.current != nil && m.Name == c.current.Name {
+			mounts = append(mounts, *c.newMount)
+			replaced = true
+		}
+	}
+	if !replaced {
+		mounts = append(mounts, *c.newMount)
+	}
+	cluster.Status.Mounts = mounts
+	cluster.Spec.Image = replaceAppImages(cluster.Spec.Image, c.app, c.target.ImageName)
+	c.done = true
+	rev := cluster.RecordImageRevision(v2.ImageRevision{
+		ImageName: c.target.ImageName,
+		ImageID:   c.newID,
+		Digest:    c.target.Digest,
+		Env:       c.target.Env,
+		AppliedAt: metav1.Now(),
+	})
+	logger.Info("succeeded in rolling back app %s to %s, it's revision %d now", c.app, c.target.ImageName, rev.Revision)
+	return nil
+}
+
+// rollbackApp returns the application to roll back, it's the one of the last failed command if image is empty.
+func rollbackApp(cluster *v2.Cluster, image string) (string, error) {
+	if image != "" {
+		return v2.ImageRepository(image), nil
+	}
+	for i := len(cluster.Status.CommandConditions) - 1; i >= 0; i-- {
+		cond := cluster.Status.CommandConditions[i]
+		if cond.Type != v2.CommandConditionTypeError || len(cond.Images) == 0 {
+			continue
+		}
+		apps := make([]string, 0, len(cond.Images))
+		for _, img := range cond.Images {
+			if app := v2.ImageRepository(img); cluster.GetImageHistory(app) != nil {
+				apps = append(apps, app)
+			}
+		}
+		if len(apps) == 1 {
+			return apps[0], nil
+		}
+		return "", fmt.Errorf("cannot decide which app of %v to roll back, please specify the image", cond.Images)
+	}
+	return "", fmt.Errorf("no failed command found in cluster %s, please specify the image to roll back", cluster.Name)
+}
+
+// rollbackRevision returns the revision to roll back to, it's the latest one which is not the
+// current image if toRevision is zero.
+func rollbackRevision(h *v2.ImageHistory, current *v2.MountImage, currentID string, toRevision int64) (*v2.ImageRevision, error) {
+	if h == nil || len(h.Revisions) == 0 {
+		return nil, fmt.Errorf("no revision found in the history")
+	}
+	if toRevision > 0 {
+		for i := range h.Revisions {
+			if h.Revisions[i].Revision == toRevision {
+				return &h.Revisions[i], nil
+			}
+		}
+		return nil, fmt.Errorf("revision %d of app %s not found", toRevision, h.App)
+	}
+	for i := len(h.Revisions) - 1; i >= 0; i-- {
+		rev := h.Revisions[i]
+		if current != nil && rev.ImageName == current.ImageName && rev.ImageID == currentID {
+			continue
+		}
+		return &h.Revisions[i], nil
+	}
+	return nil, fmt.Errorf("no previous revision of app %s found", h.App)
+}
+
+// replaceAppImages replaces the first image of the app with newImage and removes the others,
+// newImage is appended if there is no image of the app.
+func replaceAppImages(images []string, app, newImage string) []string {
+	ret := make([]string, 0, len(images)+1)
+	replaced := false
+	for _, img := range images {
+		if v2.ImageRepository(img) == app {
+			if replaced {
+				continue
+			}
+			img, replaced = newImage, true
+		}
+		ret = append(ret, img)
+	}
+	if !replaced {
+		ret = append(ret, newImage)
+	}
+	return stringsutil.RemoveDuplicate(ret)
+}
+
+// recordRevisions records the application mounts into the image history of the cluster
+// after they have been applied successfully.
+func recordRevisions(cluster *v2.Cluster, bder buildah.Interface, mounts []v2.MountImage) {
+	for _, m := range mounts {
+		if !m.IsApplication() {
+			continue
+		}
+		info, err := bder.InspectContainer(m.Name)
+		if err != nil {
+			logger.Warn("failed to inspect container %s, image %s is not recorded into history: %v", m.Name, m.ImageName, err)
+			continue
+		}
+		cluster.RecordImageRevision(v2.ImageRevision{
+			ImageName: m.ImageName,
+			ImageID:   info.FromImageID,
+			Digest:    info.FromImageDigest,
+			Env:       m.Env,
+			AppliedAt: metav1.Now(),
+		})
+	}
+}
+
+func NewRollbackProcessor(clusterFile clusterfile.Interface, opts RollbackOptions) (Interface, error) {
+	bder, err := buildah.New(clusterFile.GetCluster().Name)
+	if err != nil {
+		return nil, err
+	}
+	gs, err := guest.NewGuestManager()
+	if err != nil {
+		return nil, err
+	}
+	return &RollbackProcessor{
+		ClusterFile: clusterFile,
+		Buildah:     bder,
+		Guest:       gs,
+		Options:     opts,
+	}, nil
+}
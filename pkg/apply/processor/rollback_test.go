@@ -0,0 +1,252 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/containers/buildah"
+
+	sealosbuildah "github.com/labring/sealos/pkg/buildah"
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+)
+
+func TestRecordImageRevision(t *testing.T) {
+	cluster := &v2.Cluster{}
+	for i := 1; i <= v2.MaxImageRevisions+2; i++ {
+		cluster.RecordImageRevision(v2.ImageRevision{ImageName: fmt.Sprintf("labring/helm:v3.%d.0", i), ImageID: fmt.Sprintf("id%d", i)})
+	}
+	// applying an old revision again moves it to the latest
+	cluster.RecordImageRevision(v2.ImageRevision{ImageName: "labring/helm:v3.5.0", ImageID: "id5"})
+	cluster.RecordImageRevision(v2.ImageRevision{ImageName: "docker.io/labring/calico:v3.24.1", ImageID: "calico"})
+
+	h := cluster.GetImageHistory("labring/helm")
+	if h == nil || len(h.Revisions) != v2.MaxImageRevisions {
+		t.Fatalf("unexpected history %+v", h)
+	}
+	last := h.Revisions[len(h.Revisions)-1]
+	if last.ImageName != "labring/helm:v3.5.0" || last.Revision != int64(v2.MaxImageRevisions+3) {
+		t.Errorf("unexpected latest revision %+v", last)
+	}
+	if h.Revisions[0].ImageName != "labring/helm:v3.3.0" {
+		t.Errorf("the oldest revisions should be dropped, got %+v", h.Revisions[0])
+	}
+	if h := cluster.GetImageHistory("docker.io/labring/calico"); h == nil || h.Revisions[0].Revision != 1 {
+		t.Errorf("unexpected history of calico %+v", h)
+	}
+}
+
+func TestImageRepository(t *testing.T) {
+	tests := map[string]string{
+		"labring/helm:v3.8.2":                         "labring/helm",
+		"labring/helm":                                "labring/helm",
+		"localhost:5000/helm:v3.8.2":                  "localhost:5000/helm",
+		"localhost:5000/helm":                         "localhost:5000/helm",
+		"labring/helm:v3.8.2@sha256:0123456789abcdef": "labring/helm",
+	}
+	for image, want := range tests {
+		if got := v2.ImageRepository(image); got != want {
+			t.Errorf("ImageRepository(%s) = %s, want %s", image, got, want)
+		}
+	}
+}
+
+func TestRollbackRevision(t *testing.T) {
+	h := &v2.ImageHistory{App: "labring/helm", Revisions: []v2.ImageRevision{
+		{Revision: 1, ImageName: "labring/helm:v3.7.0", ImageID: "a"},
+		{Revision: 2, ImageName: "labring/helm:v3.8.0", ImageID: "b"},
+	}}
+	tests := []struct {
+		name       string
+		history    *v2.ImageHistory
+		current    *v2.MountImage
+		currentID  string
+		toRevision int64
+		want       int64
+		wantErr    bool
+	}{
+		{
+			name:      "previous of the current revision",
+			history:   h,
+			current:   &v2.MountImage{ImageName: "labring/helm:v3.8.0"},
+			currentID: "b",
+			want:      1,
+		},
+		{
+			name:      "latest revision if the current one failed",
+			history:   h,
+			current:   &v2.MountImage{ImageName: "labring/helm:v3.9.0"},
+			currentID: "c",
+			want:      2,
+		},
+		{
+			name:      "retagged image is not the same revision",
+			history:   h,
+			current:   &v2.MountImage{ImageName: "labring/helm:v3.8.0"},
+			currentID: "d",
+			want:      2,
+		},
+		{
+			name:       "specified revision",
+			history:    h,
+			current:    &v2.MountImage{ImageName: "labring/helm:v3.8.0"},
+			currentID:  "b",
+			toRevision: 2,
+			want:       2,
+		},
+		{
+			name:       "revision not found",
+			history:    h,
+			toRevision: 3,
+			wantErr:    true,
+		},
+		{
+			name:      "no previous revision",
+			history:   &v2.ImageHistory{App: "labring/helm", Revisions: h.Revisions[1:]},
+			current:   &v2.MountImage{ImageName: "labring/helm:v3.8.0"},
+			currentID: "b",
+			wantErr:   true,
+		},
+		{
+			name:    "no history",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rollbackRevision(tt.history, tt.current, tt.currentID, tt.toRevision)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("rollbackRevision() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got.Revision != tt.want {
+				t.Errorf("rollbackRevision() = %d, want %d", got.Revision, tt.want)
+			}
+		})
+	}
+}
+
+func TestRollbackApp(t *testing.T) {
+	cluster := &v2.Cluster{}
+	cluster.RecordImageRevision(v2.ImageRevision{ImageName: "labring/helm:v3.8.0"})
+	if _, err := rollbackApp(cluster, ""); err == nil {
+		t.Errorf("expect error if there is no failed command")
+	}
+	cluster.Status.CommandConditions = []v2.CommandCondition{
+		v2.NewFailedCommandCondition("RunGuestFailed"),
+	}
+	cluster.Status.CommandConditions[0].Images = []string{"labring/helm:v3.9.0"}
+	if app, err := rollbackApp(cluster, ""); err != nil || app != "labring/helm" {
+		t.Errorf("rollbackApp() = %s, %v, want labring/helm", app, err)
+	}
+	if app, _ := rollbackApp(cluster, "labring/calico:v3.24.1"); app != "labring/calico" {
+		t.Errorf("rollbackApp() = %s, want labring/calico", app)
+	}
+}
+
+// fakeBuildah knows the image ids of the containers and records the deleted ones.
+type fakeBuildah struct {
+	sealosbuildah.Interface
+	imageIDs map[string]string
+	deleted  []string
+}
+
+func (f *fakeBuildah) InspectContainer(name string) (buildah.BuilderInfo, error) {
+	id, ok := f.imageIDs[name]
+	if !ok {
+		return buildah.BuilderInfo{}, fmt.Errorf("container %s not found", name)
+	}
+	return buildah.BuilderInfo{Container: name, FromImageID: id}, nil
+}
+
+func (f *fakeBuildah) Delete(name string) error {
+	f.deleted = append(f.deleted, name)
+	return nil
+}
+
+func TestRollbackFailedUpgrade(t *testing.T) {
+	// helm:v3.8.0 is installed, and helm:v3.9.0 failed to be installed on top of it
+	cluster := &v2.Cluster{
+		Spec: v2.ClusterSpec{Image: []string{"labring/kubernetes:v1.25.0", "labring/helm:v3.8.0", "labring/helm:v3.9.0"}},
+		Status: v2.ClusterStatus{
+			Mounts: []v2.MountImage{
+				{Name: "default-kube", ImageName: "labring/kubernetes:v1.25.0", Type: v2.RootfsImage},
+				{Name: "default-helm-old", ImageName: "labring/helm:v3.8.0", Type: v2.AppImage},
+				{Name: "default-helm-new", ImageName: "labring/helm:v3.9.0", Type: v2.AppImage},
+			},
+			CommandConditions: []v2.CommandCondition{
+				{Type: v2.CommandConditionTypeError, Images: []string{"labring/helm:v3.9.0"}},
+			},
+		},
+	}
+	cluster.RecordImageRevision(v2.ImageRevision{ImageName: "labring/helm:v3.8.0", ImageID: "old"})
+	bder := &fakeBuildah{imageIDs: map[string]string{"default-helm-old": "old", "default-helm-new": "new"}}
+	c := &RollbackProcessor{Buildah: bder}
+
+	if err := c.SyncStatusAndCheck(cluster); err != nil {
+		t.Fatalf("SyncStatusAndCheck() error = %v", err)
+	}
+	if c.current == nil || c.current.ImageName != "labring/helm:v3.9.0" {
+		t.Fatalf("the failed image should be the current one, got %+v", c.current)
+	}
+	if c.target.ImageName != "labring/helm:v3.8.0" || c.target.Revision != 1 {
+		t.Fatalf("unexpected target revision %+v", c.target)
+	}
+
+	// the mount created by PreProcess
+	c.newMount = &v2.MountImage{Name: "default-helm-rollback", ImageName: "labring/helm:v3.8.0", Type: v2.AppImage}
+	c.newID = "old"
+	if err := c.PostProcess(cluster); err != nil {
+		t.Fatalf("PostProcess() error = %v", err)
+	}
+	if want := []string{"labring/kubernetes:v1.25.0", "labring/helm:v3.8.0"}; !reflect.DeepEqual([]string(cluster.Spec.Image), want) {
+		t.Errorf("spec images = %v, want %v", cluster.Spec.Image, want)
+	}
+	var names []string
+	for _, m := range cluster.Status.Mounts {
+		names = append(names, m.Name)
+	}
+	if want := []string{"default-kube", "default-helm-rollback"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("mounts = %v, want %v", names, want)
+	}
+	if want := []string{"default-helm-old", "default-helm-new"}; !reflect.DeepEqual(bder.deleted, want) {
+		t.Errorf("deleted containers = %v, want %v", bder.deleted, want)
+	}
+	if h := cluster.GetImageHistory("labring/helm"); len(h.Revisions) != 1 || h.Revisions[0].Revision != 2 {
+		t.Errorf("the target should be recorded as the latest revision, got %+v", h)
+	}
+}
+
+func TestReplaceAppImages(t *testing.T) {
+	tests := []struct {
+		images []string
+		want   []string
+	}{
+		{
+			images: []string{"labring/kubernetes:v1.25.0", "labring/helm:v3.9.0", "labring/calico:v3.24.1", "labring/helm:v3.10.0"},
+			want:   []string{"labring/kubernetes:v1.25.0", "labring/helm:v3.8.0", "labring/calico:v3.24.1"},
+		},
+		{
+			images: []string{"labring/kubernetes:v1.25.0"},
+			want:   []string{"labring/kubernetes:v1.25.0", "labring/helm:v3.8.0"},
+		},
+	}
+	for _, tt := range tests {
+		if got := replaceAppImages(tt.images, "labring/helm", "labring/helm:v3.8.0"); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("replaceAppImages(%v) = %v, want %v", tt.images, got, tt.want)
+		}
+	}
+}
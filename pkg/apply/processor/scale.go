@@ -47,6 +47,9 @@ type ScaleProcessor struct {
 	NodesToDelete   []string
 	IsScaleUp       bool
 	Guest           guest.Interface
+	// DrainOptions changes how the runtime drains the nodes to be deleted if not nil
+	DrainOptions *runtime.DrainOptions
+	checkpoint   *Checkpoint
 }
 
 func (c *ScaleProcessor) Execute(cluster *v2.Cluster) error {
@@ -54,43 +57,35 @@ func (c *ScaleProcessor) Execute(cluster *v2.Cluster) error {
 	if err != nil {
 		return err
 	}
-
-	for _, f := range pipLine {
-		if err = f(cluster); err != nil {
-			return err
-		}
-	}
-
-	return nil
+	c.checkpoint = newCheckpoint(cluster.Name, ScaleProcessorName, nil,
+		c.MastersToJoin, c.MastersToDelete, c.NodesToJoin, c.NodesToDelete)
+	return c.checkpoint.run(cluster, pipLine)
 }
 
-func (c *ScaleProcessor) GetPipeLine() ([]func(cluster *v2.Cluster) error, error) {
-	var todoList []func(cluster *v2.Cluster) error
+func (c *ScaleProcessor) GetPipeLine() ([]PipelineStage, error) {
 	if c.IsScaleUp {
-		todoList = append(todoList,
-			c.JoinCheck,
-			c.PreProcess,
-			c.PreProcessImage,
-			c.RunConfig,
-			c.MountRootfs,
-			c.Bootstrap,
+		return []PipelineStage{
+			{"JoinCheck", c.JoinCheck},
+			{"PreProcess", c.PreProcess},
+			{"PreProcessImage", c.PreProcessImage},
+			{"RunConfig", c.RunConfig},
+			{"MountRootfs", c.MountRootfs},
+			{"Bootstrap", c.Bootstrap},
 			//s.GetPhasePluginFunc(plugin.PhasePreJoin),
-			c.Join,
-			c.RunGuest,
+			{"Join", c.Join},
+			{"RunGuest", c.RunGuest},
 			//s.GetPhasePluginFunc(plugin.PhasePostJoin),
-		)
-		return todoList, nil
+		}, nil
 	}
 
-	todoList = append(todoList,
-		c.DeleteCheck,
-		c.PreProcess,
-		c.Delete,
-		c.UndoBootstrap,
+	return []PipelineStage{
+		{"DeleteCheck", c.DeleteCheck},
+		{"PreProcess", c.PreProcess},
+		{"Delete", c.Delete},
+		{"UndoBootstrap", c.UndoBootstrap},
 		//c.ApplyCleanPlugin,
-		c.UnMountRootfs,
-	)
-	return todoList, nil
+		{"UnMountRootfs", c.UnMountRootfs},
+	}, nil
 }
 
 func (c *ScaleProcessor) skipAppMounts(allMount []v2.MountImage) []v2.MountImage {
@@ -106,8 +101,10 @@ func (c *ScaleProcessor) skipAppMounts(allMount []v2.MountImage) []v2.MountImage
 
 func (c *ScaleProcessor) RunGuest(cluster *v2.Cluster) error {
 	logger.Info("Executing pipeline RunGuest in ScaleProcessor.")
-	hosts := append(c.MastersToJoin, c.NodesToJoin...)
-	err := c.Guest.Apply(cluster, c.skipAppMounts(cluster.Status.Mounts), hosts)
+	hosts := append(append([]string{}, c.MastersToJoin...), c.NodesToJoin...)
+	err := c.checkpoint.runOnHosts("RunGuest", hosts, func(hosts []string) error {
+		return c.Guest.Apply(cluster, c.skipAppMounts(cluster.Status.Mounts), hosts)
+	})
 	if err != nil {
 		return fmt.Errorf("%s: %w", RunGuestFailed, err)
 	}
@@ -128,7 +125,9 @@ func (c *ScaleProcessor) Delete(cluster *v2.Cluster) error {
 
 func (c *ScaleProcessor) Join(cluster *v2.Cluster) error {
 	logger.Info("Executing pipeline Join in ScaleProcessor.")
-	err := c.Runtime.ScaleUp(c.MastersToJoin, c.NodesToJoin)
+	err := c.checkpoint.runOnHosts("Join", append(append([]string{}, c.MastersToJoin...), c.NodesToJoin...), func(hosts []string) error {
+		return c.Runtime.ScaleUp(filterHosts(c.MastersToJoin, hosts), filterHosts(c.NodesToJoin, hosts))
+	})
 	if err != nil {
 		return err
 	}
@@ -212,6 +211,9 @@ func (c *ScaleProcessor) preProcess(cluster *v2.Cluster) error {
 	if err != nil {
 		return fmt.Errorf("failed to init runtime: %v", err)
 	}
+	if d, ok := rt.(runtime.Drainer); ok && c.DrainOptions != nil {
+		d.SetDrainOptions(c.DrainOptions)
+	}
 	c.Runtime = rt
 
 	return err
@@ -289,7 +291,7 @@ func (c *ScaleProcessor) UndoBootstrap(_ *v2.Cluster) error {
 	return bs.Delete(hosts...)
 }
 
-func NewScaleProcessor(clusterFile clusterfile.Interface, name string, images v2.ImageList, masterToJoin, masterToDelete, nodeToJoin, nodeToDelete []string) (Interface, error) {
+func NewScaleProcessor(clusterFile clusterfile.Interface, name string, images v2.ImageList, masterToJoin, masterToDelete, nodeToJoin, nodeToDelete []string, drainOptions *runtime.DrainOptions) (Interface, error) {
 	bder, err := buildah.New(name)
 	if err != nil {
 		return nil, err
@@ -309,5 +311,6 @@ func NewScaleProcessor(clusterFile clusterfile.Interface, name string, images v2
 		pullImages:      images,
 		IsScaleUp:       len(masterToJoin) > 0 || len(nodeToJoin) > 0,
 		Guest:           gs,
+		DrainOptions:    drainOptions,
 	}, nil
 }
@@ -0,0 +1,168 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/exp/slices"
+
+	"github.com/labring/sealos/pkg/buildah"
+	"github.com/labring/sealos/pkg/clusterfile"
+	"github.com/labring/sealos/pkg/guest"
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+	"github.com/labring/sealos/pkg/utils/confirm"
+	"github.com/labring/sealos/pkg/utils/logger"
+)
+
+var ForceUninstall bool
+
+// UninstallProcessor removes application images from the cluster, the uninstall commands
+// of the images are executed before they are removed from the cluster status.
+type UninstallProcessor struct {
+	ClusterFile clusterfile.Interface
+	Buildah     buildah.Interface
+	Guest       guest.Interface
+	Images      []string
+	mounts      []v2.MountImage
+}
+
+func (c *UninstallProcessor) Execute(cluster *v2.Cluster) error {
+	pipLine, err := c.GetPipeLine()
+	if err != nil {
+		return err
+	}
+	for _, f := range pipLine {
+		if err = f(cluster); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *UninstallProcessor) GetPipeLine() ([]func(cluster *v2.Cluster) error, error) {
+	var todoList []func(cluster *v2.Cluster) error
+	todoList = append(todoList,
+		c.SyncStatusAndCheck,
+		c.ConfirmUninstallApps,
+		c.RunGuest,
+		c.UpdateStatus,
+		c.UnMountImage,
+	)
+	return todoList, nil
+}
+
+func (c *UninstallProcessor) SyncStatusAndCheck(cluster *v2.Cluster) error {
+	logger.Info("Executing SyncStatusAndCheck Pipeline in UninstallProcessor")
+	if err := SyncClusterStatus(cluster, c.Buildah, false); err != nil {
+		return err
+	}
+	mounts, err := findUninstallMounts(cluster, c.Images)
+	if err != nil {
+		return err
+	}
+	c.mounts = mounts
+	return nil
+}
+
+func (c *UninstallProcessor) ConfirmUninstallApps(_ *v2.Cluster) error {
+	logger.Info("Executing ConfirmUninstallApps Pipeline in UninstallProcessor")
+	if ForceUninstall {
+		return nil
+	}
+	prompt := fmt.Sprintf("are you sure to uninstall these following apps? \n%s\t", strings.Join(c.Images, "\n"))
+	cancelledMsg := "you have canceled to uninstall these apps"
+	pass, err := confirm.Confirm(prompt, cancelledMsg)
+	if err != nil {
+		return err
+	}
+	if !pass {
+		return ErrCancelled
+	}
+	return nil
+}
+
+func (c *UninstallProcessor) RunGuest(cluster *v2.Cluster) error {
+	logger.Info("Executing RunGuest Pipeline in UninstallProcessor")
+	return c.Guest.Delete(cluster, c.mounts)
+}
+
+func (c *UninstallProcessor) UpdateStatus(cluster *v2.Cluster) error {
+	removeImages(cluster, c.Images)
+	return nil
+}
+
+func (c *UninstallProcessor) UnMountImage(_ *v2.Cluster) error {
+	logger.Info("Executing UnMountImage Pipeline in UninstallProcessor")
+	for _, m := range c.mounts {
+		if err := c.Buildah.Delete(m.Name); err != nil {
+			return fmt.Errorf("failed to delete container %s of image %s: %v", m.Name, m.ImageName, err)
+		}
+	}
+	logger.Info("succeeded in uninstalling apps %s", strings.Join(c.Images, ", "))
+	return nil
+}
+
+// findUninstallMounts returns the mounts of the images, the images must be installed applications.
+func findUninstallMounts(cluster *v2.Cluster, images []string) ([]v2.MountImage, error) {
+	mounts := make([]v2.MountImage, 0, len(images))
+	for _, img := range images {
+		_, m := cluster.FindImage(img)
+		if m == nil {
+			return nil, fmt.Errorf("image %s is not installed in cluster %s", img, cluster.Name)
+		}
+		if !m.IsApplication() {
+			return nil, fmt.Errorf("image %s is a %s image, only application images can be uninstalled", img, m.Type)
+		}
+		mounts = append(mounts, *m)
+	}
+	return mounts, nil
+}
+
+// removeImages removes the images from the spec and the mounts of the cluster status.
+func removeImages(cluster *v2.Cluster, images []string) {
+	specImages := make([]string, 0, len(cluster.Spec.Image))
+	for _, img := range cluster.Spec.Image {
+		if !slices.Contains(images, img) {
+			specImages = append(specImages, img)
+		}
+	}
+	cluster.Spec.Image = specImages
+	mounts := make([]v2.MountImage, 0, len(cluster.Status.Mounts))
+	for _, m := range cluster.Status.Mounts {
+		if !slices.Contains(images, m.ImageName) {
+			mounts = append(mounts, m)
+		}
+	}
+	cluster.Status.Mounts = mounts
+}
+
+func NewUninstallProcessor(clusterFile clusterfile.Interface, images []string) (Interface, error) {
+	bder, err := buildah.New(clusterFile.GetCluster().Name)
+	if err != nil {
+		return nil, err
+	}
+	gs, err := guest.NewGuestManager()
+	if err != nil {
+		return nil, err
+	}
+	return &UninstallProcessor{
+		ClusterFile: clusterFile,
+		Buildah:     bder,
+		Guest:       gs,
+		Images:      images,
+	}, nil
+}
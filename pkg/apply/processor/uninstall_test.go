@@ -0,0 +1,79 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"reflect"
+	"testing"
+
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+)
+
+func TestUninstallImages(t *testing.T) {
+	newCluster := func() *v2.Cluster {
+		return &v2.Cluster{
+			Spec: v2.ClusterSpec{Image: []string{"labring/kubernetes:v1.25.0", "labring/helm:v3.8.2", "labring/calico:v3.24.1"}},
+			Status: v2.ClusterStatus{Mounts: []v2.MountImage{
+				{Name: "default-kube", ImageName: "labring/kubernetes:v1.25.0", Type: v2.RootfsImage},
+				{Name: "default-helm", ImageName: "labring/helm:v3.8.2", Type: v2.AppImage},
+				{Name: "default-calico", ImageName: "labring/calico:v3.24.1"},
+			}},
+		}
+	}
+	tests := []struct {
+		name       string
+		images     []string
+		wantErr    bool
+		wantImages []string
+	}{
+		{
+			name:       "uninstall apps",
+			images:     []string{"labring/helm:v3.8.2", "labring/calico:v3.24.1"},
+			wantImages: []string{"labring/kubernetes:v1.25.0"},
+		},
+		{
+			name:    "not installed",
+			images:  []string{"labring/openebs:v3.4.0"},
+			wantErr: true,
+		},
+		{
+			name:    "rootfs image",
+			images:  []string{"labring/kubernetes:v1.25.0"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cluster := newCluster()
+			mounts, err := findUninstallMounts(cluster, tt.images)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("findUninstallMounts() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(mounts) != len(tt.images) {
+				t.Errorf("findUninstallMounts() got %d mounts, want %d", len(mounts), len(tt.images))
+			}
+			removeImages(cluster, tt.images)
+			if !reflect.DeepEqual([]string(cluster.Spec.Image), tt.wantImages) {
+				t.Errorf("spec images = %v, want %v", cluster.Spec.Image, tt.wantImages)
+			}
+			if len(cluster.Status.Mounts) != len(tt.wantImages) || cluster.Status.Mounts[0].ImageName != tt.wantImages[0] {
+				t.Errorf("mounts = %+v, want images %v", cluster.Status.Mounts, tt.wantImages)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apply
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/labring/sealos/pkg/apply/applydrivers"
+	"github.com/labring/sealos/pkg/apply/processor"
+)
+
+func NewApplierFromRollbackArgs(cmd *cobra.Command, args *RollbackArgs, image string) (applydrivers.Interface, error) {
+	cluster, cf, err := loadCreatedCluster(cmd, args.ClusterName)
+	if err != nil {
+		return nil, err
+	}
+	return applydrivers.NewDefaultRollbackApplier(cmd.Context(), cluster, cf, processor.RollbackOptions{
+		Image:      image,
+		ToRevision: args.ToRevision,
+	})
+}
@@ -65,7 +65,16 @@ func NewScaleApplierFromArgs(cmd *cobra.Command, scaleArgs *ScaleArgs) (applydri
 		return nil, err
 	}
 
-	return applydrivers.NewDefaultScaleApplier(cmd.Context(), curr, cluster)
+	applier, err := applydrivers.NewDefaultScaleApplier(cmd.Context(), curr, cluster)
+	if err != nil {
+		return nil, err
+	}
+	applier.(*applydrivers.Applier).DrainOptions = scaleArgs.Drain
+	if scaleArgs.DryRun.Enabled {
+		applier.(*applydrivers.Applier).DryRun = true
+		applier.(*applydrivers.Applier).PlanOutput = scaleArgs.DryRun.Output
+	}
+	return applier, nil
 }
 
 func getSSHFromCommand(cmd *cobra.Command) *v2.SSH {
@@ -177,9 +186,14 @@ func verifyAndSetNodes(cmd *cobra.Command, cluster *v2.Cluster, scaleArgs *Scale
 			if err != nil {
 				return nil, err
 			}
+			roles := []string{role}
+			// do not connect to any host in dry run mode
+			if !scaleArgs.DryRun.Enabled {
+				roles = append(roles, GetHostArch(execer, addrs[0]))
+			}
 			host := &v2.Host{
 				IPS:   addrs,
-				Roles: []string{role, GetHostArch(execer, addrs[0])},
+				Roles: roles,
 			}
 			if override != nil {
 				host.SSH = override
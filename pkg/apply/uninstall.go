@@ -0,0 +1,54 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apply
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/labring/sealos/pkg/apply/applydrivers"
+	"github.com/labring/sealos/pkg/clusterfile"
+	"github.com/labring/sealos/pkg/constants"
+	"github.com/labring/sealos/pkg/ssh"
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+)
+
+func NewApplierFromUninstallArgs(cmd *cobra.Command, args *UninstallArgs, images []string) (applydrivers.Interface, error) {
+	if len(images) == 0 {
+		return nil, fmt.Errorf("images to uninstall cannot be empty")
+	}
+	cluster, cf, err := loadCreatedCluster(cmd, args.ClusterName)
+	if err != nil {
+		return nil, err
+	}
+	return applydrivers.NewDefaultUninstallApplier(cmd.Context(), cluster, cf, images)
+}
+
+// loadCreatedCluster loads the cluster from the Clusterfile, the cluster must have been created.
+func loadCreatedCluster(cmd *cobra.Command, clusterName string) (*v2.Cluster, clusterfile.Interface, error) {
+	cf := clusterfile.NewClusterFile(constants.Clusterfile(clusterName))
+	if err := cf.Process(); err != nil {
+		return nil, nil, err
+	}
+	cluster := cf.GetCluster().DeepCopy()
+	if cluster.CreationTimestamp.IsZero() {
+		return nil, nil, fmt.Errorf("cluster %s has not been created yet", clusterName)
+	}
+	if override := getSSHFromCommand(cmd); override != nil {
+		ssh.OverSSHConfig(&cluster.Spec.SSH, override)
+	}
+	return cluster, cf, nil
+}
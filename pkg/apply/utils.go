@@ -137,7 +137,14 @@ func GetNewImages(currentCluster, desiredCluster *v2.Cluster) []string {
 	return nil
 }
 
-func CheckAndInitialize(cluster *v2.Cluster) error {
+// newExecer connects to the hosts of the cluster over ssh, it is replaced in tests.
+var newExecer = func(sshConfig *v2.SSH) (exec.Interface, error) {
+	return exec.New(ssh.MustNewClient(sshConfig, true))
+}
+
+// CheckAndInitialize sets the ssh defaults of the cluster and adds the local host as the master
+// if there is no host. The arch of the local host is detected over ssh unless dryRun is set.
+func CheckAndInitialize(cluster *v2.Cluster, dryRun bool) error {
 	cluster.Spec.SSH.Port = cluster.Spec.SSH.DefaultPort()
 
 	if cluster.Spec.SSH.Pk == "" {
@@ -145,18 +152,22 @@ func CheckAndInitialize(cluster *v2.Cluster) error {
 	}
 
 	if len(cluster.Spec.Hosts) == 0 {
-		sshClient := ssh.MustNewClient(cluster.Spec.SSH.DeepCopy(), true)
-		execer, err := exec.New(sshClient)
-		if err != nil {
-			return err
-		}
 		localIpv4 := iputils.GetLocalIpv4()
 		defaultPort := defaultSSHPort(cluster.Spec.SSH.Port)
 		addr := net.JoinHostPort(localIpv4, defaultPort)
 
+		roles := []string{v2.MASTER}
+		// do not connect to any host in dry run mode
+		if !dryRun {
+			execer, err := newExecer(cluster.Spec.SSH.DeepCopy())
+			if err != nil {
+				return err
+			}
+			roles = append(roles, GetHostArch(execer, addr))
+		}
 		cluster.Spec.Hosts = append(cluster.Spec.Hosts, v2.Host{
 			IPS:   []string{addr},
-			Roles: []string{v2.MASTER, GetHostArch(execer, addr)},
+			Roles: roles,
 		})
 	}
 	return nil
@@ -0,0 +1,208 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/docker/go-units"
+	"golang.org/x/exp/slices"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8syaml "sigs.k8s.io/yaml"
+
+	"github.com/labring/sealos/pkg/constants"
+	"github.com/labring/sealos/pkg/runtime"
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+	fileutil "github.com/labring/sealos/pkg/utils/file"
+	"github.com/labring/sealos/pkg/utils/logger"
+	"github.com/labring/sealos/pkg/utils/yaml"
+)
+
+const (
+	OutputTable = "table"
+	OutputJSON  = "json"
+	OutputYAML  = "yaml"
+)
+
+const (
+	snapshotFileName = "snapshot.db"
+	metadataFileName = "backup.yaml"
+)
+
+var nameRegexp = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
+// Backup is an etcd snapshot of the cluster, it's stored in the backups dir of the cluster
+// along with a copy of the Clusterfile and the pki dir at the time the snapshot was taken.
+type Backup struct {
+	Name         string      `json:"name"`
+	Cluster      string      `json:"cluster"`
+	Distribution string      `json:"distribution"`
+	Masters      []string    `json:"masters"`
+	Size         int64       `json:"size"`
+	CreatedAt    metav1.Time `json:"createdAt"`
+}
+
+// Dir returns the directory of the named backup of the cluster.
+func Dir(clusterName, name string) string {
+	return filepath.Join(constants.BackupsDir(clusterName), name)
+}
+
+// Snapshot returns the path of the etcd snapshot file.
+func (b *Backup) Snapshot() string {
+	return filepath.Join(Dir(b.Cluster, b.Name), snapshotFileName)
+}
+
+// Create takes a snapshot of etcd on master0, a name is generated from the cluster name and current time if empty.
+func Create(cluster *v2.Cluster, name string, em runtime.EtcdManager) (*Backup, error) {
+	if name == "" {
+		name = fmt.Sprintf("%s-%s", cluster.Name, time.Now().Format("20060102150405"))
+	}
+	if !nameRegexp.MatchString(name) {
+		return nil, fmt.Errorf("invalid backup name %s, must match %s", name, nameRegexp)
+	}
+	dir := Dir(cluster.Name, name)
+	if fileutil.IsExist(dir) {
+		return nil, fmt.Errorf("backup %s already exists", name)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	b := &Backup{
+		Name:         name,
+		Cluster:      cluster.Name,
+		Distribution: cluster.GetDistribution(),
+		Masters:      cluster.GetMasterIPAndPortList(),
+	}
+	if err := b.save(em); err != nil {
+		if rmErr := os.RemoveAll(dir); rmErr != nil {
+			logger.Warn("failed to clean up backup %s: %v", dir, rmErr)
+		}
+		return nil, err
+	}
+	return b, nil
+}
+
+func (b *Backup) save(em runtime.EtcdManager) error {
+	logger.Info("taking etcd snapshot of cluster %s", b.Cluster)
+	if err := em.SnapshotEtcd(b.Snapshot()); err != nil {
+		return fmt.Errorf("failed to take etcd snapshot: %v", err)
+	}
+	fi, err := os.Stat(b.Snapshot())
+	if err != nil {
+		return err
+	}
+	b.Size = fi.Size()
+	dir := Dir(b.Cluster, b.Name)
+	for _, src := range []string{constants.Clusterfile(b.Cluster), constants.NewPathResolver(b.Cluster).PkiPath()} {
+		if !fileutil.IsExist(src) {
+			continue
+		}
+		if err = fileutil.RecursionCopy(src, filepath.Join(dir, filepath.Base(src))); err != nil {
+			return fmt.Errorf("failed to copy %s: %v", src, err)
+		}
+	}
+	b.CreatedAt = metav1.Now()
+	return yaml.MarshalFile(filepath.Join(dir, metadataFileName), b)
+}
+
+// Get returns the named backup of the cluster.
+func Get(clusterName, name string) (*Backup, error) {
+	fp := filepath.Join(Dir(clusterName, name), metadataFileName)
+	if !fileutil.IsExist(fp) {
+		return nil, fmt.Errorf("backup %s of cluster %s not found", name, clusterName)
+	}
+	b := &Backup{}
+	if err := yaml.UnmarshalFile(fp, b); err != nil {
+		return nil, fmt.Errorf("failed to load backup %s: %v", name, err)
+	}
+	return b, nil
+}
+
+// List returns the backups of the cluster, the latest first.
+func List(clusterName string) ([]*Backup, error) {
+	entries, err := os.ReadDir(constants.BackupsDir(clusterName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var list []*Backup
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		b, err := Get(clusterName, entry.Name())
+		if err != nil {
+			logger.Warn("skip invalid backup %s: %v", entry.Name(), err)
+			continue
+		}
+		list = append(list, b)
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[j].CreatedAt.Before(&list[i].CreatedAt)
+	})
+	return list, nil
+}
+
+// Restore rebuilds etcd of the cluster from the backup, the masters of the cluster are restored
+// as the members even if they are not the same as the ones when the backup was taken.
+func Restore(cluster *v2.Cluster, b *Backup, em runtime.EtcdManager) error {
+	if b.Distribution != cluster.GetDistribution() {
+		return fmt.Errorf("backup %s is taken from %s, cannot be restored to %s", b.Name, b.Distribution, cluster.GetDistribution())
+	}
+	if masters := cluster.GetMasterIPAndPortList(); !slices.Equal(masters, b.Masters) {
+		logger.Warn("masters of backup %s are %v, they will be restored to %v", b.Name, b.Masters, masters)
+	}
+	if !fileutil.IsExist(b.Snapshot()) {
+		return fmt.Errorf("snapshot of backup %s not found", b.Name)
+	}
+	logger.Info("restoring etcd of cluster %s from backup %s", cluster.Name, b.Name)
+	return em.RestoreEtcd(b.Snapshot())
+}
+
+// Print writes the backups in the given format, table, json or yaml.
+func Print(w io.Writer, list []*Backup, format string) error {
+	switch format {
+	case OutputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(list)
+	case OutputYAML:
+		data, err := k8syaml.Marshal(list)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	case OutputTable, "":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "NAME\tDISTRIBUTION\tMASTERS\tSIZE\tCREATED")
+		for _, b := range list {
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\n", b.Name, b.Distribution, len(b.Masters),
+				units.BytesSize(float64(b.Size)), b.CreatedAt.Format(time.RFC3339))
+		}
+		return tw.Flush()
+	}
+	return fmt.Errorf("unsupported output format %s, only %s, %s and %s are supported", format, OutputTable, OutputJSON, OutputYAML)
+}
@@ -0,0 +1,122 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/labring/sealos/pkg/constants"
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+)
+
+type fakeEtcdManager struct {
+	snapshotErr error
+	restored    string
+}
+
+func (f *fakeEtcdManager) SnapshotEtcd(dst string) error {
+	if f.snapshotErr != nil {
+		return f.snapshotErr
+	}
+	return os.WriteFile(dst, []byte("snapshot"), 0600)
+}
+
+func (f *fakeEtcdManager) RestoreEtcd(src string) error {
+	f.restored = src
+	return nil
+}
+
+func TestBackup(t *testing.T) {
+	defer func(dir string) { constants.DefaultRuntimeRootDir = dir }(constants.DefaultRuntimeRootDir)
+	constants.DefaultRuntimeRootDir = t.TempDir()
+
+	cluster := &v2.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "default"},
+		Spec: v2.ClusterSpec{Hosts: []v2.Host{
+			{IPS: []string{"192.168.0.2:22"}, Roles: []string{v2.MASTER}},
+		}},
+	}
+	pki := constants.NewPathResolver(cluster.Name).PkiPath()
+	if err := os.MkdirAll(pki, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(pki, "ca.crt"), []byte("ca"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	em := &fakeEtcdManager{}
+	b, err := Create(cluster, "first", em)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Size != int64(len("snapshot")) {
+		t.Errorf("unexpected size %d", b.Size)
+	}
+	if _, err = os.Stat(filepath.Join(Dir(cluster.Name, b.Name), constants.PkiDirName, "ca.crt")); err != nil {
+		t.Errorf("pki should be saved along with the snapshot: %v", err)
+	}
+	if _, err = Create(cluster, "first", em); err == nil {
+		t.Errorf("expect error for the backup already exists")
+	}
+	if _, err = Create(cluster, "../escape", em); err == nil {
+		t.Errorf("expect error for invalid name")
+	}
+	if _, err = Create(cluster, "failed", &fakeEtcdManager{snapshotErr: errors.New("etcd is down")}); err == nil {
+		t.Errorf("expect error for snapshot failed")
+	}
+	if _, err = os.Stat(Dir(cluster.Name, "failed")); !os.IsNotExist(err) {
+		t.Errorf("failed backup should be cleaned up")
+	}
+	if _, err = Create(cluster, "", em); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := List(cluster.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[1].Name != "first" {
+		t.Errorf("unexpected backups %+v", list)
+	}
+	buf := &bytes.Buffer{}
+	if err = Print(buf, list, OutputTable); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "first") {
+		t.Errorf("unexpected table output:\n%s", buf.String())
+	}
+
+	got, err := Get(cluster.Name, "first")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = Restore(cluster, got, em); err != nil {
+		t.Fatal(err)
+	}
+	if em.restored != got.Snapshot() {
+		t.Errorf("restored from %s, want %s", em.restored, got.Snapshot())
+	}
+	got.Distribution = "k3s"
+	if err = Restore(cluster, got, em); err == nil {
+		t.Errorf("expect error for restoring to another distribution")
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cert
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	certutil "k8s.io/client-go/util/cert"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	OutputTable = "table"
+	OutputJSON  = "json"
+	OutputYAML  = "yaml"
+)
+
+// File is a certificate file on the masters.
+type File struct {
+	// Name is the path relative to the pki directory, e.g. etcd/ca
+	Name string
+	Path string
+	CA   bool
+	// Optional files are skipped if not exist, e.g. the certificates of the embedded etcd
+	Optional bool
+}
+
+// KubeFiles returns the certificate files of CaList and List in the default kubernetes pki directory.
+func KubeFiles() []File {
+	var files []File
+	for _, list := range []struct {
+		configs []Config
+		ca      bool
+	}{
+		{CaList(KubeDefaultCertPath, KubeDefaultCertEtcdPath), true},
+		{List(KubeDefaultCertPath, KubeDefaultCertEtcdPath), false},
+	} {
+		for _, cfg := range list.configs {
+			name := strings.TrimPrefix(path.Join(strings.TrimPrefix(cfg.DefaultPath, KubeDefaultCertPath), cfg.BaseName), "/")
+			files = append(files, File{Name: name, Path: pathForCert(cfg.DefaultPath, cfg.BaseName), CA: list.ca})
+		}
+	}
+	return files
+}
+
+// Expiration is the expiration of a certificate on a host.
+type Expiration struct {
+	Host     string    `json:"host"`
+	Name     string    `json:"name"`
+	CA       bool      `json:"ca"`
+	NotAfter time.Time `json:"notAfter,omitempty"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// NewExpiration parses the PEM encoded certificate of the file on the host.
+func NewExpiration(host string, f File, data []byte) Expiration {
+	e := Expiration{Host: host, Name: f.Name, CA: f.CA}
+	certs, err := certutil.ParseCertsPEM(data)
+	if err != nil {
+		e.Error = fmt.Sprintf("failed to parse %s: %v", f.Path, err)
+		return e
+	}
+	e.NotAfter = certs[0].NotAfter
+	return e
+}
+
+// ResidualTime returns the time left before the certificate expires, a negative value means expired.
+func (e Expiration) ResidualTime(now time.Time) time.Duration {
+	return e.NotAfter.Sub(now)
+}
+
+// PrintExpirations writes the expirations in the given format, table, json or yaml.
+func PrintExpirations(w io.Writer, list []Expiration, format string) error {
+	switch format {
+	case OutputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(list)
+	case OutputYAML:
+		data, err := yaml.Marshal(list)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	case OutputTable, "":
+		now := time.Now()
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "HOST\tCERTIFICATE\tEXPIRES\tRESIDUAL TIME\tCERTIFICATE AUTHORITY")
+		for _, e := range list {
+			if e.Error != "" {
+				fmt.Fprintf(tw, "%s\t%s\t<error>\t%s\t%s\n", e.Host, e.Name, e.Error, yesOrNo(e.CA))
+				continue
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", e.Host, e.Name, e.NotAfter.UTC().Format("Jan 02, 2006 15:04 MST"),
+				humanResidualTime(e.ResidualTime(now)), yesOrNo(e.CA))
+		}
+		return tw.Flush()
+	}
+	return fmt.Errorf("unsupported output format %s, only %s, %s and %s are supported", format, OutputTable, OutputJSON, OutputYAML)
+}
+
+func humanResidualTime(d time.Duration) string {
+	if d <= 0 {
+		return "<expired>"
+	}
+	if days := int(d.Hours() / 24); days >= 365 {
+		return fmt.Sprintf("%dy", days/365)
+	} else if days > 0 {
+		return fmt.Sprintf("%dd", days)
+	}
+	return fmt.Sprintf("%dh", int(d.Hours()))
+}
+
+func yesOrNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
@@ -0,0 +1,76 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cert
+
+import (
+	"bytes"
+	"crypto/x509"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestKubeFiles(t *testing.T) {
+	files := KubeFiles()
+	want := map[string]File{
+		"ca":             {Name: "ca", Path: "/etc/kubernetes/pki/ca.crt", CA: true},
+		"etcd/ca":        {Name: "etcd/ca", Path: "/etc/kubernetes/pki/etcd/ca.crt", CA: true},
+		"apiserver":      {Name: "apiserver", Path: "/etc/kubernetes/pki/apiserver.crt"},
+		"etcd/peer":      {Name: "etcd/peer", Path: "/etc/kubernetes/pki/etcd/peer.crt"},
+		"front-proxy-ca": {Name: "front-proxy-ca", Path: "/etc/kubernetes/pki/front-proxy-ca.crt", CA: true},
+	}
+	if len(files) != len(CaList("", ""))+len(List("", "")) {
+		t.Errorf("unexpected count of files %d", len(files))
+	}
+	for _, f := range files {
+		if w, ok := want[f.Name]; ok && w != f {
+			t.Errorf("KubeFiles() got %+v, want %+v", f, w)
+		}
+	}
+}
+
+func TestNewExpiration(t *testing.T) {
+	key, err := NewPrivateKey(x509.ECDSA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caCert, err := NewSelfSignedCACert(key, "kubernetes", nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := File{Name: "ca", Path: "/etc/kubernetes/pki/ca.crt", CA: true}
+	e := NewExpiration("192.168.0.2:22", f, EncodeCertPEM(caCert))
+	if e.Error != "" || !e.NotAfter.Equal(caCert.NotAfter) || !e.CA {
+		t.Errorf("unexpected expiration %+v", e)
+	}
+	if d := e.ResidualTime(time.Now()); d < 364*24*time.Hour {
+		t.Errorf("unexpected residual time %s", d)
+	}
+	broken := NewExpiration("192.168.0.2:22", f, []byte("not a cert"))
+	if broken.Error == "" {
+		t.Errorf("expect error for invalid cert")
+	}
+
+	buf := &bytes.Buffer{}
+	if err = PrintExpirations(buf, []Expiration{e, broken}, OutputTable); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "<error>") || !strings.Contains(buf.String(), "364d") {
+		t.Errorf("unexpected table output:\n%s", buf.String())
+	}
+	if err = PrintExpirations(buf, nil, "wide"); err == nil {
+		t.Errorf("expect error for unsupported output format")
+	}
+}
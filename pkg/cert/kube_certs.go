@@ -30,7 +30,7 @@ import (
 
 var (
 	KubeDefaultCertPath     = "/etc/kubernetes/pki"
-	kubeDefaultCertEtcdPath = "/etc/kubernetes/pki/etcd"
+	KubeDefaultCertEtcdPath = "/etc/kubernetes/pki/etcd"
 )
 
 func CaList(CertPath, CertEtcdPath string) []Config {
@@ -57,7 +57,7 @@ func CaList(CertPath, CertEtcdPath string) []Config {
 		},
 		{
 			Path:         CertEtcdPath,
-			DefaultPath:  kubeDefaultCertEtcdPath,
+			DefaultPath:  KubeDefaultCertEtcdPath,
 			BaseName:     "ca",
 			CommonName:   "etcd-ca",
 			Organization: nil,
@@ -126,7 +126,7 @@ func List(CertPath, CertEtcdPath string) []Config {
 		},
 		{
 			Path:         CertEtcdPath,
-			DefaultPath:  kubeDefaultCertEtcdPath,
+			DefaultPath:  KubeDefaultCertEtcdPath,
 			BaseName:     "server",
 			CAName:       "etcd-ca",
 			CommonName:   "etcd", // kubeadm using node name as common name cc.CommonName = mc.NodeRegistration.Name
@@ -137,7 +137,7 @@ func List(CertPath, CertEtcdPath string) []Config {
 		},
 		{
 			Path:         CertEtcdPath,
-			DefaultPath:  kubeDefaultCertEtcdPath,
+			DefaultPath:  KubeDefaultCertEtcdPath,
 			BaseName:     "peer",
 			CAName:       "etcd-ca",
 			CommonName:   "etcd-peer", // change this in filter
@@ -148,7 +148,7 @@ func List(CertPath, CertEtcdPath string) []Config {
 		},
 		{
 			Path:         CertEtcdPath,
-			DefaultPath:  kubeDefaultCertEtcdPath,
+			DefaultPath:  KubeDefaultCertEtcdPath,
 			BaseName:     "healthcheck-client",
 			CAName:       "etcd-ca",
 			CommonName:   "kube-etcd-healthcheck-client",
@@ -22,6 +22,7 @@ import (
 	"time"
 
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 
 	"github.com/labring/sealos/pkg/client-go/kubernetes"
 	"github.com/labring/sealos/pkg/constants"
@@ -119,6 +120,47 @@ Cluster Status
 	return tpl.Execute(os.Stdout, map[string][]ClusterStatus{"ClusterStatusList": clusterStatus})
 }
 
+func (n *ClusterChecker) Name() string {
+	return "cluster"
+}
+
+// Report reports the control plane components and kubelet of every node.
+func (n *ClusterChecker) Report(cluster *v2.Cluster) []Result {
+	c, err := newKubeClient(cluster)
+	if err != nil {
+		return []Result{newResult("", err)}
+	}
+	ctx := context.Background()
+	nodes, err := c.Kubernetes().CoreV1().Nodes().List(ctx, v1.ListOptions{})
+	if err != nil {
+		return []Result{newResult("", err)}
+	}
+	ke := kubernetes.NewKubeExpansion(c.Kubernetes())
+	healthyClient := kubernetes.NewKubeHealthy(c.Kubernetes(), 30*time.Second)
+	masters := sets.NewString(cluster.GetMasterIPList()...)
+	results := make([]Result, 0)
+	for _, node := range nodes.Items {
+		ip, _ := getNodeStatus(node)
+		if masters.Has(ip) {
+			for _, component := range []string{kubernetes.KubeAPIServer, kubernetes.KubeControllerManager, kubernetes.KubeScheduler} {
+				result := Result{Check: component, Host: ip, Status: StatusPass}
+				pod, err := ke.FetchStaticPod(ctx, node.Name, component)
+				if err != nil {
+					result.Status = StatusFail
+					result.Message = err.Error()
+				} else if result.Message = healthyClient.ForHealthyPod(pod); getPodReadyStatus(*pod) != nil {
+					result.Status = StatusFail
+				}
+				results = append(results, result)
+			}
+		}
+		result := newResult(ip, healthyClient.ForHealthyKubelet(5*time.Second, ip))
+		result.Check = "kubelet"
+		results = append(results, result)
+	}
+	return results
+}
+
 func NewClusterChecker() Interface {
 	return &ClusterChecker{}
 }
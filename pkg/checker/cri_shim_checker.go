@@ -20,11 +20,14 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/labring/image-cri-shim/pkg/types"
+	"sigs.k8s.io/yaml"
 
 	"github.com/labring/sreg/pkg/buildimage"
 
+	"github.com/labring/sealos/pkg/exec"
 	"github.com/labring/sealos/pkg/template"
 	v2 "github.com/labring/sealos/pkg/types/v1beta1"
 	"github.com/labring/sealos/pkg/utils/logger"
@@ -43,14 +46,15 @@ func (n *CRIShimChecker) Check(_ *v2.Cluster, phase string) error {
 	if phase != PhasePost {
 		return nil
 	}
-	status := &CRIShimStatus{}
-	defer func() {
-		err := n.Output(status)
-		if err != nil {
-			logger.Error("error output: %+v", err)
-		}
-	}()
+	status := n.collect()
+	if err := n.Output(status); err != nil {
+		logger.Error("error output: %+v", err)
+	}
+	return nil
+}
 
+func (n *CRIShimChecker) collect() *CRIShimStatus {
+	status := &CRIShimStatus{}
 	if shimCfg, err := types.Unmarshal(types.DefaultImageCRIShimConfig); err != nil {
 		status.Error = fmt.Errorf("read image-cri-shim config error: %w", err).Error()
 	} else {
@@ -74,8 +78,33 @@ func (n *CRIShimChecker) Check(_ *v2.Cluster, phase string) error {
 		}
 	}
 
-	status.Error = Nil
-	return nil
+	if status.Error == "" {
+		status.Error = Nil
+	}
+	return status
+}
+
+func (n *CRIShimChecker) Name() string {
+	return "image-cri-shim"
+}
+
+// Report reports whether the config of image-cri-shim on every host can be read.
+func (n *CRIShimChecker) Report(cluster *v2.Cluster) []Result {
+	return reportOnHosts(cluster, func(execer exec.Interface, host string) []Result {
+		return []Result{n.reportHost(execer, host)}
+	})
+}
+
+func (n *CRIShimChecker) reportHost(execer exec.Interface, host string) Result {
+	out, err := execer.Cmd(host, "cat "+types.DefaultImageCRIShimConfig)
+	if err != nil {
+		return newResult(host, fmt.Errorf("read image-cri-shim config error: %w: %s", err, strings.TrimSpace(string(out))))
+	}
+	cfg := &types.Config{}
+	if err = yaml.Unmarshal(out, cfg); err != nil {
+		return newResult(host, fmt.Errorf("read image-cri-shim config error: %w", err))
+	}
+	return Result{Host: host, Status: StatusPass, Message: fmt.Sprintf("registry %s", cfg.Address)}
 }
 
 func (n *CRIShimChecker) Output(status *CRIShimStatus) error {
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"sync"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	executils "k8s.io/utils/exec"
@@ -38,6 +39,11 @@ import (
 	"github.com/labring/sealos/pkg/utils/yaml"
 )
 
+const (
+	containerRunning = "CONTAINER_RUNNING"
+	containerExited  = "CONTAINER_EXITED"
+)
+
 type CRICtlChecker struct {
 }
 
@@ -62,14 +68,15 @@ func (n *CRICtlChecker) Check(cluster *v2.Cluster, phase string) error {
 	if phase != PhasePost {
 		return nil
 	}
-	status := &CRICtlStatus{}
-	defer func() {
-		err := n.Output(status)
-		if err != nil {
-			logger.Error("error output: %+v", err)
-		}
-	}()
+	status, err := n.collect(cluster)
+	if outErr := n.Output(status); outErr != nil {
+		logger.Error("error output: %+v", outErr)
+	}
+	return err
+}
 
+func (n *CRICtlChecker) collect(cluster *v2.Cluster) (*CRICtlStatus, error) {
+	status := &CRICtlStatus{}
 	criShimConfig := "/etc/crictl.yaml"
 	if cfg, err := fileutil.ReadAll(criShimConfig); err != nil {
 		status.Error = fmt.Errorf("read crictl config error: %w", err).Error()
@@ -83,7 +90,7 @@ func (n *CRICtlChecker) Check(cluster *v2.Cluster, phase string) error {
 	crictlPath, err := execer.LookPath("crictl")
 	if err != nil {
 		status.Error = fmt.Errorf("error looking for path of crictl: %w", err).Error()
-		return nil
+		return status, nil
 	}
 
 	imageList, err := n.getCRICtlImageList(crictlPath)
@@ -98,24 +105,14 @@ func (n *CRICtlChecker) Check(cluster *v2.Cluster, phase string) error {
 	}
 	status.ContainerList = containerList
 
-	pauseImage := ""
-	for _, mountImg := range cluster.Status.Mounts {
-		if mountImg.IsRootFs() || mountImg.IsPatch() {
-			if v, ok := mountImg.Env["sandboxImage"]; ok {
-				pauseImage = v
-				break
-			}
-		}
-	}
+	pauseImage := getPauseImage(cluster)
 	sshCtx := ssh.NewCacheClientFromCluster(cluster, false)
 	sshCtx, err = exec.New(sshCtx)
 	if err != nil {
-		return err
+		return status, err
 	}
-	root := constants.NewPathResolver(cluster.Name).RootFSPath()
-	regInfo := helpers.GetRegistryInfo(sshCtx, root, cluster.GetRegistryIPAndPort())
 
-	regStatus, err := n.getRegistryStatus(crictlPath, pauseImage, fmt.Sprintf("%s:%s", regInfo.Domain, regInfo.Port))
+	regStatus, err := n.getRegistryStatus(crictlPath, pauseImage, getRegistryAddress(sshCtx, cluster))
 	if err != nil {
 		status.Error = fmt.Errorf("pull registry image error: %w", err).Error()
 	}
@@ -126,8 +123,73 @@ func (n *CRICtlChecker) Check(cluster *v2.Cluster, phase string) error {
 		status.Error = fmt.Errorf("pull shim image error: %w", err).Error()
 	}
 	status.ImageShimPullStatus = shimStatus
-	status.Error = Nil
-	return nil
+	if status.Error == "" {
+		status.Error = Nil
+	}
+	return status, nil
+}
+
+func (n *CRICtlChecker) Name() string {
+	return "cri"
+}
+
+func getPauseImage(cluster *v2.Cluster) string {
+	for _, mountImg := range cluster.Status.Mounts {
+		if mountImg.IsRootFs() || mountImg.IsPatch() {
+			if v, ok := mountImg.Env["sandboxImage"]; ok {
+				return v
+			}
+		}
+	}
+	return ""
+}
+
+func getRegistryAddress(execer ssh.Interface, cluster *v2.Cluster) string {
+	root := constants.NewPathResolver(cluster.Name).RootFSPath()
+	regInfo := helpers.GetRegistryInfo(execer, root, cluster.GetRegistryIPAndPort())
+	return fmt.Sprintf("%s:%s", regInfo.Domain, regInfo.Port)
+}
+
+// Report reports the containers which are not running and whether images can be pulled
+// from the registry on every host.
+func (n *CRICtlChecker) Report(cluster *v2.Cluster) []Result {
+	pauseImage := getPauseImage(cluster)
+	var (
+		once     sync.Once
+		registry string
+	)
+	return reportOnHosts(cluster, func(execer exec.Interface, host string) []Result {
+		once.Do(func() { registry = getRegistryAddress(execer, cluster) })
+		return n.reportHost(execer, host, pauseImage, registry)
+	})
+}
+
+func (n *CRICtlChecker) reportHost(execer exec.Interface, host, pauseImage, registry string) []Result {
+	psOut, err := execer.Cmd(host, "crictl ps -a -o json")
+	if err != nil {
+		return []Result{newResult(host, fmt.Errorf("error ps container of crictl: %w: %s", err, psOut))}
+	}
+	for _, reg := range []string{registry, "k8s.gcr.io"} {
+		if out, err := execer.Cmd(host, fmt.Sprintf("crictl pull %s/%s", reg, pauseImage)); err != nil {
+			return []Result{newResult(host, fmt.Errorf("pull image from %s error: %w: %s", reg, err, strings.TrimSpace(string(out))))}
+		}
+	}
+	return []Result{containerResult(host, parseCRICtlContainers(psOut))}
+}
+
+func containerResult(host string, containers []Container) Result {
+	result := Result{Host: host, Status: StatusPass, Message: fmt.Sprintf("%d containers", len(containers))}
+	var notRunning []string
+	for _, c := range containers {
+		if c.State != containerRunning && c.State != containerExited {
+			notRunning = append(notRunning, fmt.Sprintf("%s(%s)", c.Name, c.State))
+		}
+	}
+	if len(notRunning) > 0 {
+		result.Status = StatusWarn
+		result.Message = fmt.Sprintf("containers not running: %s", strings.Join(notRunning, ","))
+	}
+	return result
 }
 
 func (n *CRICtlChecker) Output(status *CRICtlStatus) error {
@@ -181,6 +243,16 @@ func (n *CRICtlChecker) getCRICtlImageList(crictlPath string) ([]string, error)
 }
 
 func (n *CRICtlChecker) getCRICtlContainerList(crictlPath string) ([]Container, error) {
+	psCmd := `%s  ps -a -o json`
+	psOut, err := exec2.RunBashCmd(fmt.Sprintf(psCmd, crictlPath))
+	if err != nil {
+		return nil, err
+	}
+	return parseCRICtlContainers([]byte(psOut)), nil
+}
+
+// parseCRICtlContainers parses the output of crictl ps -o json.
+func parseCRICtlContainers(psOut []byte) []Container {
 	type psStruct struct {
 		Containers []struct {
 			ID           string `json:"id"`
@@ -198,12 +270,7 @@ func (n *CRICtlChecker) getCRICtlContainerList(crictlPath string) ([]Container,
 		}
 	}
 	ps := &psStruct{}
-	psCmd := `%s  ps -a -o json`
-	psOut, err := exec2.RunBashCmd(fmt.Sprintf(psCmd, crictlPath))
-	if err != nil {
-		return nil, err
-	}
-	_ = json.Unmarshal([]byte(psOut), ps)
+	_ = json.Unmarshal(psOut, ps)
 
 	containerList := make([]Container, 0)
 
@@ -217,7 +284,7 @@ func (n *CRICtlChecker) getCRICtlContainerList(crictlPath string) ([]Container,
 			PodName:   c.Labels["io.kubernetes.pod.name"],
 		})
 	}
-	return containerList, nil
+	return containerList
 }
 
 func (n *CRICtlChecker) getRegistryStatus(crictlPath string, pauseImage string, registry string) (status string, err error) {
@@ -75,22 +75,65 @@ func checkHostnameUnique(s exec.Interface, ipList []string) error {
 func checkTimeSync(s exec.Interface, ipList []string) error {
 	logger.Info("checker:timeSync %v", ipList)
 	for _, ip := range ipList {
-		timestamp, err := s.CmdToString(ip, "date +%s", "")
+		timeDiff, err := getTimeSkew(s, ip)
 		if err != nil {
-			return fmt.Errorf("failed to get %s timestamp, %v", ip, err)
+			return err
 		}
-		ts, err := strconv.Atoi(timestamp)
-		if err != nil {
-			return fmt.Errorf("failed to reverse timestamp %s, %v", timestamp, err)
-		}
-		timeDiff := time.Since(time.Unix(int64(ts), 0)).Minutes()
-		if timeDiff < -1 || timeDiff > 1 {
+		if timeDiff < -maxTimeSkew || timeDiff > maxTimeSkew {
 			return fmt.Errorf("the time of %s node is not synchronized", ip)
 		}
 	}
 	return nil
 }
 
+const maxTimeSkew = time.Minute
+
+// getTimeSkew returns how far the clock of given host is behind the local one.
+func getTimeSkew(s exec.Interface, ip string) (time.Duration, error) {
+	timestamp, err := s.CmdToString(ip, "date +%s", "")
+	if err != nil {
+		return 0, fmt.Errorf("failed to get %s timestamp, %v", ip, err)
+	}
+	ts, err := strconv.Atoi(timestamp)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reverse timestamp %s, %v", timestamp, err)
+	}
+	return time.Since(time.Unix(int64(ts), 0)), nil
+}
+
+// TimeSkewChecker reports the clock skew between current host and every host of the cluster.
+type TimeSkewChecker struct{}
+
+func NewTimeSkewChecker() Reporter {
+	return &TimeSkewChecker{}
+}
+
+func (t *TimeSkewChecker) Name() string {
+	return "time-skew"
+}
+
+func (t *TimeSkewChecker) Report(cluster *v2.Cluster) []Result {
+	execer, err := exec.New(ssh.NewCacheClientFromCluster(cluster, false))
+	if err != nil {
+		return []Result{newResult("", err)}
+	}
+	hosts := append(cluster.GetMasterIPAndPortList(), cluster.GetNodeIPAndPortList()...)
+	results := make([]Result, 0, len(hosts))
+	for _, host := range hosts {
+		skew, err := getTimeSkew(execer, host)
+		if err != nil {
+			results = append(results, newResult(host, err))
+			continue
+		}
+		result := Result{Host: host, Status: StatusPass, Message: fmt.Sprintf("skew %s", skew.Round(time.Second))}
+		if skew < -maxTimeSkew || skew > maxTimeSkew {
+			result.Status = StatusFail
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
 func confirmNonOddMasters() error {
 	prompt := "Warning: Using an even number of master nodes is a risky operation and can lead to reduced high availability and potential resource wastage. " +
 		"It is strongly recommended to use an odd number of master nodes for optimal cluster stability. " +
@@ -20,13 +20,21 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strings"
 
+	"github.com/labring/sealos/pkg/exec"
 	"github.com/labring/sealos/pkg/template"
 	v2 "github.com/labring/sealos/pkg/types/v1beta1"
 	"github.com/labring/sealos/pkg/utils/initsystem"
 	"github.com/labring/sealos/pkg/utils/logger"
 )
 
+const (
+	serviceNotExists = "NotExists"
+	serviceDisabled  = "Disable"
+	serviceNotActive = "NotActive"
+)
+
 type InitSystemChecker struct {
 }
 
@@ -44,23 +52,25 @@ func (n *InitSystemChecker) Check(_ *v2.Cluster, phase string) error {
 	if phase != PhasePost {
 		return nil
 	}
-	status := &InitSystemStatus{}
+	status := n.collect()
+	if err := n.Output(status); err != nil {
+		logger.Error("error output: %+v", err)
+	}
+	return nil
+}
 
-	defer func() {
-		err := n.Output(status)
-		if err != nil {
-			logger.Error("error output: %+v", err)
-		}
-	}()
+var initSystemServiceNames = []string{"kubelet", "containerd", "cri-docker", "docker", "registry", "image-cri-shim"}
+
+func (n *InitSystemChecker) collect() *InitSystemStatus {
+	status := &InitSystemStatus{}
 	initsystemvar, err := initsystem.GetInitSystem()
 	if err != nil {
 		status.Error = fmt.Errorf("get initsystem error: %w", err).Error()
-		return nil
+		return status
 	}
 
-	serviceNames := []string{"kubelet", "containerd", "cri-docker", "docker", "registry", "image-cri-shim"}
 	status.ServiceList = make([]systemStatus, 0)
-	for _, sn := range serviceNames {
+	for _, sn := range initSystemServiceNames {
 		status.ServiceList = append(status.ServiceList, systemStatus{
 			Name:   sn,
 			Status: n.checkInitSystem(initsystemvar, sn),
@@ -68,7 +78,67 @@ func (n *InitSystemChecker) Check(_ *v2.Cluster, phase string) error {
 	}
 
 	status.Error = Nil
-	return nil
+	return status
+}
+
+func (n *InitSystemChecker) Name() string {
+	return "service"
+}
+
+// remoteServiceStatusCmd prints the status of every service like checkInitSystem,
+// one line per service prefixed with its name.
+const remoteServiceStatusCmd = `for s in %s; do
+  if ! systemctl cat "$s" >/dev/null 2>&1; then echo "$s %s"; continue; fi
+  e=Enable; systemctl is-enabled -q "$s" || e=%s
+  case "$(systemctl is-active "$s")" in active|activating) a=Active ;; *) a=%s ;; esac
+  echo "$s $e && $a"
+done`
+
+// Report reports the services installed on every host, a stopped service fails
+// and a disabled one warns since it will not be started after rebooting.
+func (n *InitSystemChecker) Report(cluster *v2.Cluster) []Result {
+	return reportOnHosts(cluster, n.reportHost)
+}
+
+func (n *InitSystemChecker) reportHost(execer exec.Interface, host string) []Result {
+	cmd := fmt.Sprintf(remoteServiceStatusCmd, strings.Join(initSystemServiceNames, " "),
+		serviceNotExists, serviceDisabled, serviceNotActive)
+	out, err := execer.Cmd(host, cmd)
+	if err != nil {
+		return []Result{newResult(host, fmt.Errorf("get service status error: %w", err))}
+	}
+	return serviceResults(host, parseServiceStatus(string(out)))
+}
+
+// parseServiceStatus parses the output of remoteServiceStatusCmd.
+func parseServiceStatus(out string) []systemStatus {
+	list := make([]systemStatus, 0)
+	for _, line := range strings.Split(out, "\n") {
+		name, status, ok := strings.Cut(strings.TrimSpace(line), " ")
+		if !ok {
+			continue
+		}
+		list = append(list, systemStatus{Name: name, Status: status})
+	}
+	return list
+}
+
+func serviceResults(host string, services []systemStatus) []Result {
+	results := make([]Result, 0, len(services))
+	for _, svc := range services {
+		if svc.Status == serviceNotExists {
+			continue
+		}
+		result := Result{Check: fmt.Sprintf("service/%s", svc.Name), Host: host, Status: StatusPass, Message: svc.Status}
+		switch {
+		case strings.HasSuffix(svc.Status, serviceNotActive):
+			result.Status = StatusFail
+		case strings.HasPrefix(svc.Status, serviceDisabled):
+			result.Status = StatusWarn
+		}
+		results = append(results, result)
+	}
+	return results
 }
 
 func (n *InitSystemChecker) Output(status *InitSystemStatus) error {
@@ -98,16 +168,16 @@ func NewInitSystemChecker() Interface {
 
 func (n *InitSystemChecker) checkInitSystem(system initsystem.InitSystem, name string) (status string) {
 	if !system.ServiceExists(name) {
-		status = "NotExists"
+		status = serviceNotExists
 	} else {
 		var enable, subStatus string
 		if !system.ServiceIsEnabled(name) {
-			enable = "Disable"
+			enable = serviceDisabled
 		} else {
 			enable = "Enable"
 		}
 		if !system.ServiceIsActive(name) {
-			subStatus = "NotActive"
+			subStatus = serviceNotActive
 		} else {
 			subStatus = "Active"
 		}
@@ -0,0 +1,48 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestServiceResults(t *testing.T) {
+	out := `kubelet Enable && Active
+containerd Disable && Active
+docker NotExists
+image-cri-shim Enable && NotActive
+`
+	got := serviceResults("192.168.0.3:22", parseServiceStatus(out))
+	want := []Result{
+		{Check: "service/kubelet", Host: "192.168.0.3:22", Status: StatusPass, Message: "Enable && Active"},
+		{Check: "service/containerd", Host: "192.168.0.3:22", Status: StatusWarn, Message: "Disable && Active"},
+		{Check: "service/image-cri-shim", Host: "192.168.0.3:22", Status: StatusFail, Message: "Enable && NotActive"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("serviceResults() = %+v, want %+v", got, want)
+	}
+}
+
+func TestContainerResult(t *testing.T) {
+	out := []byte(`{"containers": [
+  {"id": "0123456789abcdef", "metadata": {"name": "kube-apiserver"}, "state": "CONTAINER_RUNNING"},
+  {"id": "fedcba9876543210", "metadata": {"name": "coredns"}, "state": "CONTAINER_UNKNOWN"}
+]}`)
+	got := containerResult("192.168.0.3:22", parseCRICtlContainers(out))
+	if got.Status != StatusWarn || got.Message != "containers not running: coredns(CONTAINER_UNKNOWN)" {
+		t.Errorf("containerResult() = %+v", got)
+	}
+}
@@ -17,6 +17,7 @@ package checker
 import (
 	"context"
 	"errors"
+	"fmt"
 	"os"
 
 	corev1 "k8s.io/api/core/v1"
@@ -127,6 +128,31 @@ func getNodeStatus(node corev1.Node) (IP string, Phase string) {
 	return IP, Phase
 }
 
+func (n *NodeChecker) Name() string {
+	return "node"
+}
+
+func (n *NodeChecker) Report(cluster *v2.Cluster) []Result {
+	c, err := newKubeClient(cluster)
+	if err != nil {
+		return []Result{newResult("", err)}
+	}
+	nodes, err := c.Kubernetes().CoreV1().Nodes().List(context.Background(), v1.ListOptions{})
+	if err != nil {
+		return []Result{newResult("", err)}
+	}
+	results := make([]Result, 0, len(nodes.Items))
+	for _, node := range nodes.Items {
+		nodeIP, nodePhase := getNodeStatus(node)
+		result := Result{Host: nodeIP, Status: StatusPass, Message: fmt.Sprintf("node %s is %s", node.Name, nodePhase)}
+		if nodePhase != ReadyNodeStatus {
+			result.Status = StatusFail
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
 func NewNodeChecker() Interface {
 	return &NodeChecker{}
 }
@@ -17,7 +17,9 @@ package checker
 import (
 	"context"
 	"errors"
+	"fmt"
 	"os"
+	"strings"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -127,6 +129,47 @@ func getPodReadyStatus(pod corev1.Pod) error {
 	return &NotFindReadyTypeError{}
 }
 
+func (n *PodChecker) Name() string {
+	return "pod"
+}
+
+// Report reports the pods which are not ready per namespace.
+func (n *PodChecker) Report(cluster *v2.Cluster) []Result {
+	c, err := newKubeClient(cluster)
+	if err != nil {
+		return []Result{newResult("", err)}
+	}
+	pods, err := c.Kubernetes().CoreV1().Pods(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return []Result{newResult("", err)}
+	}
+	notReady := make(map[string][]string)
+	namespaces := make([]string, 0)
+	for _, pod := range pods.Items {
+		if _, ok := notReady[pod.Namespace]; !ok {
+			notReady[pod.Namespace] = make([]string, 0)
+			namespaces = append(namespaces, pod.Namespace)
+		}
+		// pods of completed jobs are never ready
+		if pod.Status.Phase == corev1.PodSucceeded {
+			continue
+		}
+		if err := getPodReadyStatus(pod); err != nil {
+			notReady[pod.Namespace] = append(notReady[pod.Namespace], pod.Name)
+		}
+	}
+	results := make([]Result, 0, len(namespaces))
+	for _, ns := range namespaces {
+		result := Result{Check: fmt.Sprintf("pod/%s", ns), Status: StatusPass}
+		if len(notReady[ns]) > 0 {
+			result.Status = StatusFail
+			result.Message = fmt.Sprintf("pods not ready: %s", strings.Join(notReady[ns], ","))
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
 func NewPodChecker() Interface {
 	return &PodChecker{}
 }
@@ -59,8 +59,7 @@ func (n *RegistryChecker) Check(cluster *v2.Cluster, phase string) error {
 	if phase != PhasePost {
 		return nil
 	}
-	localAddr, _ := iputils.ListLocalHostAddrs()
-	if !iputils.IsLocalIP(cluster.GetRegistryIP(), localAddr) {
+	if !isLocalRegistry(cluster) {
 		logger.Info("current registry ip is %s,not local addr,skip check.", cluster.GetRegistryIP())
 		return nil
 	}
@@ -71,7 +70,15 @@ func (n *RegistryChecker) Check(cluster *v2.Cluster, phase string) error {
 			logger.Error("error output: %+v", err)
 		}
 	}()
+	return n.collect(cluster, status)
+}
+
+func isLocalRegistry(cluster *v2.Cluster) bool {
+	localAddr, err := iputils.ListLocalHostAddrs()
+	return err == nil && iputils.IsLocalIP(cluster.GetRegistryIP(), localAddr)
+}
 
+func (n *RegistryChecker) collect(cluster *v2.Cluster, status *RegistryStatus) error {
 	registryConfig := "/etc/registry/registry_config.yml"
 	if cfg, err := fileutil.ReadAll(registryConfig); err != nil {
 		status.Error = fmt.Errorf("read registry config error: %w", err).Error()
@@ -107,7 +114,9 @@ func (n *RegistryChecker) Check(cluster *v2.Cluster, phase string) error {
 		return nil
 	}
 	status.Ping = "ok"
-	status.Error = Nil
+	if status.Error == "" {
+		status.Error = Nil
+	}
 	return nil
 }
 
@@ -134,6 +143,25 @@ func (n *RegistryChecker) Output(status *RegistryStatus) error {
 	return tpl.Execute(os.Stdout, status)
 }
 
+func (n *RegistryChecker) Name() string {
+	return "registry"
+}
+
+func (n *RegistryChecker) Report(cluster *v2.Cluster) []Result {
+	host := cluster.GetRegistryIP()
+	if !isLocalRegistry(cluster) {
+		return []Result{{Host: host, Status: StatusWarn, Message: "registry is not on current host, skipped"}}
+	}
+	status := &RegistryStatus{}
+	if err := n.collect(cluster, status); err != nil {
+		return []Result{newResult(host, err)}
+	}
+	if status.Error != Nil {
+		return []Result{{Host: host, Status: StatusFail, Message: status.Error}}
+	}
+	return []Result{{Host: host, Status: StatusPass, Message: fmt.Sprintf("ping %s %s", status.RegistryDomain, status.Ping)}}
+}
+
 func NewRegistryChecker() Interface {
 	return &RegistryChecker{}
 }
@@ -0,0 +1,209 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/labring/sealos/pkg/client-go/kubernetes"
+	"github.com/labring/sealos/pkg/constants"
+	"github.com/labring/sealos/pkg/exec"
+	"github.com/labring/sealos/pkg/ssh"
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+)
+
+type Status string
+
+const (
+	StatusPass Status = "PASS"
+	StatusWarn Status = "WARN"
+	StatusFail Status = "FAIL"
+)
+
+const (
+	OutputTable = "table"
+	OutputJSON  = "json"
+	OutputYAML  = "yaml"
+)
+
+// hostCluster is the host of the results which are about the whole cluster.
+const hostCluster = "-"
+
+func (s Status) severity() int {
+	switch s {
+	case StatusWarn:
+		return 1
+	case StatusFail:
+		return 2
+	}
+	return 0
+}
+
+// ParseStatus parses the status name case-insensitively.
+func ParseStatus(s string) (Status, error) {
+	for _, status := range []Status{StatusPass, StatusWarn, StatusFail} {
+		if strings.EqualFold(s, string(status)) {
+			return status, nil
+		}
+	}
+	return "", fmt.Errorf("unknown status %s, must be one of %s, %s, %s", s, StatusPass, StatusWarn, StatusFail)
+}
+
+// Result is the result of a check on a host.
+type Result struct {
+	Check   string `json:"check"`
+	Host    string `json:"host"`
+	Status  Status `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// Reporter is a checker which collects its results per host instead of printing them,
+// it should report a failed result rather than stopping at the first error.
+type Reporter interface {
+	Name() string
+	Report(cluster *v2.Cluster) []Result
+}
+
+// Report is the results of all checks of a cluster.
+type Report struct {
+	Cluster string         `json:"cluster"`
+	Status  Status         `json:"status"`
+	Summary map[Status]int `json:"summary"`
+	Results []Result       `json:"results"`
+}
+
+// RunReporters runs every reporter and collects all the results.
+func RunReporters(list []Reporter, cluster *v2.Cluster) *Report {
+	report := &Report{
+		Cluster: cluster.Name,
+		Status:  StatusPass,
+		Summary: map[Status]int{StatusPass: 0, StatusWarn: 0, StatusFail: 0},
+		Results: make([]Result, 0),
+	}
+	for _, r := range list {
+		for _, result := range r.Report(cluster) {
+			if result.Check == "" {
+				result.Check = r.Name()
+			}
+			if result.Host == "" {
+				result.Host = hostCluster
+			}
+			report.Summary[result.Status]++
+			if result.Status.severity() > report.Status.severity() {
+				report.Status = result.Status
+			}
+			report.Results = append(report.Results, result)
+		}
+	}
+	return report
+}
+
+// Err returns an error if any result is as bad as or worse than the given status.
+func (r *Report) Err(failOn Status) error {
+	if r.Status == StatusPass || r.Status.severity() < failOn.severity() {
+		return nil
+	}
+	return fmt.Errorf("cluster %s status is %s: %d failed, %d warning",
+		r.Cluster, r.Status, r.Summary[StatusFail], r.Summary[StatusWarn])
+}
+
+// Print writes the report in the given format, table, json or yaml.
+func (r *Report) Print(w io.Writer, format string) error {
+	switch format {
+	case OutputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	case OutputYAML:
+		data, err := yaml.Marshal(r)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	case OutputTable, "":
+		return r.printTable(w)
+	}
+	return fmt.Errorf("unsupported output format %s, only %s, %s and %s are supported", format, OutputTable, OutputJSON, OutputYAML)
+}
+
+func (r *Report) printTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CHECK\tHOST\tSTATUS\tMESSAGE")
+	for _, result := range r.Results {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", result.Check, result.Host, result.Status, strings.ReplaceAll(result.Message, "\n", "; "))
+	}
+	fmt.Fprintf(tw, "\nCluster %s is %s: %d passed, %d warning, %d failed\n",
+		r.Cluster, r.Status, r.Summary[StatusPass], r.Summary[StatusWarn], r.Summary[StatusFail])
+	return tw.Flush()
+}
+
+func newResult(host string, err error) Result {
+	if err != nil {
+		return Result{Host: host, Status: StatusFail, Message: err.Error()}
+	}
+	return Result{Host: host, Status: StatusPass}
+}
+
+func newKubeClient(cluster *v2.Cluster) (kubernetes.Client, error) {
+	return kubernetes.NewKubernetesClient(constants.NewPathResolver(cluster.Name).AdminFile(), "")
+}
+
+// reportOnHosts runs the report of a check about host-local daemons on every host of the
+// cluster in parallel through the ssh executor, which runs the commands of current host locally.
+func reportOnHosts(cluster *v2.Cluster, report func(execer exec.Interface, host string) []Result) []Result {
+	execer, err := exec.New(ssh.NewCacheClientFromCluster(cluster, false))
+	if err != nil {
+		return []Result{newResult("", err)}
+	}
+	hosts := cluster.GetAllIPS()
+	perHost := make([][]Result, len(hosts))
+	var wg sync.WaitGroup
+	for i := range hosts {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			perHost[i] = report(execer, hosts[i])
+		}(i)
+	}
+	wg.Wait()
+	results := make([]Result, 0, len(hosts))
+	for _, list := range perHost {
+		results = append(results, list...)
+	}
+	return results
+}
+
+// NewStatusReporters returns the built-in checkers of the cluster status.
+func NewStatusReporters() []Reporter {
+	return []Reporter{
+		&RegistryChecker{},
+		&CRIShimChecker{},
+		&CRICtlChecker{},
+		&InitSystemChecker{},
+		&NodeChecker{},
+		&PodChecker{},
+		&SvcChecker{},
+		&ClusterChecker{},
+		NewTimeSkewChecker(),
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+)
+
+type fakeReporter struct {
+	name    string
+	results []Result
+}
+
+func (f *fakeReporter) Name() string                { return f.name }
+func (f *fakeReporter) Report(*v2.Cluster) []Result { return f.results }
+
+func TestRunReporters(t *testing.T) {
+	cluster := &v2.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "default"}}
+	list := []Reporter{
+		&fakeReporter{name: "node", results: []Result{
+			{Host: "192.168.0.2", Status: StatusPass},
+			{Host: "192.168.0.3", Status: StatusFail, Message: "node node3 is NotReady"},
+		}},
+		&fakeReporter{name: "service", results: []Result{
+			{Check: "service/default", Status: StatusWarn, Message: "services without endpoints: foo"},
+		}},
+	}
+	report := RunReporters(list, cluster)
+	if report.Status != StatusFail {
+		t.Errorf("report status = %s, want %s", report.Status, StatusFail)
+	}
+	if report.Results[0].Check != "node" || report.Results[2].Host != hostCluster {
+		t.Errorf("check name and host should be defaulted, got %+v", report.Results)
+	}
+	if report.Summary[StatusPass] != 1 || report.Summary[StatusWarn] != 1 || report.Summary[StatusFail] != 1 {
+		t.Errorf("unexpected summary %+v", report.Summary)
+	}
+
+	tests := []struct {
+		failOn  Status
+		results []Result
+		wantErr bool
+	}{
+		{StatusFail, []Result{{Status: StatusWarn}}, false},
+		{StatusWarn, []Result{{Status: StatusWarn}}, true},
+		{StatusFail, []Result{{Status: StatusFail}}, true},
+		{StatusWarn, []Result{{Status: StatusPass}}, false},
+	}
+	for _, tt := range tests {
+		r := RunReporters([]Reporter{&fakeReporter{name: "fake", results: tt.results}}, cluster)
+		if err := r.Err(tt.failOn); (err != nil) != tt.wantErr {
+			t.Errorf("Err(%s) with %s error = %v, wantErr %v", tt.failOn, r.Status, err, tt.wantErr)
+		}
+	}
+}
+
+func TestReport_Print(t *testing.T) {
+	cluster := &v2.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "default"}}
+	report := RunReporters([]Reporter{&fakeReporter{name: "node", results: []Result{
+		{Host: "192.168.0.3", Status: StatusFail, Message: "node node3 is NotReady"},
+	}}}, cluster)
+
+	buf := &bytes.Buffer{}
+	if err := report.Print(buf, OutputTable); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "node node3 is NotReady") || !strings.Contains(buf.String(), "Cluster default is FAIL") {
+		t.Errorf("unexpected table output:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := report.Print(buf, OutputJSON); err != nil {
+		t.Fatal(err)
+	}
+	got := &Report{}
+	if err := json.Unmarshal(buf.Bytes(), got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != StatusFail || len(got.Results) != 1 {
+		t.Errorf("unexpected json output %+v", got)
+	}
+
+	if err := report.Print(buf, "wide"); err == nil {
+		t.Errorf("expect error for unsupported output format")
+	}
+}
+
+func TestLoadScriptCheckers(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"disk-pressure.sh":       "df -h /",
+		".hidden":                "exit 2",
+		"masters/etcd-health.sh": "etcdctl endpoint health",
+	}
+	for name, content := range files {
+		fp := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(fp), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fp, []byte(content), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	list, err := LoadScriptCheckers(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]bool)
+	for _, r := range list {
+		got[r.Name()] = r.(*ScriptChecker).mastersOnly
+	}
+	want := map[string]bool{"disk-pressure": false, "etcd-health": true}
+	if len(got) != len(want) || got["disk-pressure"] != want["disk-pressure"] || got["etcd-health"] != want["etcd-health"] {
+		t.Errorf("LoadScriptCheckers() = %v, want %v", got, want)
+	}
+
+	if _, err = LoadScriptCheckers(filepath.Join(dir, "not-exist")); err == nil {
+		t.Errorf("expect error for the directory not exists")
+	}
+}
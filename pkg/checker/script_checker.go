@@ -0,0 +1,150 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/labring/sealos/pkg/constants"
+	"github.com/labring/sealos/pkg/exec"
+	"github.com/labring/sealos/pkg/ssh"
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+	fileutil "github.com/labring/sealos/pkg/utils/file"
+)
+
+const (
+	// scripts in this sub directory of a checker directory only run on masters
+	mastersCheckerDirName = "masters"
+	exitCodeMarker        = "__SEALOS_CHECKER_EXIT_CODE__="
+	runScriptCmd          = "echo %s | base64 -d | bash 2>&1; echo " + exitCodeMarker + "$?"
+)
+
+// ScriptChecker runs a bash script on the hosts of cluster, the exit code of the script
+// decides the status like nagios plugins do: 0 is PASS, 1 is WARN and others are FAIL.
+// The output of the script is reported as the message.
+type ScriptChecker struct {
+	name        string
+	content     []byte
+	mastersOnly bool
+}
+
+func NewScriptChecker(name string, content []byte, mastersOnly bool) Reporter {
+	return &ScriptChecker{name: name, content: content, mastersOnly: mastersOnly}
+}
+
+func (s *ScriptChecker) Name() string {
+	return s.name
+}
+
+func (s *ScriptChecker) Report(cluster *v2.Cluster) []Result {
+	hosts := cluster.GetMasterIPAndPortList()
+	if !s.mastersOnly {
+		hosts = append(hosts, cluster.GetNodeIPAndPortList()...)
+	}
+	execer, err := exec.New(ssh.NewCacheClientFromCluster(cluster, false))
+	if err != nil {
+		return []Result{newResult("", err)}
+	}
+	cmd := fmt.Sprintf(runScriptCmd, base64.StdEncoding.EncodeToString(s.content))
+	results := make([]Result, len(hosts))
+	var wg sync.WaitGroup
+	for i := range hosts {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = s.run(execer, hosts[i], cmd)
+		}(i)
+	}
+	wg.Wait()
+	return results
+}
+
+func (s *ScriptChecker) run(execer exec.Interface, host, cmd string) Result {
+	result := Result{Host: host, Status: StatusFail}
+	out, err := execer.Cmd(host, cmd)
+	if err != nil {
+		result.Message = fmt.Sprintf("failed to run checker: %v", err)
+		return result
+	}
+	output := strings.TrimSpace(string(out))
+	idx := strings.LastIndex(output, exitCodeMarker)
+	if idx < 0 {
+		result.Message = fmt.Sprintf("exit code not found in output %q", output)
+		return result
+	}
+	code, err := strconv.Atoi(strings.TrimSpace(output[idx+len(exitCodeMarker):]))
+	if err != nil {
+		result.Message = fmt.Sprintf("invalid exit code: %v", err)
+		return result
+	}
+	result.Message = strings.TrimSpace(output[:idx])
+	switch code {
+	case 0:
+		result.Status = StatusPass
+	case 1:
+		result.Status = StatusWarn
+	}
+	return result
+}
+
+// LoadScriptCheckers loads the scripts in the given directories as checkers, scripts in the
+// top level run on all hosts and the ones in the `masters` sub directory run on masters only.
+func LoadScriptCheckers(dirs ...string) ([]Reporter, error) {
+	list := make([]Reporter, 0)
+	for _, dir := range dirs {
+		for _, sub := range []string{"", mastersCheckerDirName} {
+			path := filepath.Join(dir, sub)
+			if sub != "" && !fileutil.IsDir(path) {
+				continue
+			}
+			entries, err := os.ReadDir(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load checkers from %s: %v", path, err)
+			}
+			for _, entry := range entries {
+				if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+					continue
+				}
+				content, err := os.ReadFile(filepath.Join(path, entry.Name()))
+				if err != nil {
+					return nil, err
+				}
+				name := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+				list = append(list, NewScriptChecker(name, content, sub == mastersCheckerDirName))
+			}
+		}
+	}
+	return list, nil
+}
+
+// ImageCheckerDirs returns the `checkers` directories of the images mounted in the cluster.
+func ImageCheckerDirs(cluster *v2.Cluster) []string {
+	var dirs []string
+	for _, m := range cluster.Status.Mounts {
+		if m.MountPoint == "" {
+			continue
+		}
+		if dir := filepath.Join(m.MountPoint, constants.CheckersDirName); fileutil.IsDir(dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
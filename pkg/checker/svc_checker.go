@@ -17,7 +17,9 @@ package checker
 import (
 	"context"
 	"errors"
+	"fmt"
 	"os"
+	"strings"
 
 	"github.com/labring/sealos/pkg/template"
 
@@ -135,6 +137,50 @@ func IsExistEndpoint(endpointList *corev1.EndpointsList, serviceName string) boo
 	return false
 }
 
+func (n *SvcChecker) Name() string {
+	return "service"
+}
+
+// Report warns the services without any endpoint per namespace.
+func (n *SvcChecker) Report(cluster *v2.Cluster) []Result {
+	c, err := newKubeClient(cluster)
+	if err != nil {
+		return []Result{newResult("", err)}
+	}
+	nsList, err := c.Kubernetes().CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return []Result{newResult("", err)}
+	}
+	results := make([]Result, 0, len(nsList.Items))
+	for _, ns := range nsList.Items {
+		check := fmt.Sprintf("service/%s", ns.Name)
+		svcList, err := c.Kubernetes().CoreV1().Services(ns.Name).List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			results = append(results, Result{Check: check, Status: StatusFail, Message: err.Error()})
+			continue
+		}
+		epList, err := c.Kubernetes().CoreV1().Endpoints(ns.Name).List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			results = append(results, Result{Check: check, Status: StatusFail, Message: err.Error()})
+			continue
+		}
+		var unhealthy []string
+		for _, svc := range svcList.Items {
+			// ExternalName services never have any endpoint
+			if svc.Spec.Type != corev1.ServiceTypeExternalName && !IsExistEndpoint(epList, svc.Name) {
+				unhealthy = append(unhealthy, svc.Name)
+			}
+		}
+		result := Result{Check: check, Status: StatusPass}
+		if len(unhealthy) > 0 {
+			result.Status = StatusWarn
+			result.Message = fmt.Sprintf("services without endpoints: %s", strings.Join(unhealthy, ","))
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
 func NewSvcChecker() Interface {
 	return &SvcChecker{}
 }
@@ -0,0 +1,140 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/kubectl/pkg/drain"
+
+	"github.com/labring/sealos/pkg/utils/logger"
+)
+
+const DefaultDrainTimeout = 5 * time.Minute
+
+// DrainOptions controls how pods are evicted from a node.
+type DrainOptions struct {
+	// Timeout is the maximum time to wait for all pods to be evicted, zero means infinite.
+	Timeout time.Duration
+	// Force deletes pods that are not managed by any controller as well.
+	Force bool
+	// GracePeriodSeconds overrides the pod termination grace period, negative means using the pod's own.
+	GracePeriodSeconds int
+}
+
+func NewDefaultDrainOptions() *DrainOptions {
+	return &DrainOptions{
+		Timeout:            DefaultDrainTimeout,
+		GracePeriodSeconds: -1,
+	}
+}
+
+// Drainer cordons a node and evicts its pods through the eviction API,
+// so that PodDisruptionBudgets are respected.
+type Drainer interface {
+	// Cordon marks the node as unschedulable
+	Cordon(ctx context.Context, nodeName string) error
+	// Drain cordons the node and evicts all pods except mirror pods and DaemonSet pods
+	Drain(ctx context.Context, nodeName string) error
+}
+
+type kubeDrainer struct {
+	client clientset.Interface
+	opts   DrainOptions
+}
+
+// NewKubeDrainer returns a new Drainer object that talks to the given Kubernetes cluster
+func NewKubeDrainer(client clientset.Interface, opts DrainOptions) Drainer {
+	return &kubeDrainer{
+		client: client,
+		opts:   opts,
+	}
+}
+
+func (kd *kubeDrainer) newHelper(ctx context.Context) *drain.Helper {
+	return &drain.Helper{
+		Ctx:                 ctx,
+		Client:              kd.client,
+		Force:               kd.opts.Force,
+		GracePeriodSeconds:  kd.opts.GracePeriodSeconds,
+		IgnoreAllDaemonSets: true,
+		DeleteEmptyDirData:  true,
+		Timeout:             kd.opts.Timeout,
+		Out:                 io.Discard,
+		ErrOut:              warnWriter{},
+	}
+}
+
+func (kd *kubeDrainer) Cordon(ctx context.Context, nodeName string) error {
+	node, err := kd.client.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	logger.Info("cordon node %s", nodeName)
+	return drain.RunCordonOrUncordon(kd.newHelper(ctx), node, true)
+}
+
+func (kd *kubeDrainer) Drain(ctx context.Context, nodeName string) error {
+	if err := kd.Cordon(ctx, nodeName); err != nil {
+		return fmt.Errorf("failed to cordon node %s: %v", nodeName, err)
+	}
+	helper := kd.newHelper(ctx)
+	list, errs := helper.GetPodsForDeletion(nodeName)
+	if len(errs) > 0 {
+		msgs := make([]string, 0, len(errs))
+		for _, e := range errs {
+			msgs = append(msgs, e.Error())
+		}
+		return fmt.Errorf("cannot drain node %s: %s", nodeName, strings.Join(msgs, "; "))
+	}
+	if warnings := list.Warnings(); warnings != "" {
+		logger.Warn("drain node %s: %s", nodeName, warnings)
+	}
+	pods := list.Pods()
+	if len(pods) == 0 {
+		logger.Info("no pods need to be evicted from node %s", nodeName)
+		return nil
+	}
+	logger.Info("start to evict %d pods from node %s", len(pods), nodeName)
+	var done int32
+	helper.OnPodDeletedOrEvicted = func(pod *v1.Pod, usingEviction bool) {
+		verb := "deleted"
+		if usingEviction {
+			verb = "evicted"
+		}
+		logger.Info("[%d/%d] pod %s/%s %s from node %s", atomic.AddInt32(&done, 1), len(pods), pod.Namespace, pod.Name, verb, nodeName)
+	}
+	if err := helper.DeleteOrEvictPods(pods); err != nil {
+		return fmt.Errorf("failed to drain node %s: %v", nodeName, err)
+	}
+	logger.Info("succeeded in draining node %s", nodeName)
+	return nil
+}
+
+// warnWriter forwards the messages written by the drain helper to the logger.
+type warnWriter struct{}
+
+func (warnWriter) Write(p []byte) (int, error) {
+	logger.Warn(strings.TrimSpace(string(p)))
+	return len(p), nil
+}
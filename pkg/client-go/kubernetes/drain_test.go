@@ -0,0 +1,130 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func newTestPod(name, node, ownerKind string) *v1.Pod {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: metav1.NamespaceDefault},
+		Spec:       v1.PodSpec{NodeName: node},
+	}
+	if ownerKind != "" {
+		controller := true
+		pod.OwnerReferences = []metav1.OwnerReference{{Kind: ownerKind, Name: name, Controller: &controller}}
+	}
+	return pod
+}
+
+func newFakeDrainClient(objects ...runtime.Object) *fake.Clientset {
+	client := fake.NewSimpleClientset(objects...)
+	client.Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "v1",
+			APIResources: []metav1.APIResource{{Name: "pods/eviction", Kind: "Eviction", Group: "policy", Version: "v1"}},
+		},
+		{
+			GroupVersion: "policy/v1",
+			APIResources: []metav1.APIResource{{Name: "evictions", Kind: "Eviction"}},
+		},
+	}
+	// the fake tracker doesn't know how to evict pods, so delete them instead
+	client.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "eviction" {
+			return false, nil, nil
+		}
+		eviction := action.(k8stesting.CreateAction).GetObject().(*policyv1.Eviction)
+		return true, nil, client.Tracker().Delete(v1.SchemeGroupVersion.WithResource("pods"), eviction.Namespace, eviction.Name)
+	})
+	return client
+}
+
+func TestKubeDrainer_Drain(t *testing.T) {
+	node := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}}
+	tests := []struct {
+		name         string
+		objects      []runtime.Object
+		opts         DrainOptions
+		wantErr      bool
+		wantDeleted  []string
+		wantRetained []string
+	}{
+		{
+			name: "evict managed pods and skip daemonset pods",
+			objects: []runtime.Object{
+				node,
+				&appsv1.DaemonSet{ObjectMeta: metav1.ObjectMeta{Name: "ds-pod", Namespace: metav1.NamespaceDefault}},
+				newTestPod("rs-pod", "node1", "ReplicaSet"),
+				newTestPod("ds-pod", "node1", "DaemonSet"),
+			},
+			opts:         DrainOptions{Timeout: 10 * time.Second},
+			wantDeleted:  []string{"rs-pod"},
+			wantRetained: []string{"ds-pod"},
+		},
+		{
+			name:         "refuse to delete unmanaged pods without force",
+			objects:      []runtime.Object{node, newTestPod("bare-pod", "node1", "")},
+			opts:         DrainOptions{Timeout: 10 * time.Second},
+			wantErr:      true,
+			wantRetained: []string{"bare-pod"},
+		},
+		{
+			name:        "delete unmanaged pods with force",
+			objects:     []runtime.Object{node, newTestPod("bare-pod", "node1", "")},
+			opts:        DrainOptions{Timeout: 10 * time.Second, Force: true},
+			wantDeleted: []string{"bare-pod"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newFakeDrainClient(tt.objects...)
+			ctx := context.Background()
+			err := NewKubeDrainer(client, tt.opts).Drain(ctx, "node1")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Drain() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			got, err := client.CoreV1().Nodes().Get(ctx, "node1", metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Spec.Unschedulable {
+				t.Errorf("node1 should be cordoned")
+			}
+			for _, name := range tt.wantDeleted {
+				if _, err = client.CoreV1().Pods(metav1.NamespaceDefault).Get(ctx, name, metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+					t.Errorf("pod %s should be removed, got error %v", name, err)
+				}
+			}
+			for _, name := range tt.wantRetained {
+				if _, err = client.CoreV1().Pods(metav1.NamespaceDefault).Get(ctx, name, metav1.GetOptions{}); err != nil {
+					t.Errorf("pod %s should be retained, got error %v", name, err)
+				}
+			}
+		})
+	}
+}
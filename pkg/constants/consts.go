@@ -15,7 +15,9 @@
 package constants
 
 const (
-	DefaultClusterFileName = "Clusterfile"
+	DefaultClusterFileName    = "Clusterfile"
+	DefaultCheckpointFileName = "checkpoint.yaml"
+	DefaultBackupsDirName     = "backups"
 )
 
 const TemplateSuffix = ".tmpl"
@@ -46,6 +46,7 @@ const (
 	PkiEtcdDirName              = "etcd"
 	ScriptsDirName              = "scripts"
 	StaticsDirName              = "statics"
+	CheckersDirName             = "checkers"
 )
 
 func GetHomeDir() string {
@@ -64,6 +65,14 @@ func Clusterfile(clusterName string) string {
 	return filepath.Join(DefaultRuntimeRootDir, clusterName, DefaultClusterFileName)
 }
 
+func Checkpoint(clusterName string) string {
+	return filepath.Join(DefaultRuntimeRootDir, clusterName, DefaultCheckpointFileName)
+}
+
+func BackupsDir(clusterName string) string {
+	return filepath.Join(DefaultRuntimeRootDir, clusterName, DefaultBackupsDirName)
+}
+
 func GetRuntimeRootDir(name string) string {
 	if v, ok := os.LookupEnv(strings.ToUpper(name) + "_RUNTIME_ROOT"); ok {
 		return v
@@ -0,0 +1,121 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rootfs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/labring/sealos/pkg/utils/file"
+	stringsutil "github.com/labring/sealos/pkg/utils/strings"
+)
+
+// renderCache keeps the rendered template files by their content hash, so that a file
+// rendered with the same content for many hosts is written to disk only once.
+type renderCache struct {
+	dir string
+
+	mu sync.Mutex
+	// hash of content -> path of the local file
+	files map[string]string
+	// path of the local file in the mount point -> hash of content
+	base map[string]string
+}
+
+func newRenderCache() (*renderCache, error) {
+	dir, err := file.MkTmpdir("")
+	if err != nil {
+		return nil, err
+	}
+	return &renderCache{
+		dir:   dir,
+		files: make(map[string]string),
+		base:  make(map[string]string),
+	}, nil
+}
+
+func hashOf(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// file returns the local file with the content, it's created if not exists yet.
+func (c *renderCache) file(data []byte) (string, error) {
+	hash := hashOf(data)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if fp, ok := c.files[hash]; ok {
+		return fp, nil
+	}
+	fp := filepath.Join(c.dir, hash)
+	if err := os.WriteFile(fp, data, os.ModePerm); err != nil {
+		return "", err
+	}
+	c.files[hash] = fp
+	return fp, nil
+}
+
+// baseHash returns the content hash of the file which has been copied to hosts along with the
+// mount point, empty if the file not exists.
+func (c *renderCache) baseHash(fp string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if hash, ok := c.base[fp]; ok {
+		return hash
+	}
+	var hash string
+	if data, err := os.ReadFile(fp); err == nil {
+		hash = hashOf(data)
+	}
+	c.base[fp] = hash
+	return hash
+}
+
+// renderedFile is a template rendered for a host which differs from the one in the mount point.
+type renderedFile struct {
+	// path relative to the mount point
+	rel   string
+	local string
+}
+
+// renderForHost renders the templates in the mount point with the envs of a host, only
+// the files whose contents differ from the ones already in the mount point are returned.
+func (c *renderCache) renderForHost(mountPoint string, envs map[string]string) ([]renderedFile, error) {
+	rendered, err := stringsutil.RenderTemplates(mountPoint, envs)
+	if err != nil {
+		return nil, err
+	}
+	var ret []renderedFile
+	for rel, data := range rendered {
+		if hashOf(data) == c.baseHash(filepath.Join(mountPoint, rel)) {
+			continue
+		}
+		local, err := c.file(data)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, renderedFile{rel: rel, local: local})
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].rel < ret[j].rel })
+	return ret, nil
+}
+
+func (c *renderCache) cleanup() error {
+	return os.RemoveAll(c.dir)
+}
@@ -0,0 +1,87 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rootfs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/labring/sealos/pkg/constants"
+	stringsutil "github.com/labring/sealos/pkg/utils/strings"
+)
+
+func TestRenderCache_renderForHost(t *testing.T) {
+	mountPoint := t.TempDir()
+	templates := map[string]string{
+		filepath.Join(constants.EtcDirName, "kubelet-flags.env.tmpl"):   "KUBELET_EXTRA_ARGS=--node-ip={{ .NODE_IP }}",
+		filepath.Join(constants.EtcDirName, "99-sysctl.conf.tmpl"):      "net.ipv4.ip_forward = 1",
+		filepath.Join(constants.ScriptsDirName, "init.sh.tmpl"):         "echo {{ .SEALOS_SYS_RUN_MODE }}",
+		filepath.Join(constants.ManifestsDirName, "registry.yaml.tmpl"): "image: {{ .registryImage }}",
+	}
+	for name, content := range templates {
+		fp := filepath.Join(mountPoint, name)
+		if err := os.MkdirAll(filepath.Dir(fp), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fp, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defaults := map[string]string{"NODE_IP": "192.168.0.2", "SEALOS_SYS_RUN_MODE": "master", "registryImage": "registry:2.8"}
+	if err := stringsutil.RenderTemplatesWithEnv(mountPoint, defaults); err != nil {
+		t.Fatal(err)
+	}
+
+	cache, err := newRenderCache()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = cache.cleanup() }()
+
+	files, err := cache.renderForHost(mountPoint, defaults)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 0 {
+		t.Errorf("files rendered with the defaults should not be sent again, got %+v", files)
+	}
+
+	var sent []renderedFile
+	for _, ip := range []string{"192.168.0.3", "192.168.0.4"} {
+		files, err = cache.renderForHost(mountPoint, map[string]string{"NODE_IP": ip, "SEALOS_SYS_RUN_MODE": "node", "registryImage": "registry:2.8"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(files) != 2 || files[0].rel != filepath.Join(constants.EtcDirName, "kubelet-flags.env") ||
+			files[1].rel != filepath.Join(constants.ScriptsDirName, "init.sh") {
+			t.Fatalf("unexpected rendered files for host %s: %+v", ip, files)
+		}
+		data, err := os.ReadFile(files[0].local)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != "KUBELET_EXTRA_ARGS=--node-ip="+ip {
+			t.Errorf("unexpected content for host %s: %s", ip, data)
+		}
+		sent = append(sent, files...)
+	}
+	if sent[1].local != sent[3].local {
+		t.Errorf("identical renders should share the same local file, got %s and %s", sent[1].local, sent[3].local)
+	}
+	if len(cache.files) != 3 {
+		t.Errorf("expect 3 distinct rendered files, got %d", len(cache.files))
+	}
+}
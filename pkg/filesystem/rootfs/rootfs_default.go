@@ -65,8 +65,8 @@ func (f *defaultRootfs) mountRootfs(cluster *v2.Cluster, ipList []string) error
 				logger.Debug("Image %s not exist, render env continue", src.ImageName)
 				return nil
 			}
-			// TODO: if we are planing to support rendering templates for each host,
-			// then move this rendering process before ssh.CopyDir and do it one by one.
+			// render with the envs of the first host as the defaults, rootfs and patch images
+			// are rendered again for each host after the mount point is copied to it.
 			envs := v2.MergeEnvWithBuiltinKeys(src.Env, src)
 			err := renderTemplatesWithEnv(src.MountPoint, ipList, envProcessor, envs)
 			if err != nil {
@@ -114,9 +114,36 @@ func (f *defaultRootfs) mountRootfs(cluster *v2.Cluster, ipList []string) error
 	}
 	rootfsEnvs := v2.MergeEnvWithBuiltinKeys(rootfs.Env, *rootfs)
 
+	cache, err := newRenderCache()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := cache.cleanup(); err != nil {
+			logger.Warn("failed to clean up rendered files: %v", err)
+		}
+	}()
+	// send the templates rendered with the envs of the host if they differ from the defaults
+	copyHostTemplatesFn := func(m v2.MountImage, targetHost, targetDir string, hostEnvs map[string]string) error {
+		envs := maps.Merge(v2.MergeEnvWithBuiltinKeys(m.Env, m), hostEnvs)
+		files, err := cache.renderForHost(m.MountPoint, envs)
+		if err != nil {
+			return fmt.Errorf("failed to render templates of image %s for host %s: %w", m.ImageName, targetHost, err)
+		}
+		for _, rf := range files {
+			logger.Debug("send rendered file %s of image %s to host %s", rf.rel, m.ImageName, targetHost)
+			if err := execer.Copy(targetHost, rf.local, filepath.Join(targetDir, rf.rel)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
 	for idx := range ipList {
 		ip := ipList[idx]
 		eg.Go(func() error {
+			hostEnvs := envProcessor.Getenv(ip)
+			hostEnvs[v2.ImageRunModeEnvSysKey] = strings.Join(cluster.GetRolesByIP(ip), ",")
 			var renderingRequired bool
 			for i := range f.mounts {
 				if f.mounts[i].IsRootFs() || f.mounts[i].IsPatch() {
@@ -125,14 +152,15 @@ func (f *defaultRootfs) mountRootfs(cluster *v2.Cluster, ipList []string) error
 					if err := copyFn(f.mounts[i], ip, target); err != nil {
 						return err
 					}
+					if err := copyHostTemplatesFn(f.mounts[i], ip, target, hostEnvs); err != nil {
+						return err
+					}
 				}
 			}
 			if !renderingRequired {
 				return nil
 			}
-			envs := envProcessor.Getenv(ip)
-			envs = maps.Merge(rootfsEnvs, envs)
-			envs[v2.ImageRunModeEnvSysKey] = strings.Join(cluster.GetRolesByIP(ip), ",")
+			envs := maps.Merge(rootfsEnvs, hostEnvs)
 			renderCommand := getRenderCommand(pathResolver.RootFSSealctlPath(), target)
 
 			return execer.CmdAsync(ip, stringsutil.RenderShellWithEnv(renderCommand, envs))
@@ -193,7 +221,7 @@ func (f *defaultRootfs) unmountRootfs(cluster *v2.Cluster, ipList []string) erro
 }
 
 func renderTemplatesWithEnv(mountDir string, ipList []string, p env.Interface, envs map[string]string) error {
-	// currently only render once
+	// render the defaults in place with the first host, see copyHostTemplatesFn for the others
 	return p.RenderAll(ipList[0], mountDir, envs)
 }
 
@@ -17,7 +17,9 @@ package guest
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 	"strings"
+	"sync"
 
 	"golang.org/x/sync/errgroup"
 
@@ -27,13 +29,17 @@ import (
 	"github.com/labring/sealos/pkg/exec"
 	"github.com/labring/sealos/pkg/ssh"
 	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+	"github.com/labring/sealos/pkg/utils/logger"
 	"github.com/labring/sealos/pkg/utils/maps"
 	stringsutil "github.com/labring/sealos/pkg/utils/strings"
 )
 
 type Interface interface {
+	// Apply runs the commands of the images on the target hosts, if some of the hosts failed
+	// it returns a *ssh.HostsError with the hosts that have completed all the images.
 	Apply(cluster *v2.Cluster, mounts []v2.MountImage, targetHosts []string) error
-	Delete(cluster *v2.Cluster) error
+	// Delete runs the uninstall commands of the application images on the first master.
+	Delete(cluster *v2.Cluster, mounts []v2.MountImage) error
 }
 
 type Default struct{}
@@ -50,35 +56,82 @@ func (d *Default) Apply(cluster *v2.Cluster, mounts []v2.MountImage, targetHosts
 		return err
 	}
 
+	// the hosts that failed are left out of the following images, the others go on
+	// so that they have completed all the images when Apply returns
+	hosts := targetHosts
+	var failed error
 	for i, m := range mounts {
 		switch {
 		case m.IsRootFs(), m.IsPatch():
-			eg, ctx := errgroup.WithContext(context.Background())
-			for j := range targetHosts {
-				node := targetHosts[j]
-				envs := maps.Merge(m.Env, envGetter.Getenv(node))
-				cmds := formalizeImageCommands(cluster, i, m, envs)
+			var (
+				mu        sync.Mutex
+				succeeded []string
+			)
+			// a failed host doesn't cancel the others
+			var eg errgroup.Group
+			for j := range hosts {
+				node := hosts[j]
+				cmd := renderImageCommand(cluster, envGetter, i, m, node)
 				eg.Go(func() error {
-					return execer.CmdAsyncWithContext(ctx, node,
-						stringsutil.RenderShellWithEnv(strings.Join(cmds, "; "), envs),
-					)
+					if err := execer.CmdAsyncWithContext(context.Background(), node, cmd); err != nil {
+						return err
+					}
+					mu.Lock()
+					succeeded = append(succeeded, node)
+					mu.Unlock()
+					return nil
 				})
 			}
 			if err := eg.Wait(); err != nil {
-				return err
+				failed = err
+				hosts = succeeded
 			}
 		case m.IsApplication():
+			if failed != nil {
+				// the applications run after the images of all hosts, the hosts the images
+				// succeeded on are still reported to be resumed from
+				return ssh.NewHostsError(hosts, failed)
+			}
 			// on run on the first master
-			envs := maps.Merge(m.Env, envGetter.Getenv(cluster.GetMaster0IP()))
-			cmds := formalizeImageCommands(cluster, i, m, envs)
 			if err := execer.CmdAsync(cluster.GetMaster0IPAndPort(),
-				stringsutil.RenderShellWithEnv(strings.Join(cmds, "; "), envs),
+				renderImageCommand(cluster, envGetter, i, m, cluster.GetMaster0IP()),
 			); err != nil {
 				return err
 			}
 		}
 	}
-	return nil
+	return ssh.NewHostsError(hosts, failed)
+}
+
+// HostCommand is the command of a mount image that would be executed on a host.
+type HostCommand struct {
+	Image   string `json:"image"`
+	Host    string `json:"host"`
+	Command string `json:"command"`
+}
+
+// Commands returns the commands that Apply would execute, in order, without connecting to any host.
+func Commands(cluster *v2.Cluster, mounts []v2.MountImage, targetHosts []string) []HostCommand {
+	envGetter := env.NewEnvProcessor(cluster)
+	ret := make([]HostCommand, 0)
+	for i, m := range mounts {
+		switch {
+		case m.IsRootFs(), m.IsPatch():
+			for _, node := range targetHosts {
+				ret = append(ret, HostCommand{Image: m.ImageName, Host: node, Command: renderImageCommand(cluster, envGetter, i, m, node)})
+			}
+		case m.IsApplication():
+			ret = append(ret, HostCommand{Image: m.ImageName, Host: cluster.GetMaster0IPAndPort(),
+				Command: renderImageCommand(cluster, envGetter, i, m, cluster.GetMaster0IP())})
+		}
+	}
+	return ret
+}
+
+func renderImageCommand(cluster *v2.Cluster, envGetter env.Interface, index int, m v2.MountImage, host string) string {
+	envs := maps.Merge(m.Env, envGetter.Getenv(host))
+	cmds := formalizeImageCommands(cluster, index, m, envs)
+	return stringsutil.RenderShellWithEnv(strings.Join(cmds, "; "), envs)
 }
 
 func formalizeWorkingCommand(clusterName string, imageName string, t v2.ImageType, cmd string) string {
@@ -116,6 +169,52 @@ func formalizeImageCommands(cluster *v2.Cluster, index int, m v2.MountImage, ext
 	return cmds
 }
 
-func (d Default) Delete(_ *v2.Cluster) error {
-	panic("not yet implemented")
+func (d *Default) Delete(cluster *v2.Cluster, mounts []v2.MountImage) error {
+	envGetter := env.NewEnvProcessor(cluster)
+	sshClient := ssh.NewCacheClientFromCluster(cluster, true)
+	execer, err := exec.New(sshClient)
+	if err != nil {
+		return err
+	}
+	master0 := cluster.GetMaster0IPAndPort()
+	for _, m := range mounts {
+		if !m.IsApplication() {
+			return fmt.Errorf("image %s is a %s image, only application images can be uninstalled", m.ImageName, m.Type)
+		}
+		appDir, err := applicationDir(cluster.Name, m.Name)
+		if err != nil {
+			return fmt.Errorf("failed to uninstall %s: %v", m.ImageName, err)
+		}
+		if cmd := renderUninstallCommand(cluster, envGetter, m, cluster.GetMaster0IP()); cmd != "" {
+			if err = execer.CmdAsync(master0, cmd); err != nil {
+				return fmt.Errorf("failed to uninstall %s: %v", m.ImageName, err)
+			}
+		} else {
+			logger.Warn("uninstall command of image %s is not found, resources created by it are left in the cluster", m.ImageName)
+		}
+		if err = execer.CmdAsync(master0, fmt.Sprintf("rm -rf %s", appDir)); err != nil {
+			return fmt.Errorf("failed to remove %s: %v", appDir, err)
+		}
+	}
+	return nil
+}
+
+// applicationDir returns the directory of the application, the parent of its working directory
+// <data>/<cluster>/applications/<name>/workdir. The name must be a single path element since
+// the directory is removed with rm -rf.
+func applicationDir(clusterName, name string) (string, error) {
+	if name == "" || name == "." || name == ".." || strings.Contains(name, "/") {
+		return "", fmt.Errorf("invalid application name %q", name)
+	}
+	return filepath.Dir(constants.GetAppWorkDir(clusterName, name)), nil
+}
+
+func renderUninstallCommand(cluster *v2.Cluster, envGetter env.Interface, m v2.MountImage, host string) string {
+	cmd := m.UninstallCommand()
+	if cmd == "" {
+		return ""
+	}
+	envs := v2.MergeEnvWithBuiltinKeys(maps.Merge(m.Env, envGetter.Getenv(host)), m)
+	cmd = formalizeWorkingCommand(cluster.Name, m.Name, m.Type, expansion.Expand(cmd, expansion.MappingFuncFor(envs)))
+	return stringsutil.RenderShellWithEnv(cmd, envs)
 }
@@ -18,10 +18,12 @@ package guest
 
 import (
 	"fmt"
+	"path/filepath"
 	"reflect"
 	"testing"
 
 	"github.com/labring/sealos/pkg/constants"
+	"github.com/labring/sealos/pkg/env"
 
 	v2 "github.com/labring/sealos/pkg/types/v1beta1"
 )
@@ -355,3 +357,72 @@ func TestDefault_getGuestCmd_dollarVariable(t *testing.T) {
 		})
 	}
 }
+
+func TestRenderUninstallCommand(t *testing.T) {
+	cluster := &v2.Cluster{}
+	cluster.Name = "default"
+	tests := []struct {
+		name  string
+		mount v2.MountImage
+		want  string
+	}{
+		{
+			name:  "no uninstall label",
+			mount: v2.MountImage{Name: "app", Cmd: []string{"helm install app charts/app"}},
+			want:  "",
+		},
+		{
+			name: "uninstall label",
+			mount: v2.MountImage{
+				Name:   "app",
+				Labels: map[string]string{"sealos.io.uninstall": "helm uninstall app -n $(NAMESPACE)"},
+				Env:    map[string]string{"NAMESPACE": "app-system"},
+			},
+			want: fmt.Sprintf("export NAMESPACE=\"app-system\" ; %s",
+				fmt.Sprintf(constants.CdAndExecCmd, constants.GetAppWorkDir("default", "app"), "helm uninstall app -n app-system")),
+		},
+		{
+			name: "v2 uninstall label",
+			mount: v2.MountImage{
+				Name:   "app",
+				Labels: map[string]string{v2.GroupName + "/uninstall": "kubectl delete -f manifests"},
+			},
+			want: fmt.Sprintf(constants.CdAndExecCmd, constants.GetAppWorkDir("default", "app"), "kubectl delete -f manifests"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderUninstallCommand(cluster, env.NewEnvProcessor(cluster), tt.mount, "192.168.0.2"); got != tt.want {
+				t.Errorf("renderUninstallCommand() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplicationDir(t *testing.T) {
+	tests := []struct {
+		name    string
+		app     string
+		want    string
+		wantErr bool
+	}{
+		{name: "application", app: "nginx", want: filepath.Join(constants.DataPath(), "default", "applications", "nginx")},
+		{name: "empty", app: "", wantErr: true},
+		{name: "current dir", app: ".", wantErr: true},
+		{name: "parent dir", app: "..", wantErr: true},
+		{name: "path", app: "../../rootfs", wantErr: true},
+		{name: "absolute path", app: "/etc", wantErr: true},
+		{name: "trailing separator", app: "nginx/", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := applicationDir("default", tt.app)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("applicationDir() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("applicationDir() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
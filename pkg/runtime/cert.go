@@ -0,0 +1,59 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/labring/sealos/pkg/cert"
+	"github.com/labring/sealos/pkg/exec"
+)
+
+// CheckCertExpiration reads the certificate files on every host, hosts are checked in parallel
+// and a certificate that cannot be read is reported with the error instead of failing the others.
+func CheckCertExpiration(execer exec.Interface, hosts []string, files []cert.File) []cert.Expiration {
+	results := make([][]cert.Expiration, len(hosts))
+	var wg sync.WaitGroup
+	for i := range hosts {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for _, f := range files {
+				cmd := fmt.Sprintf("cat %s", f.Path)
+				if f.Optional {
+					cmd = fmt.Sprintf("if [ -f %s ]; then cat %s; fi", f.Path, f.Path)
+				}
+				out, err := execer.Cmd(hosts[i], cmd)
+				if err == nil && f.Optional && len(out) == 0 {
+					continue
+				}
+				if err != nil {
+					results[i] = append(results[i], cert.Expiration{
+						Host: hosts[i], Name: f.Name, CA: f.CA, Error: fmt.Sprintf("failed to read %s: %v", f.Path, err),
+					})
+					continue
+				}
+				results[i] = append(results[i], cert.NewExpiration(hosts[i], f, out))
+			}
+		}(i)
+	}
+	wg.Wait()
+	var list []cert.Expiration
+	for i := range results {
+		list = append(list, results[i]...)
+	}
+	return list
+}
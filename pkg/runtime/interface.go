@@ -14,6 +14,22 @@
 
 package runtime
 
+import (
+	"github.com/labring/sealos/pkg/cert"
+	"github.com/labring/sealos/pkg/client-go/kubernetes"
+)
+
+// DrainOptions is used by ScaleDown to drain nodes before they are removed from the cluster.
+type DrainOptions struct {
+	kubernetes.DrainOptions
+	// IgnoreErrors removes the nodes even if they could not be drained.
+	IgnoreErrors bool
+}
+
+func NewDefaultDrainOptions() *DrainOptions {
+	return &DrainOptions{DrainOptions: *kubernetes.NewDefaultDrainOptions()}
+}
+
 type Interface interface {
 	Ruler
 	Init() error
@@ -24,13 +40,29 @@ type Interface interface {
 	GetRawConfig() ([]byte, error)
 }
 
+// Drainer is implemented by runtimes that drain nodes in ScaleDown.
+type Drainer interface {
+	SetDrainOptions(opts *DrainOptions)
+}
+
 type Ruler interface {
 	SyncNodeIPVS(masters, nodes []string) error
 }
 
 type CertManager interface {
+	// Renew renews the leaf certificates of all masters and restarts the control plane one master at a time.
 	Renew() error
 	UpdateCertSANs(certSANs []string) error
+	// CheckExpiration returns the expiration of every certificate on every master.
+	CheckExpiration() ([]cert.Expiration, error)
+}
+
+// EtcdManager snapshots the datastore of the control plane and restores the cluster from a snapshot.
+type EtcdManager interface {
+	// SnapshotEtcd takes a snapshot on master0 and fetches it to the local dst.
+	SnapshotEtcd(dst string) error
+	// RestoreEtcd rebuilds the datastore of all masters from the local snapshot file.
+	RestoreEtcd(src string) error
 }
 
 type Config interface {
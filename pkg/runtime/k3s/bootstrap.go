@@ -27,6 +27,7 @@ import (
 	"github.com/labring/sealos/pkg/utils/iputils"
 
 	"github.com/labring/sealos/pkg/constants"
+	"github.com/labring/sealos/pkg/ssh"
 	"github.com/labring/sealos/pkg/utils/file"
 	"github.com/labring/sealos/pkg/utils/logger"
 	"github.com/labring/sealos/pkg/utils/rand"
@@ -53,9 +54,9 @@ func (k *K3s) joinMasters(masters []string) error {
 	if err != nil {
 		return err
 	}
-	for _, master := range masters {
+	for i, master := range masters {
 		if err = k.joinMaster(master); err != nil {
-			return err
+			return ssh.NewHostsError(masters[:i], err)
 		}
 	}
 	return nil
@@ -116,7 +117,7 @@ func (k *K3s) joinNodes(nodes []string) error {
 	}
 	for i := range nodes {
 		if err := k.joinNode(nodes[i]); err != nil {
-			return err
+			return ssh.NewHostsError(nodes[:i], err)
 		}
 	}
 	return nil
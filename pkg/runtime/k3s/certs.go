@@ -0,0 +1,168 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k3s
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/labring/sealos/pkg/cert"
+	"github.com/labring/sealos/pkg/runtime"
+	fileutil "github.com/labring/sealos/pkg/utils/file"
+	"github.com/labring/sealos/pkg/utils/logger"
+	stringsutil "github.com/labring/sealos/pkg/utils/strings"
+	"github.com/labring/sealos/pkg/utils/yaml"
+)
+
+const rotateCertsCmd = "k3s certificate rotate"
+
+var (
+	defaultTLSDir = filepath.Join(defaultDataDir, "server", "tls")
+	caCertNames   = []string{"client-ca", "server-ca", "request-header-ca", "etcd/server-ca", "etcd/peer-ca"}
+	leafCertNames = []string{
+		"serving-kube-apiserver", "client-admin", "client-controller", "client-scheduler", "client-kube-apiserver",
+		"client-kube-proxy", "client-k3s-controller", "client-auth-proxy", "etcd/client", "etcd/server-client", "etcd/peer-server-client",
+	}
+)
+
+func certFiles() []cert.File {
+	var files []cert.File
+	for _, list := range []struct {
+		names []string
+		ca    bool
+	}{{caCertNames, true}, {leafCertNames, false}} {
+		for _, name := range list.names {
+			files = append(files, cert.File{
+				Name: name,
+				Path: filepath.Join(defaultTLSDir, name+".crt"),
+				CA:   list.ca,
+				// etcd certificates only exist with the embedded etcd datastore
+				Optional: filepath.Dir(name) == "etcd",
+			})
+		}
+	}
+	return files
+}
+
+func (k *K3s) CheckExpiration() ([]cert.Expiration, error) {
+	return runtime.CheckCertExpiration(k.execer, k.cluster.GetMasterIPAndPortList(), certFiles()), nil
+}
+
+// Renew rotates the leaf certificates of servers one at a time, k3s must be stopped while rotating
+// and the server must be ready again before moving on to the next one.
+func (k *K3s) Renew() error {
+	for _, master := range k.cluster.GetMasterIPAndPortList() {
+		err := k.runPipelines(fmt.Sprintf("renew certs on server %s", master),
+			func() error { return k.remoteUtil.InitSystem(master).ServiceStop(Distribution) },
+			func() error { return k.execer.CmdAsync(master, rotateCertsCmd) },
+			func() error { return k.remoteUtil.InitSystem(master).ServiceStart(Distribution) },
+			func() error { return k.waitServerReady(master) },
+		)
+		if err != nil {
+			return err
+		}
+		logger.Info("succeeded in renewing certs on server %s", master)
+	}
+	return k.syncKubeConfig()
+}
+
+// UpdateCertSANs appends the SANs to tls-san of the k3s config on every server and restarts them one by one,
+// k3s regenerates the serving certificate of api-server once the SANs are changed.
+func (k *K3s) UpdateCertSANs(certSANs []string) error {
+	if k.config != nil {
+		k.config.TLSSan = stringsutil.RemoveDuplicate(append(k.config.TLSSan, certSANs...))
+	}
+	if err := k.updateLocalInitConfig(certSANs); err != nil {
+		return err
+	}
+	for _, master := range k.cluster.GetMasterIPAndPortList() {
+		err := k.runPipelines(fmt.Sprintf("update cert SANs on server %s", master),
+			func() error { return k.updateServerCertSANs(master, certSANs) },
+			func() error { return k.remoteUtil.InitSystem(master).ServiceRestart(Distribution) },
+			func() error { return k.waitServerReady(master) },
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return k.syncKubeConfig()
+}
+
+// updateLocalInitConfig keeps the SANs in the init config so that the servers joined later have them too.
+func (k *K3s) updateLocalInitConfig(certSANs []string) error {
+	fp := filepath.Join(k.pathResolver.EtcPath(), defaultInitFilename)
+	if !fileutil.IsExist(fp) {
+		return nil
+	}
+	return updateConfigFileCertSANs(fp, certSANs)
+}
+
+func (k *K3s) updateServerCertSANs(master string, certSANs []string) error {
+	tmpDir, err := fileutil.MkTmpdir("")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+	fp := filepath.Join(tmpDir, filepath.Base(defaultK3sConfigPath))
+	if err = k.execer.Fetch(master, defaultK3sConfigPath, fp); err != nil {
+		return err
+	}
+	if err = updateConfigFileCertSANs(fp, certSANs); err != nil {
+		return err
+	}
+	return k.execer.Copy(master, fp, defaultK3sConfigPath)
+}
+
+func updateConfigFileCertSANs(fp string, certSANs []string) error {
+	data, err := fileutil.ReadAll(fp)
+	if err != nil {
+		return err
+	}
+	cfg, err := ParseConfig(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse k3s config %s: %v", fp, err)
+	}
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	cfg.TLSSan = stringsutil.RemoveDuplicate(append(cfg.TLSSan, certSANs...))
+	return yaml.MarshalFile(fp, cfg)
+}
+
+// waitServerReady waits for both the api-server and the node of the server to be ready.
+func (k *K3s) waitServerReady(master string) error {
+	if err := k.waitAPIServerReady(); err != nil {
+		return err
+	}
+	nodeName, err := k.getNodeName(master)
+	if err != nil {
+		return err
+	}
+	return k.waitNodeReady(nodeName, "")
+}
+
+func (k *K3s) syncKubeConfig() error {
+	k.cli = nil
+	return k.runPipelines("sync kubeconfig",
+		// fetching refuses to overwrite an existing file
+		func() error { return os.RemoveAll(k.pathResolver.AdminFile()) },
+		k.pullKubeConfigFromMaster0,
+		// the nodes got a copy of the kubeconfig when they joined as well
+		func() error {
+			return k.copyKubeConfigFileToNodes(append(k.cluster.GetMasterIPAndPortList(), k.cluster.GetNodeIPAndPortList()...)...)
+		},
+	)
+}
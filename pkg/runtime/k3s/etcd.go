@@ -0,0 +1,108 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k3s
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/labring/sealos/pkg/utils/logger"
+)
+
+const (
+	etcdSnapshotDirName  = "etcd-snapshots"
+	etcdSnapshotName     = "sealos"
+	etcdSnapshotFileName = "etcd-snapshot.db"
+
+	embeddedEtcdCmd     = "test -d %s || { echo 'k3s is not running with the embedded etcd datastore' >&2; exit 1; }"
+	etcdSnapshotSaveCmd = "rm -rf %[1]s && mkdir -p %[1]s && k3s etcd-snapshot save --name %[2]s --dir %[1]s"
+	latestSnapshotCmd   = "ls -t %s | head -n 1"
+	clusterResetCmd     = "k3s server --cluster-reset --cluster-reset-restore-path=%s"
+	moveDBDirCmd        = "if [ -d %[1]s ]; then mv %[1]s %[1]s.%[2]s; fi"
+)
+
+var defaultDBDir = filepath.Join(defaultDataDir, "server", "db")
+
+func (k *K3s) SnapshotEtcd(dst string) error {
+	master0 := k.cluster.GetMaster0IPAndPort()
+	dir := filepath.Join(k.pathResolver.ConfigsPath(), etcdSnapshotDirName)
+	var snapshot string
+	return k.runPipelines("snapshot embedded etcd",
+		func() error {
+			return k.execer.CmdAsync(master0, fmt.Sprintf(embeddedEtcdCmd, filepath.Join(defaultDBDir, "etcd")),
+				fmt.Sprintf(etcdSnapshotSaveCmd, dir, etcdSnapshotName))
+		},
+		func() error {
+			// k3s appends the node name and timestamp to the snapshot name
+			name, err := k.execer.CmdToString(master0, fmt.Sprintf(latestSnapshotCmd, dir), "")
+			if err != nil {
+				return err
+			}
+			if name = strings.TrimSpace(name); name == "" {
+				return fmt.Errorf("cannot find the snapshot in %s", dir)
+			}
+			snapshot = filepath.Join(dir, name)
+			return nil
+		},
+		func() error { return k.execer.Fetch(master0, snapshot, dst) },
+		func() error { return k.execer.CmdAsync(master0, fmt.Sprintf("rm -rf %s", dir)) },
+	)
+}
+
+// RestoreEtcd stops k3s on all servers and resets the cluster on master0 from the snapshot, the other
+// servers rejoin the restored cluster with an empty datastore, the old one is kept with a timestamp suffix.
+func (k *K3s) RestoreEtcd(src string) error {
+	master0 := k.cluster.GetMaster0IPAndPort()
+	snapshot := filepath.Join(k.pathResolver.ConfigsPath(), etcdSnapshotFileName)
+	suffix := time.Now().Format("20060102150405")
+	if err := k.execer.CmdAsync(master0, fmt.Sprintf(embeddedEtcdCmd, filepath.Join(defaultDBDir, "etcd"))); err != nil {
+		return err
+	}
+	if err := k.execer.Copy(master0, src, snapshot); err != nil {
+		return err
+	}
+	for _, master := range k.cluster.GetMasterIPAndPortList() {
+		logger.Info("stop k3s on %s", master)
+		if err := k.remoteUtil.InitSystem(master).ServiceStop(Distribution); err != nil {
+			return err
+		}
+	}
+	err := k.runPipelines(fmt.Sprintf("restore embedded etcd on %s", master0),
+		func() error {
+			return k.execer.CmdAsync(master0, fmt.Sprintf(clusterResetCmd, snapshot), fmt.Sprintf("rm -f %s", snapshot))
+		},
+		func() error { return k.remoteUtil.InitSystem(master0).ServiceStart(Distribution) },
+		func() error { return k.waitServerReady(master0) },
+	)
+	if err != nil {
+		return err
+	}
+	for _, master := range k.cluster.GetMasterIPAndPortList() {
+		if master == master0 {
+			continue
+		}
+		err = k.runPipelines(fmt.Sprintf("rejoin server %s", master),
+			func() error { return k.execer.CmdAsync(master, fmt.Sprintf(moveDBDirCmd, defaultDBDir, suffix)) },
+			func() error { return k.remoteUtil.InitSystem(master).ServiceStart(Distribution) },
+			func() error { return k.waitServerReady(master) },
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
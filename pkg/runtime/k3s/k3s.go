@@ -16,16 +16,20 @@ package k3s
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
+	"github.com/Masterminds/semver/v3"
 	"golang.org/x/sync/errgroup"
 
 	"github.com/labring/sealos/pkg/utils/iputils"
 	"github.com/labring/sealos/pkg/utils/strings"
 
+	"github.com/labring/sealos/pkg/client-go/kubernetes"
 	"github.com/labring/sealos/pkg/constants"
 	"github.com/labring/sealos/pkg/env"
 	"github.com/labring/sealos/pkg/exec"
+	"github.com/labring/sealos/pkg/runtime"
 	"github.com/labring/sealos/pkg/ssh"
 	v2 "github.com/labring/sealos/pkg/types/v1beta1"
 	"github.com/labring/sealos/pkg/utils/logger"
@@ -40,6 +44,8 @@ type K3s struct {
 	pathResolver constants.PathResolver
 	remoteUtil   *ssh.Remote
 	execer       exec.Interface
+	cli          kubernetes.Client
+	drainOptions *runtime.DrainOptions
 }
 
 func New(cluster *v2.Cluster, config any) (*K3s, error) {
@@ -54,6 +60,7 @@ func New(cluster *v2.Cluster, config any) (*K3s, error) {
 		execer:       execer,
 		envInterface: env.NewEnvProcessor(cluster),
 		remoteUtil:   ssh.NewRemoteFromSSH(cluster.GetName(), execer),
+		drainOptions: runtime.NewDefaultDrainOptions(),
 	}
 	if v, ok := config.(*Config); ok {
 		k.config = v
@@ -85,7 +92,12 @@ func (k *K3s) ScaleUp(masters []string, nodes []string) error {
 	if len(nodes) != 0 {
 		logger.Info("%s will be added as worker", nodes)
 		if err := k.joinNodes(nodes); err != nil {
-			return err
+			joined := append([]string{}, masters...)
+			var hostsErr *ssh.HostsError
+			if errors.As(err, &hostsErr) {
+				joined = append(joined, hostsErr.Succeeded...)
+			}
+			return ssh.NewHostsError(joined, err)
 		}
 	}
 	return nil
@@ -106,8 +118,28 @@ func (k *K3s) ScaleDown(masters []string, nodes []string) error {
 }
 
 func (k *K3s) Upgrade(version string) error {
+	currVersion := k.getKubeVersionFromImage()
+
+	v0, err := semver.NewVersion(currVersion)
+	if err != nil {
+		return err
+	}
+	v1, err := semver.NewVersion(version)
+	if err != nil {
+		return err
+	}
+	if v0.Equal(v1) {
+		logger.Info("skip upgrade because of same version")
+		return nil
+	}
+	if v0.GreaterThan(v1) {
+		return fmt.Errorf("cannot apply an older version %s than %s", version, currVersion)
+	}
+	if v0.Minor()+1 < v1.Minor() {
+		return fmt.Errorf("cannot be upgraded across more than one major releases, %s -> %s", currVersion, version)
+	}
 	logger.Info("trying to upgrade to version %s", version)
-	return nil
+	return k.upgradeCluster(version)
 }
 
 func (k *K3s) GetRawConfig() ([]byte, error) {
@@ -144,6 +176,19 @@ func (k *K3s) SyncNodeIPVS(mastersIPList, nodeIPList []string) error {
 	return eg.Wait()
 }
 
+func (k *K3s) getKubeInterface() (kubernetes.Client, error) {
+	if k.cli != nil {
+		return k.cli, nil
+	}
+	apiServer := fmt.Sprintf("https://%s:%d", iputils.GetHostIP(k.cluster.GetMaster0IP()), k.getAPIServerPort())
+	cli, err := kubernetes.NewKubernetesClient(k.pathResolver.AdminFile(), apiServer)
+	if err != nil {
+		return nil, err
+	}
+	k.cli = cli
+	return cli, nil
+}
+
 func (k *K3s) runPipelines(phase string, pipelines ...func() error) error {
 	logger.Info("starting %s", phase)
 	for i := range pipelines {
@@ -18,8 +18,8 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/labring/sealos/pkg/utils/iputils"
-
+	"github.com/labring/sealos/pkg/client-go/kubernetes"
+	"github.com/labring/sealos/pkg/runtime"
 	"github.com/labring/sealos/pkg/utils/strings"
 
 	"golang.org/x/exp/slices"
@@ -79,7 +79,9 @@ func (k *K3s) deleteNode(node string) error {
 		masterIPs = strings.RemoveFromSlice(k.cluster.GetMasterIPList(), node)
 	}
 	if len(masterIPs) > 0 {
-		// TODO: do we need draining first?
+		if err := k.drainNode(node); err != nil {
+			return err
+		}
 		if err := k.removeNode(node); err != nil {
 			logger.Warn(fmt.Errorf("delete nodes %s failed %v", node, err))
 		}
@@ -87,11 +89,43 @@ func (k *K3s) deleteNode(node string) error {
 	return nil
 }
 
+// drainNode evicts all pods from the node before it is removed, drain failures are
+// ignored if the drain options say so.
+func (k *K3s) drainNode(ip string) error {
+	client, err := k.getKubeInterface()
+	if err != nil {
+		return k.drainFailed(ip, err)
+	}
+	nodeName, err := k.getNodeName(ip)
+	if err != nil {
+		logger.Warn("skip draining node %s: %v", ip, err)
+		return nil
+	}
+	err = kubernetes.NewKubeDrainer(client.Kubernetes(), k.drainOptions.DrainOptions).Drain(context.Background(), nodeName)
+	if err != nil {
+		return k.drainFailed(ip, err)
+	}
+	return nil
+}
+
+// SetDrainOptions changes how nodes are drained in ScaleDown.
+func (k *K3s) SetDrainOptions(opts *runtime.DrainOptions) {
+	k.drainOptions = opts
+}
+
+func (k *K3s) drainFailed(ip string, err error) error {
+	if !k.drainOptions.IgnoreErrors {
+		return err
+	}
+	logger.Warn("failed to drain node %s: %v, continue to delete it as drain errors are ignored", ip, err)
+	return nil
+}
+
 func (k *K3s) removeNode(ip string) error {
 	logger.Info("start to remove node from k3s %s", ip)
-	nodeName, err := k.execer.CmdToString(k.cluster.GetMaster0IPAndPort(), fmt.Sprintf("kubectl get nodes -o wide | awk '$6==\"%s\" {print $1}'", iputils.GetHostIP(ip)), "")
+	nodeName, err := k.getNodeName(ip)
 	if err != nil {
-		return fmt.Errorf("cannot get node with ip address %s: %v", ip, err)
+		return err
 	}
 	logger.Debug("found node name is %s, we will delete it", nodeName)
 	return k.execer.CmdAsync(k.cluster.GetMaster0IPAndPort(), fmt.Sprintf("kubectl delete node %s --ignore-not-found=true", nodeName))
@@ -0,0 +1,188 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k3s
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+
+	"github.com/labring/sealos/pkg/utils/iputils"
+	"github.com/labring/sealos/pkg/utils/logger"
+)
+
+const (
+	installK3sCmd     = "cp -rf %s /usr/bin/k3s"
+	getNodeNameCmd    = "kubectl get nodes -o wide | awk '$6==\"%s\" {print $1}'"
+	cordonNodeCmd     = "kubectl cordon %s"
+	drainNodeCmd      = "kubectl drain %s --ignore-daemonsets --delete-emptydir-data --timeout=%s"
+	uncordonNodeCmd   = "kubectl uncordon %s"
+	apiServerReadyCmd = "kubectl get --raw=/readyz"
+	nodeStatusCmd     = "kubectl get node %s -o jsonpath='{.status.nodeInfo.kubeletVersion} {.status.conditions[?(@.type==\"Ready\")].status}'"
+
+	defaultDrainTimeout   = 5 * time.Minute
+	defaultHealthTimeout  = 3 * time.Minute
+	defaultHealthInterval = 5 * time.Second
+)
+
+func (k *K3s) getKubeVersionFromImage() string {
+	img := k.cluster.GetRootfsImage()
+	if img == nil {
+		return ""
+	}
+	return img.KubeVersion()
+}
+
+// upgradeCluster upgrades master0 first, then the rest servers, and agents at last,
+// one host at a time so that the cluster always keeps a serving control plane.
+func (k *K3s) upgradeCluster(version string) error {
+	master0 := k.cluster.GetMaster0IPAndPort()
+	logger.Info("start to upgrade master0")
+	if err := k.upgradeNode(master0, version); err != nil {
+		return err
+	}
+	logger.Info("start to upgrade other servers")
+	for _, master := range k.cluster.GetMasterIPAndPortList() {
+		if master == master0 {
+			continue
+		}
+		if err := k.upgradeNode(master, version); err != nil {
+			return err
+		}
+	}
+	logger.Info("start to upgrade agents")
+	for _, node := range k.cluster.GetNodeIPAndPortList() {
+		if err := k.upgradeNode(node, version); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (k *K3s) upgradeNode(host string, version string) error {
+	// assure the cluster is healthy before touching the next host
+	if err := k.waitAPIServerReady(); err != nil {
+		return err
+	}
+	nodeName, err := k.getNodeName(host)
+	if err != nil {
+		return err
+	}
+	logger.Info("upgrade node %s(%s) to %s", nodeName, host, version)
+	return k.runPipelines(fmt.Sprintf("upgrade node %s", nodeName),
+		func() error { return k.cordonAndDrainNode(nodeName) },
+		func() error { return k.installK3sBinary(host) },
+		func() error { return k.remoteUtil.InitSystem(host).ServiceRestart("k3s") },
+		func() error { return k.waitNodeReady(nodeName, version) },
+		func() error { return k.uncordonNode(nodeName) },
+	)
+}
+
+func (k *K3s) getNodeName(host string) (string, error) {
+	nodeName, err := k.execer.CmdToString(k.cluster.GetMaster0IPAndPort(), fmt.Sprintf(getNodeNameCmd, iputils.GetHostIP(host)), "")
+	if err != nil {
+		return "", fmt.Errorf("cannot get node with ip address %s: %v", host, err)
+	}
+	nodeName = strings.TrimSpace(nodeName)
+	if nodeName == "" {
+		return "", fmt.Errorf("cannot find node with ip address %s", host)
+	}
+	return nodeName, nil
+}
+
+func (k *K3s) cordonAndDrainNode(nodeName string) error {
+	master0 := k.cluster.GetMaster0IPAndPort()
+	if err := k.execer.CmdAsync(master0, fmt.Sprintf(cordonNodeCmd, nodeName)); err != nil {
+		return err
+	}
+	// a drain failure should not block the upgrade, pods that cannot be evicted
+	// will be restarted along with k3s anyway.
+	if err := k.execer.CmdAsync(master0, fmt.Sprintf(drainNodeCmd, nodeName, defaultDrainTimeout)); err != nil {
+		logger.Warn("failed to drain node %s: %v", nodeName, err)
+	}
+	return nil
+}
+
+func (k *K3s) installK3sBinary(host string) error {
+	return k.execer.CmdAsync(host, fmt.Sprintf(installK3sCmd, filepath.Join(k.pathResolver.RootFSBinPath(), Distribution)))
+}
+
+func (k *K3s) uncordonNode(nodeName string) error {
+	return k.waitFor(fmt.Sprintf("uncordon node %s", nodeName), func() error {
+		return k.execer.CmdAsync(k.cluster.GetMaster0IPAndPort(), fmt.Sprintf(uncordonNodeCmd, nodeName))
+	})
+}
+
+func (k *K3s) waitAPIServerReady() error {
+	return k.waitFor("api-server ready", func() error {
+		_, err := k.execer.CmdToString(k.cluster.GetMaster0IPAndPort(), apiServerReadyCmd, "")
+		return err
+	})
+}
+
+// waitNodeReady waits for the node to be reported as Ready by the api-server with the expected kubelet version,
+// the version is not checked if it's empty.
+func (k *K3s) waitNodeReady(nodeName, version string) error {
+	var expected *semver.Version
+	if version != "" {
+		v, err := semver.NewVersion(version)
+		if err != nil {
+			return err
+		}
+		expected = v
+	}
+	return k.waitFor(fmt.Sprintf("node %s ready", nodeName), func() error {
+		out, err := k.execer.CmdToString(k.cluster.GetMaster0IPAndPort(), fmt.Sprintf(nodeStatusCmd, nodeName), "")
+		if err != nil {
+			return err
+		}
+		fields := strings.Fields(out)
+		if len(fields) != 2 {
+			return fmt.Errorf("unexpected node status %q", out)
+		}
+		if fields[1] != "True" {
+			return fmt.Errorf("node %s is not ready yet", nodeName)
+		}
+		if expected == nil {
+			return nil
+		}
+		current, err := semver.NewVersion(fields[0])
+		if err != nil {
+			return err
+		}
+		if !current.Equal(expected) {
+			return fmt.Errorf("node %s is still running %s", nodeName, current)
+		}
+		return nil
+	})
+}
+
+func (k *K3s) waitFor(desc string, fn func() error) error {
+	timeout := time.Now().Add(defaultHealthTimeout)
+	for {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(timeout) {
+			return fmt.Errorf("wait for %s timeout within %s: %v", desc, defaultHealthTimeout, err)
+		}
+		logger.Debug("waiting for %s: %v", desc, err)
+		time.Sleep(defaultHealthInterval)
+	}
+}
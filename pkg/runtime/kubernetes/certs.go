@@ -16,16 +16,21 @@ package kubernetes
 
 import (
 	"context"
-	"errors"
 	"fmt"
+	"os"
 	"path"
+	"strings"
+	"time"
 
-	"golang.org/x/sync/errgroup"
+	"github.com/Masterminds/semver/v3"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/util/json"
 
+	"github.com/labring/sealos/pkg/cert"
 	"github.com/labring/sealos/pkg/client-go/kubernetes"
+	"github.com/labring/sealos/pkg/runtime"
 	"github.com/labring/sealos/pkg/utils/file"
+	"github.com/labring/sealos/pkg/utils/iputils"
 	"github.com/labring/sealos/pkg/utils/logger"
 	"github.com/labring/sealos/pkg/utils/yaml"
 )
@@ -37,8 +42,48 @@ const (
 	KubeletConf    = "kubelet.conf"
 )
 
+const (
+	renewCertsLt120   = "kubeadm alpha certs renew all"
+	renewCertsGte120  = "kubeadm certs renew all"
+	etcdComponent     = "etcd"
+	apiServerReadyCmd = "kubectl --kubeconfig /etc/kubernetes/admin.conf --server https://%s:%d get --raw=/readyz"
+
+	defaultHealthTimeout  = 3 * time.Minute
+	defaultHealthInterval = 5 * time.Second
+)
+
+// staticPodComponents are restarted to pick up the new certificates.
+var staticPodComponents = []string{etcdComponent, kubernetes.KubeAPIServer, kubernetes.KubeControllerManager, kubernetes.KubeScheduler}
+
+func (k *KubeadmRuntime) CheckExpiration() ([]cert.Expiration, error) {
+	return runtime.CheckCertExpiration(k.execer, k.getMasterIPAndPortList(), cert.KubeFiles()), nil
+}
+
+// Renew renews the leaf certificates and kubeconfig files signed by the existing CAs on every master,
+// the control plane is restarted one master at a time and must be healthy before moving on to the next one.
 func (k *KubeadmRuntime) Renew() error {
-	return errors.New("not implement")
+	renewCmd := renewCertsGte120
+	if !gte(semver.MustParse(k.getKubeVersion()), V1200) {
+		renewCmd = renewCertsLt120
+	}
+	for _, master := range k.getMasterIPAndPortList() {
+		err := k.runPipelines(fmt.Sprintf("renew certs on master %s", master),
+			func() error { return k.sshCmdAsync(master, fmt.Sprintf("%s%s", renewCmd, vlogToStr(k.klogLevel))) },
+			func() error { return k.restartStaticPods(master) },
+			func() error { return k.waitAPIServerReady(master) },
+			func() error { return k.copyMasterKubeConfig(master) },
+		)
+		if err != nil {
+			return err
+		}
+		logger.Info("succeeded in renewing certs on master %s", master)
+	}
+	// the nodes got a copy of admin.conf when they joined, which is signed by the old certificate
+	return k.runPipelines("sync kubeconfig",
+		k.fetchAdminKubeConfig,
+		func() error { return k.copyKubeConfigFileToNodes(k.getNodeIPAndPortList()...) },
+		k.showKubeadmCert,
+	)
 }
 
 func (k *KubeadmRuntime) UpdateCertSANs(certSans []string) error {
@@ -61,7 +106,7 @@ func (k *KubeadmRuntime) UpdateCertSANs(certSans []string) error {
 		k.saveNewKubeadmConfig,
 		k.uploadConfigFromKubeadm,
 		k.syncCert,
-		k.deleteAPIServer,
+		k.restartAPIServers,
 		k.showKubeadmCert,
 	}
 	for _, f := range pipeline {
@@ -166,39 +211,78 @@ func (k *KubeadmRuntime) showKubeadmCert() error {
 	return k.sshCmdAsync(k.getMaster0IPAndPort(), fmt.Sprintf("%s%s", certCheck, vlogToStr(k.klogLevel)))
 }
 
-func (k *KubeadmRuntime) deleteAPIServer() error {
-	podIDSh := fmt.Sprintf("crictl ps -a --name %s -o json", kubernetes.KubeAPIServer)
+// restartAPIServers restarts the api-server of masters one by one to load the new certificates.
+func (k *KubeadmRuntime) restartAPIServers() error {
+	for _, master := range k.getMasterIPAndPortList() {
+		if err := k.restartStaticPods(master, kubernetes.KubeAPIServer); err != nil {
+			return err
+		}
+		if err := k.waitAPIServerReady(master); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restartStaticPods removes the pod sandboxes of the static pods, kubelet will recreate them
+// from the manifests immediately. All control plane components are restarted if none is given.
+func (k *KubeadmRuntime) restartStaticPods(master string, components ...string) error {
 	type crictlPS struct {
 		Containers []struct {
 			ID           string `json:"id"`
 			PodSandboxID string `json:"podSandboxId"`
 		} `json:"containers"`
 	}
-	logger.Info("delete pod apiserver from crictl")
-	eg, _ := errgroup.WithContext(context.Background())
-	for _, master := range k.getMasterIPAndPortList() {
-		m := master
-		eg.Go(func() error {
-			podIDJson, err := k.sshCmdToString(m, podIDSh)
-			if err != nil {
-				return err
-			}
-			ps := &crictlPS{}
-			if err = json.Unmarshal([]byte(podIDJson), ps); err != nil {
-				return err
-			}
-			if len(ps.Containers) > 0 {
-				podID := ps.Containers[0].PodSandboxID[:13]
-				logger.Debug("found podID %s in %s", podID, m)
-				//crictl stopp
-				if err = k.sshCmdAsync(m, fmt.Sprintf("crictl --timeout=10s stopp %s", podID)); err != nil {
-					return err
-				}
-				//crictl rmp
-				return k.sshCmdAsync(m, fmt.Sprintf("crictl rmp %s", podID))
+	if len(components) == 0 {
+		components = staticPodComponents
+	}
+	for _, component := range components {
+		logger.Info("restart static pod %s on %s", component, master)
+		podIDJson, err := k.sshCmdToString(master, fmt.Sprintf("crictl ps --name '^%s$' -o json", component))
+		if err != nil {
+			return err
+		}
+		ps := &crictlPS{}
+		if err = json.Unmarshal([]byte(podIDJson), ps); err != nil {
+			return err
+		}
+		if len(ps.Containers) == 0 {
+			if component == etcdComponent {
+				// etcd might be external
+				logger.Debug("etcd is not running as static pod on %s, skip", master)
+				continue
 			}
-			return errors.New("not found apiServer pod running")
-		})
+			return fmt.Errorf("not found %s pod running on %s", component, master)
+		}
+		podID := ps.Containers[0].PodSandboxID
+		logger.Debug("found podID %s in %s", podID, master)
+		if err = k.sshCmdAsync(master, fmt.Sprintf("crictl --timeout=10s stopp %s", podID), fmt.Sprintf("crictl rmp %s", podID)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (k *KubeadmRuntime) waitAPIServerReady(master string) error {
+	cmd := fmt.Sprintf(apiServerReadyCmd, iputils.GetHostIP(master), k.getAPIServerPort())
+	return k.waitFor(fmt.Sprintf("api-server on %s ready", master), func() error {
+		out, err := k.sshCmdToString(master, cmd)
+		if err == nil && strings.TrimSpace(out) != "ok" {
+			return fmt.Errorf("api-server is not ready: %s", out)
+		}
+		return err
+	})
+}
+
+// fetchAdminKubeConfig pulls the renewed admin.conf from master0, the cached client is reset to use it.
+func (k *KubeadmRuntime) fetchAdminKubeConfig() error {
+	// fetching refuses to overwrite an existing file
+	if err := os.RemoveAll(k.pathResolver.AdminFile()); err != nil {
+		return err
 	}
-	return eg.Wait()
+	if err := k.execer.Fetch(k.getMaster0IPAndPort(), path.Join(kubernetesEtc, AdminConf), k.pathResolver.AdminFile()); err != nil {
+		return err
+	}
+	k.cli = nil
+	return nil
 }
@@ -0,0 +1,155 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/labring/sealos/pkg/cert"
+	"github.com/labring/sealos/pkg/utils/iputils"
+	"github.com/labring/sealos/pkg/utils/logger"
+)
+
+const (
+	etcdSnapshotFileName    = "etcd-snapshot.db"
+	etcdPeerPort            = 2380
+	etcdInitialClusterToken = "sealos-etcd-restore"
+	stashedManifestsDirName = "stashed-manifests"
+
+	// the etcd image ships etcdctl, copy it to the host if it's not installed
+	installEtcdctlCmd = `command -v etcdctl >/dev/null 2>&1 || { id=$(crictl ps --name '^etcd$' -q | head -n 1); ` +
+		`[ -n "$id" ] || { echo "etcdctl is not installed and etcd is not running" >&2; exit 1; }; ` +
+		`crictl exec $id cat /usr/local/bin/etcdctl > /usr/bin/etcdctl && chmod +x /usr/bin/etcdctl; }`
+	etcdSnapshotSaveCmd    = "ETCDCTL_API=3 etcdctl --endpoints=https://127.0.0.1:2379 --cacert=%s --cert=%s --key=%s snapshot save %s"
+	etcdSnapshotRestoreCmd = "ETCDCTL_API=3 etcdctl snapshot restore %s --name %s --initial-cluster %s " +
+		"--initial-cluster-token %s --initial-advertise-peer-urls https://%s:%d --data-dir %s"
+	stashManifestsCmd   = "mkdir -p %[2]s && mv %[1]s/etcd.yaml %[1]s/kube-apiserver.yaml %[2]s/"
+	unstashManifestsCmd = "mv %[2]s/etcd.yaml %[2]s/kube-apiserver.yaml %[1]s/"
+	etcdRunningCmd      = "crictl ps --name '^(etcd|kube-apiserver)$' -q"
+	moveDataDirCmd      = "if [ -d %[1]s ]; then mv %[1]s %[1]s.%[2]s; fi"
+)
+
+func (k *KubeadmRuntime) SnapshotEtcd(dst string) error {
+	if err := k.validateLocalEtcd(); err != nil {
+		return err
+	}
+	master0 := k.getMaster0IPAndPort()
+	snapshot := path.Join(k.pathResolver.ConfigsPath(), etcdSnapshotFileName)
+	saveCmd := fmt.Sprintf(etcdSnapshotSaveCmd,
+		path.Join(cert.KubeDefaultCertEtcdPath, "ca.crt"),
+		path.Join(cert.KubeDefaultCertEtcdPath, "healthcheck-client.crt"),
+		path.Join(cert.KubeDefaultCertEtcdPath, "healthcheck-client.key"),
+		snapshot,
+	)
+	return k.runPipelines("snapshot etcd",
+		func() error { return k.sshCmdAsync(master0, installEtcdctlCmd, saveCmd) },
+		func() error { return k.execer.Fetch(master0, snapshot, dst) },
+		func() error { return k.sshCmdAsync(master0, fmt.Sprintf("rm -f %s", snapshot)) },
+	)
+}
+
+// RestoreEtcd stops etcd and api-server on all masters first, then every member is restored from
+// the snapshot as a new cluster with the same members, the old data dir is kept with a timestamp suffix.
+func (k *KubeadmRuntime) RestoreEtcd(src string) error {
+	if err := k.validateLocalEtcd(); err != nil {
+		return err
+	}
+	masters := k.getMasterIPAndPortList()
+	names := make([]string, len(masters))
+	initialCluster := make([]string, len(masters))
+	for i, master := range masters {
+		name, err := k.execHostname(master)
+		if err != nil {
+			return err
+		}
+		names[i] = name
+		initialCluster[i] = fmt.Sprintf("%s=https://%s:%d", name, iputils.GetHostIP(master), etcdPeerPort)
+	}
+	snapshot := path.Join(k.pathResolver.ConfigsPath(), etcdSnapshotFileName)
+	stashDir := path.Join(k.pathResolver.ConfigsPath(), stashedManifestsDirName)
+	suffix := time.Now().Format("20060102150405")
+
+	for _, master := range masters {
+		logger.Info("prepare to restore etcd on %s", master)
+		if err := k.sshCmdAsync(master, installEtcdctlCmd); err != nil {
+			return err
+		}
+		if err := k.sshCopy(master, src, snapshot); err != nil {
+			return err
+		}
+	}
+	// every member must be stopped before any of them is restored
+	for _, master := range masters {
+		logger.Info("stop etcd and api-server on %s", master)
+		if err := k.sshCmdAsync(master, fmt.Sprintf(stashManifestsCmd, kubernetesEtcStaticPod, stashDir)); err != nil {
+			return err
+		}
+		if err := k.waitFor(fmt.Sprintf("etcd on %s stopped", master), func() error {
+			out, err := k.sshCmdToString(master, etcdRunningCmd)
+			if err == nil && strings.TrimSpace(out) != "" {
+				return errors.New("etcd or api-server is still running")
+			}
+			return err
+		}); err != nil {
+			return err
+		}
+	}
+	for i, master := range masters {
+		logger.Info("restore etcd member %s on %s", names[i], master)
+		restoreCmd := fmt.Sprintf(etcdSnapshotRestoreCmd, snapshot, names[i], strings.Join(initialCluster, ","),
+			etcdInitialClusterToken, iputils.GetHostIP(master), etcdPeerPort, k.getEtcdDataDir())
+		if err := k.sshCmdAsync(master, fmt.Sprintf(moveDataDirCmd, k.getEtcdDataDir(), suffix), restoreCmd, fmt.Sprintf("rm -f %s", snapshot)); err != nil {
+			return err
+		}
+	}
+	for _, master := range masters {
+		logger.Info("start etcd and api-server on %s", master)
+		if err := k.sshCmdAsync(master, fmt.Sprintf(unstashManifestsCmd, kubernetesEtcStaticPod, stashDir)); err != nil {
+			return err
+		}
+	}
+	for _, master := range masters {
+		if err := k.waitAPIServerReady(master); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (k *KubeadmRuntime) validateLocalEtcd() error {
+	if k.kubeadmConfig.ClusterConfiguration.Etcd.External != nil {
+		return errors.New("external etcd is not managed by sealos, please backup it by yourself")
+	}
+	return nil
+}
+
+func (k *KubeadmRuntime) waitFor(desc string, fn func() error) error {
+	timeout := time.Now().Add(defaultHealthTimeout)
+	for {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(timeout) {
+			return fmt.Errorf("wait for %s timeout within %s: %v", desc, defaultHealthTimeout, err)
+		}
+		logger.Debug("waiting for %s: %v", desc, err)
+		time.Sleep(defaultHealthInterval)
+	}
+}
@@ -41,6 +41,7 @@ import (
 var (
 	V1130 = semver.MustParse("v1.13.0")
 	V1150 = semver.MustParse("v1.15.0")
+	V1200 = semver.MustParse("v1.20.0")
 	V1220 = semver.MustParse("v1.22.0")
 	V1250 = semver.MustParse("v1.25.0")
 	V1260 = semver.MustParse("v1.26.0")
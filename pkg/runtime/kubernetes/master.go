@@ -109,30 +109,38 @@ func (k *KubeadmRuntime) joinMasters(masters []string) error {
 	if joinCmd == "" {
 		return fmt.Errorf("get join master command failed, kubernetes version is %s", k.getKubeVersion())
 	}
-	for _, master := range masters {
-		logger.Info("start to join %s as master", master)
-		logger.Debug("start to generate cert for master %s", master)
-		err = k.execCert(master)
-		if err != nil {
-			return fmt.Errorf("failed to create cert for master %s: %v", master, err)
+	for i, master := range masters {
+		if err = k.joinMaster(master, joinCmd); err != nil {
+			// the masters are joined one by one, the ones before have been joined
+			return ssh.NewHostsError(masters[:i], err)
 		}
+	}
+	return nil
+}
 
-		err = k.sshCmdAsync(master, joinCmd)
-		if err != nil {
-			return fmt.Errorf("exec kubeadm join in %s failed %v", master, err)
-		}
+func (k *KubeadmRuntime) joinMaster(master, joinCmd string) error {
+	logger.Info("start to join %s as master", master)
+	logger.Debug("start to generate cert for master %s", master)
+	err := k.execCert(master)
+	if err != nil {
+		return fmt.Errorf("failed to create cert for master %s: %v", master, err)
+	}
 
-		err = k.execHostsAppend(master, master, k.getAPIServerDomain())
-		if err != nil {
-			return fmt.Errorf("add master0 apiserver domain hosts in %s failed %v", master, err)
-		}
+	err = k.sshCmdAsync(master, joinCmd)
+	if err != nil {
+		return fmt.Errorf("exec kubeadm join in %s failed %v", master, err)
+	}
 
-		err = k.copyMasterKubeConfig(master)
-		if err != nil {
-			return err
-		}
-		logger.Info("succeeded in joining %s as master", master)
+	err = k.execHostsAppend(master, master, k.getAPIServerDomain())
+	if err != nil {
+		return fmt.Errorf("add master0 apiserver domain hosts in %s failed %v", master, err)
+	}
+
+	err = k.copyMasterKubeConfig(master)
+	if err != nil {
+		return err
 	}
+	logger.Info("succeeded in joining %s as master", master)
 	return nil
 }
 
@@ -161,11 +169,15 @@ func (k *KubeadmRuntime) deleteMasters(masters []string) error {
 }
 
 func (k *KubeadmRuntime) deleteMaster(master string) error {
+	masterIPs := strings.RemoveFromSlice(k.getMasterIPList(), master)
+	if len(masterIPs) > 0 {
+		if err := k.drainNode(master); err != nil {
+			return err
+		}
+	}
 	return k.resetNode(master, func() {
 		//remove master
-		masterIPs := strings.RemoveFromSlice(k.getMasterIPList(), master)
 		if len(masterIPs) > 0 {
-			// TODO: do we need draining first?
 			if err := k.removeNode(master); err != nil {
 				logger.Warn(fmt.Errorf("delete master %s failed %v", master, err))
 			}
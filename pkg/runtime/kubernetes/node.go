@@ -18,6 +18,7 @@ import (
 	"context"
 	"fmt"
 	"path"
+	"sync"
 
 	"github.com/labring/sealos/pkg/ssh"
 	"github.com/labring/sealos/pkg/utils/file"
@@ -39,35 +40,49 @@ func (k *KubeadmRuntime) joinNodes(newNodesIPList []string) error {
 	if err = k.mergeWithBuiltinKubeadmConfig(); err != nil {
 		return err
 	}
+	var (
+		mu        sync.Mutex
+		succeeded []string
+	)
 	eg, _ := errgroup.WithContext(context.Background())
 	for _, node := range newNodesIPList {
 		node := node
 		eg.Go(func() error {
-			logger.Info("start to join %s as worker", node)
-			k.mu.Lock()
-			err = k.copyKubeadmConfigToNode(node)
-			if err != nil {
-				return fmt.Errorf("failed to copy join node kubeadm config %s %v", node, err)
+			if err := k.joinNode(node, masters); err != nil {
+				return err
 			}
-			k.mu.Unlock()
-			logger.Info("run ipvs once module: %s", node)
-			err = k.execIPVS(node, masters)
-			if err != nil {
-				return fmt.Errorf("run ipvs once failed %v", err)
-			}
-			logger.Info("start join node: %s", node)
-			joinCmd := k.Command(k.getKubeVersion(), JoinNode)
-			if joinCmd == "" {
-				return fmt.Errorf("get join node command failed, kubernetes version is %s", k.getKubeVersion())
-			}
-			if err = k.sshCmdAsync(node, joinCmd); err != nil {
-				return fmt.Errorf("failed to join node %s %v", node, err)
-			}
-			logger.Info("succeeded in joining %s as worker", node)
+			mu.Lock()
+			succeeded = append(succeeded, node)
+			mu.Unlock()
 			return nil
 		})
 	}
-	return eg.Wait()
+	// the nodes are joined in parallel, report the ones that have been joined
+	return ssh.NewHostsError(succeeded, eg.Wait())
+}
+
+func (k *KubeadmRuntime) joinNode(node string, masters []string) error {
+	logger.Info("start to join %s as worker", node)
+	k.mu.Lock()
+	err := k.copyKubeadmConfigToNode(node)
+	k.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to copy join node kubeadm config %s %v", node, err)
+	}
+	logger.Info("run ipvs once module: %s", node)
+	if err = k.execIPVS(node, masters); err != nil {
+		return fmt.Errorf("run ipvs once failed %v", err)
+	}
+	logger.Info("start join node: %s", node)
+	joinCmd := k.Command(k.getKubeVersion(), JoinNode)
+	if joinCmd == "" {
+		return fmt.Errorf("get join node command failed, kubernetes version is %s", k.getKubeVersion())
+	}
+	if err = k.sshCmdAsync(node, joinCmd); err != nil {
+		return fmt.Errorf("failed to join node %s %v", node, err)
+	}
+	logger.Info("succeeded in joining %s as worker", node)
+	return nil
 }
 
 func (k *KubeadmRuntime) copyKubeadmConfigToNode(node string) error {
@@ -109,6 +124,11 @@ func (k *KubeadmRuntime) deleteNodes(nodes []string) error {
 }
 
 func (k *KubeadmRuntime) deleteNode(node string) error {
+	if len(k.getMasterIPList()) > 0 {
+		if err := k.drainNode(node); err != nil {
+			return err
+		}
+	}
 	return k.resetNode(node, func() {
 		//remove node
 		if len(k.getMasterIPList()) > 0 {
@@ -24,6 +24,7 @@ import (
 	"github.com/labring/sealos/pkg/client-go/kubernetes"
 	"github.com/labring/sealos/pkg/constants"
 	"github.com/labring/sealos/pkg/exec"
+	"github.com/labring/sealos/pkg/runtime"
 	"github.com/labring/sealos/pkg/runtime/kubernetes/types"
 	"github.com/labring/sealos/pkg/ssh"
 	v2 "github.com/labring/sealos/pkg/types/v1beta1"
@@ -43,6 +44,7 @@ type KubeadmRuntime struct {
 	execer       ssh.Interface
 	pathResolver constants.PathResolver
 	remoteUtil   *ssh.Remote
+	drainOptions *runtime.DrainOptions
 	mu           sync.Mutex
 }
 
@@ -100,10 +102,20 @@ func (k *KubeadmRuntime) ScaleUp(newMasterIPList []string, newNodeIPList []strin
 	}
 	if len(newNodeIPList) != 0 {
 		logger.Info("%s will be added as worker", newNodeIPList)
-		if err := k.joinNodes(newNodeIPList); err != nil {
-			return err
+		joinErr := k.joinNodes(newNodeIPList)
+		joined := newNodeIPList
+		var hostsErr *ssh.HostsError
+		if errors.As(joinErr, &hostsErr) {
+			joined = hostsErr.Succeeded
+		} else if joinErr != nil {
+			return ssh.NewHostsError(newMasterIPList, joinErr)
+		}
+		if err := k.copyKubeConfigFileToNodes(joined...); err != nil {
+			return ssh.NewHostsError(newMasterIPList, err)
+		}
+		if joinErr != nil {
+			return ssh.NewHostsError(append(append([]string{}, newMasterIPList...), joined...), joinErr)
 		}
-		return k.copyKubeConfigFileToNodes(newNodeIPList...)
 	}
 	return nil
 }
@@ -138,6 +150,7 @@ func newKubeadmRuntime(cluster *v2.Cluster, kubeadm *types.KubeadmConfig) (*Kube
 		execer:        execer,
 		pathResolver:  constants.NewPathResolver(cluster.GetName()),
 		remoteUtil:    ssh.NewRemoteFromSSH(cluster.GetName(), execer),
+		drainOptions:  runtime.NewDefaultDrainOptions(),
 	}
 	if err := k.Validate(); err != nil {
 		return nil, err
@@ -26,6 +26,7 @@ import (
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/labring/sealos/pkg/client-go/kubernetes"
+	"github.com/labring/sealos/pkg/runtime"
 	"github.com/labring/sealos/pkg/utils/logger"
 )
 
@@ -120,6 +121,40 @@ func (k *KubeadmRuntime) removeNode(ip string) error {
 	return nil
 }
 
+// drainNode evicts all pods from the node before it is removed, drain failures are
+// ignored if the drain options say so.
+func (k *KubeadmRuntime) drainNode(ip string) error {
+	client, err := k.getKubeInterface()
+	if err != nil {
+		return k.drainFailed(ip, err)
+	}
+	ctx := context.Background()
+	exp := kubernetes.NewKubeExpansion(client.Kubernetes())
+	hostname, err := exp.FetchHostNameFromInternalIP(ctx, ip)
+	if err != nil {
+		logger.Warn("skip draining node %s: %v", ip, err)
+		return nil
+	}
+	err = kubernetes.NewKubeDrainer(client.Kubernetes(), k.drainOptions.DrainOptions).Drain(ctx, hostname)
+	if err != nil {
+		return k.drainFailed(ip, err)
+	}
+	return nil
+}
+
+// SetDrainOptions changes how nodes are drained in ScaleDown.
+func (k *KubeadmRuntime) SetDrainOptions(opts *runtime.DrainOptions) {
+	k.drainOptions = opts
+}
+
+func (k *KubeadmRuntime) drainFailed(ip string, err error) error {
+	if !k.drainOptions.IgnoreErrors {
+		return err
+	}
+	logger.Warn("failed to drain node %s: %v, continue to delete it as drain errors are ignored", ip, err)
+	return nil
+}
+
 func (k *KubeadmRuntime) setFeatureGatesConfiguration() {
 	k.kubeadmConfig.FinalizeFeatureGatesConfiguration()
 }
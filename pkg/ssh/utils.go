@@ -42,3 +42,26 @@ func CopyDir(sshClient Interface, host, src, dest string, filter func(fs.DirEntr
 	}
 	return nil
 }
+
+// HostsError is returned by an operation on many hosts that failed on some of them,
+// Succeeded are the hosts it has been completed on, so it can be retried on the others.
+type HostsError struct {
+	Succeeded []string
+	Err       error
+}
+
+func (e *HostsError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *HostsError) Unwrap() error {
+	return e.Err
+}
+
+// NewHostsError returns a *HostsError of err if some hosts have succeeded, otherwise err itself.
+func NewHostsError(succeeded []string, err error) error {
+	if err == nil || len(succeeded) == 0 {
+		return err
+	}
+	return &HostsError{Succeeded: succeeded, Err: err}
+}
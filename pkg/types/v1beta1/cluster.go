@@ -15,12 +15,15 @@
 package v1beta1
 
 import (
+	"fmt"
 	"path"
+	"strings"
 
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/yaml"
 
+	"github.com/labring/sealos/pkg/utils/maps"
 	"github.com/labring/sealos/pkg/version"
 )
 
@@ -75,15 +78,21 @@ var (
 	imageTypeKey           = "sealos.io.type"
 	imageVersionKey        = "sealos.io.version"
 	imageDistributionKey   = "sealos.io.distribution"
+	imageUninstallKey      = "sealos.io.uninstall"
 	imageTypeKeyV2         = path.Join(GroupName, "type")
 	imageVersionKeyV2      = path.Join(GroupName, "version")
 	imageDistributionKeyV2 = path.Join(GroupName, "distribution")
+	imageUninstallKeyV2    = path.Join(GroupName, "uninstall")
 )
 
 var ImageTypeKeys = []string{imageTypeKey, imageTypeKeyV2}
 var ImageVersionKeys = []string{imageVersionKey, imageVersionKeyV2}
 var ImageDistributionKeys = []string{imageDistributionKey, imageDistributionKeyV2}
 
+// ImageUninstallKeys are the labels of the command to remove an application image from the cluster,
+// it's executed in the working directory of the application on the first master like CMD does.
+var ImageUninstallKeys = []string{imageUninstallKey, imageUninstallKeyV2}
+
 type MountImage struct {
 	Name       string            `json:"name"`
 	Type       ImageType         `json:"type"`
@@ -102,6 +111,11 @@ func (m *MountImage) KubeVersion() string {
 	return m.Labels[ImageKubeVersionKey]
 }
 
+// UninstallCommand returns the command of the uninstall label, empty if not set.
+func (m *MountImage) UninstallCommand() string {
+	return maps.GetFromKeys(m.Labels, ImageUninstallKeys...)
+}
+
 func (m *MountImage) IsApplication() bool {
 	return m.Type == "" || m.Type == AppImage
 }
@@ -137,6 +151,8 @@ const (
 const (
 	ClusterConditionTypeSuccess string = "ApplyClusterSuccess"
 	ClusterConditionTypeError   string = "ApplyClusterError"
+	// ClusterConditionTypeStage records the last completed stage of the apply pipeline
+	ClusterConditionTypeStage string = "ApplyStageCompleted"
 
 	CommandConditionTypeSuccess   string = "ApplyCommandSuccess"
 	CommandConditionTypeError     string = "ApplyCommandError"
@@ -174,6 +190,20 @@ func NewFailedClusterCondition(message string) ClusterCondition {
 	}
 }
 
+func NewStageCompletedClusterCondition(processor, stage string, hosts []string) ClusterCondition {
+	message := fmt.Sprintf("Stage %s completed", stage)
+	if len(hosts) > 0 {
+		message = fmt.Sprintf("Stage %s completed on hosts %s", stage, strings.Join(hosts, ","))
+	}
+	return ClusterCondition{
+		Type:              ClusterConditionTypeStage,
+		Status:            v1.ConditionTrue,
+		LastHeartbeatTime: metav1.Now(),
+		Reason:            processor,
+		Message:           message,
+	}
+}
+
 type CommandCondition struct {
 	Type              string             `json:"type"`
 	Status            v1.ConditionStatus `json:"status"`
@@ -212,6 +242,28 @@ type ClusterStatus struct {
 	Mounts            []MountImage       `json:"mounts,omitempty"`
 	Conditions        []ClusterCondition `json:"conditions,omitempty"`
 	CommandConditions []CommandCondition `json:"commandCondition,omitempty"`
+	// ImageHistory is the revisions of the applications which have been applied successfully
+	ImageHistory []ImageHistory `json:"imageHistory,omitempty"`
+}
+
+// MaxImageRevisions is the max number of revisions kept in the history of an application.
+const MaxImageRevisions = 10
+
+// ImageHistory is the applied revisions of an application, the latest last.
+type ImageHistory struct {
+	// App is the repository of the images without tag and digest
+	App       string          `json:"app"`
+	Revisions []ImageRevision `json:"revisions,omitempty"`
+}
+
+// ImageRevision is an image of the application which has been applied to the cluster.
+type ImageRevision struct {
+	Revision  int64             `json:"revision"`
+	ImageName string            `json:"imageName"`
+	ImageID   string            `json:"imageID,omitempty"`
+	Digest    string            `json:"digest,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+	AppliedAt metav1.Time       `json:"appliedAt"`
 }
 
 type SSH struct {
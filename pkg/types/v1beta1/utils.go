@@ -17,6 +17,8 @@ limitations under the License.
 package v1beta1
 
 import (
+	"strings"
+
 	"github.com/Masterminds/semver/v3"
 	"golang.org/x/exp/slices"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -126,6 +128,56 @@ func (c *Cluster) FindImage(name string) (int, *MountImage) {
 	return -1, nil
 }
 
+// ImageRepository returns the repository of the image without tag and digest, e.g. labring/helm of labring/helm:v3.8.2.
+func ImageRepository(image string) string {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+	return image
+}
+
+// GetImageHistory returns the history of the application, nil if not found.
+func (c *Cluster) GetImageHistory(app string) *ImageHistory {
+	for i := range c.Status.ImageHistory {
+		if c.Status.ImageHistory[i].App == app {
+			return &c.Status.ImageHistory[i]
+		}
+	}
+	return nil
+}
+
+// RecordImageRevision appends the image as the latest revision of its application, a revision of
+// the same image is moved to the latest, and the oldest ones are dropped over MaxImageRevisions.
+func (c *Cluster) RecordImageRevision(rev ImageRevision) ImageRevision {
+	app := ImageRepository(rev.ImageName)
+	h := c.GetImageHistory(app)
+	if h == nil {
+		c.Status.ImageHistory = append(c.Status.ImageHistory, ImageHistory{App: app})
+		h = &c.Status.ImageHistory[len(c.Status.ImageHistory)-1]
+	}
+	rev.Revision = 0
+	revisions := make([]ImageRevision, 0, len(h.Revisions)+1)
+	for _, r := range h.Revisions {
+		if rev.Revision < r.Revision {
+			rev.Revision = r.Revision
+		}
+		if r.ImageName == rev.ImageName && r.ImageID == rev.ImageID {
+			continue
+		}
+		revisions = append(revisions, r)
+	}
+	rev.Revision++
+	revisions = append(revisions, rev)
+	if len(revisions) > MaxImageRevisions {
+		revisions = revisions[len(revisions)-MaxImageRevisions:]
+	}
+	h.Revisions = revisions
+	return rev
+}
+
 func (c *Cluster) ReplaceRootfsImage() {
 	i1, i2 := -1, -1
 	var v1, v2 string
@@ -17,6 +17,7 @@ limitations under the License.
 package strings
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"os"
@@ -166,12 +167,35 @@ func RenderTextWithEnv(text string, envs map[string]string) string {
 }
 
 func RenderTemplatesWithEnv(filePaths string, envs map[string]string) error {
+	rendered, err := RenderTemplates(filePaths, envs)
+	if err != nil {
+		return err
+	}
+	for rel, data := range rendered {
+		fileName := filepath.Join(filePaths, rel)
+		if file.IsExist(fileName) {
+			if err := os.Remove(fileName); err != nil {
+				logger.Warn("failed to remove existing file [%s]: %v", fileName, err)
+			}
+		}
+		if err := os.WriteFile(fileName, data, os.ModePerm); err != nil {
+			return fmt.Errorf("failed to write rendered file [%s]: %v", fileName, err)
+		}
+	}
+	return nil
+}
+
+// RenderTemplates renders the template files in the etc, scripts and manifests directories
+// of filePaths with envs, the results are keyed by the paths of the rendered files relative
+// to filePaths. Nothing is written to disk.
+func RenderTemplates(filePaths string, envs map[string]string) (map[string][]byte, error) {
 	var (
 		renderEtc       = filepath.Join(filePaths, constants.EtcDirName)
 		renderScripts   = filepath.Join(filePaths, constants.ScriptsDirName)
 		renderManifests = filepath.Join(filePaths, constants.ManifestsDirName)
 	)
 
+	rendered := make(map[string][]byte)
 	for _, dir := range []string{renderEtc, renderScripts, renderManifests} {
 		logger.Debug("render env dir: %s", dir)
 		if !file.IsExist(dir) {
@@ -187,43 +211,34 @@ func RenderTemplatesWithEnv(filePaths string, envs map[string]string) error {
 				return nil
 			}
 
-			fileName := strings.TrimSuffix(path, constants.TemplateSuffix)
-			if file.IsExist(fileName) {
-				if err := os.Remove(fileName); err != nil {
-					logger.Warn("failed to remove existing file [%s]: %v", fileName, err)
-				}
-			}
-
-			writer, err := os.OpenFile(fileName, os.O_CREATE|os.O_RDWR, os.ModePerm)
-			if err != nil {
-				return fmt.Errorf("failed to open file [%s] for rendering: %v", path, err)
-			}
-			defer writer.Close()
-
 			body, err := file.ReadAll(path)
 			if err != nil {
 				return err
 			}
 
 			t, isOk, err := template.TryParse(string(body))
-			if isOk {
-				if err != nil {
-					return fmt.Errorf("failed to create template: %s %v", path, err)
-				}
-				if err := t.Execute(writer, envs); err != nil {
-					return fmt.Errorf("failed to render env template: %s %v", path, err)
-				}
-			} else {
+			if !isOk {
 				return errors.New("parse template failed")
 			}
-
+			if err != nil {
+				return fmt.Errorf("failed to create template: %s %v", path, err)
+			}
+			out := &bytes.Buffer{}
+			if err := t.Execute(out, envs); err != nil {
+				return fmt.Errorf("failed to render env template: %s %v", path, err)
+			}
+			rel, err := filepath.Rel(filePaths, strings.TrimSuffix(path, constants.TemplateSuffix))
+			if err != nil {
+				return err
+			}
+			rendered[rel] = out.Bytes()
 			return nil
 		}); err != nil {
-			return fmt.Errorf("failed to render templates in directory %s: %v", dir, err)
+			return nil, fmt.Errorf("failed to render templates in directory %s: %v", dir, err)
 		}
 	}
 
-	return nil
+	return rendered, nil
 }
 
 func TrimQuotes(s string) string {
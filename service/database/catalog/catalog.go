@@ -0,0 +1,362 @@
+package catalog
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"gopkg.in/yaml.v2"
+
+	"github.com/labring/sealos/service/database/api"
+)
+
+// builtin are the queries of the supported databases, the files in the query dir are
+// loaded after them and replace the queries with the same type and name.
+//
+//go:embed builtin/*.yaml
+var builtin embed.FS
+
+// NamespaceParam is the namespace of the request, it's available to all queries and
+// cannot be set by the client.
+const NamespaceParam = "namespace"
+
+type ParamType string
+
+const (
+	// ParamString is escaped to be used in a double-quoted PromQL string.
+	ParamString ParamType = "string"
+	// ParamName is a kubernetes resource name, e.g. the name of a database cluster.
+	ParamName ParamType = "name"
+	// ParamInt is an integer.
+	ParamInt ParamType = "int"
+	// ParamDuration is a PromQL duration like 5m.
+	ParamDuration ParamType = "duration"
+)
+
+var (
+	nameRegexp      = regexp.MustCompile(`^[a-z0-9]([-a-z0-9.]*[a-z0-9])?$`)
+	promqlEscaper   = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	supportedParams = map[ParamType]bool{ParamString: true, ParamName: true, ParamInt: true, ParamDuration: true}
+)
+
+const maxNameLength = 253
+
+type Param struct {
+	Name        string    `yaml:"name" json:"name"`
+	Type        ParamType `yaml:"type" json:"type"`
+	Required    bool      `yaml:"required,omitempty" json:"required,omitempty"`
+	Default     string    `yaml:"default,omitempty" json:"default,omitempty"`
+	Description string    `yaml:"description,omitempty" json:"description,omitempty"`
+}
+
+// value validates the value of the param and returns it as it's put into the query.
+func (p Param) value(v string) (string, error) {
+	switch p.Type {
+	case ParamName:
+		if len(v) > maxNameLength || !nameRegexp.MatchString(v) {
+			return "", fmt.Errorf("%w: param %s must be a lowercase RFC 1123 name, got %q", api.ErrInvalidQuery, p.Name, v)
+		}
+	case ParamInt:
+		if _, err := strconv.ParseInt(v, 10, 64); err != nil {
+			return "", fmt.Errorf("%w: param %s must be an integer, got %q", api.ErrInvalidQuery, p.Name, v)
+		}
+	case ParamDuration:
+		if _, err := model.ParseDuration(v); err != nil {
+			return "", fmt.Errorf("%w: param %s must be a duration like 5m, got %q", api.ErrInvalidQuery, p.Name, v)
+		}
+	default:
+		v = promqlEscaper.Replace(v)
+	}
+	return v, nil
+}
+
+// Query is a named PromQL template of a database type.
+type Query struct {
+	Name        string  `yaml:"name" json:"name"`
+	Description string  `yaml:"description,omitempty" json:"description,omitempty"`
+	Unit        string  `yaml:"unit,omitempty" json:"unit,omitempty"`
+	Params      []Param `yaml:"params,omitempty" json:"params"`
+	// Query is a go template, the params are available by their names, e.g. {{ .app }}.
+	Query string `yaml:"query" json:"-"`
+
+	tmpl *template.Template
+}
+
+// file is the format of the query files, the params are shared by all the queries.
+type file struct {
+	Type    string  `yaml:"type"`
+	Params  []Param `yaml:"params,omitempty"`
+	Queries []Query `yaml:"queries"`
+}
+
+// Engine is the queries of a database type.
+type Engine struct {
+	Type    string   `json:"type"`
+	Queries []*Query `json:"queries"`
+}
+
+// Catalog is the query templates of all database types, loaded from the builtin files and
+// the yaml files in a directory, e.g. a mounted ConfigMap.
+type Catalog struct {
+	dir string
+
+	mu          sync.RWMutex
+	engines     map[string]map[string]*Query
+	fingerprint string
+}
+
+// New loads the catalog, dir is optional.
+func New(dir string) (*Catalog, error) {
+	c := &Catalog{dir: dir}
+	if err := c.Reload(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Reload loads the queries again, the current ones are kept if any file is invalid.
+func (c *Catalog) Reload() error {
+	fingerprint, err := c.dirFingerprint()
+	if err != nil {
+		return err
+	}
+	engines := map[string]map[string]*Query{}
+	if err = loadFS(engines, builtin, "builtin"); err != nil {
+		return fmt.Errorf("load builtin queries: %v", err)
+	}
+	if c.dir != "" {
+		if err = loadFS(engines, os.DirFS(c.dir), "."); err != nil {
+			return fmt.Errorf("load queries from %s: %v", c.dir, err)
+		}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.engines, c.fingerprint = engines, fingerprint
+	return nil
+}
+
+// Watch reloads the queries every interval if the files in the dir change, until stop
+// is closed. The files of a ConfigMap are updated in place by kubelet.
+func (c *Catalog) Watch(interval time.Duration, stop <-chan struct{}) {
+	if c.dir == "" || interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		fingerprint, err := c.dirFingerprint()
+		if err != nil {
+			log.Printf("failed to check queries in %s: %v\n", c.dir, err)
+			continue
+		}
+		c.mu.RLock()
+		changed := fingerprint != c.fingerprint
+		c.mu.RUnlock()
+		if !changed {
+			continue
+		}
+		if err = c.Reload(); err != nil {
+			log.Printf("failed to reload queries, keep the current ones: %v\n", err)
+			// don't retry until the files change again
+			c.mu.Lock()
+			c.fingerprint = fingerprint
+			c.mu.Unlock()
+			continue
+		}
+		log.Printf("reloaded queries from %s\n", c.dir)
+	}
+}
+
+// dirFingerprint returns the names, sizes and modification times of the query files.
+func (c *Catalog) dirFingerprint() (string, error) {
+	if c.dir == "" {
+		return "", nil
+	}
+	files, err := queryFiles(os.DirFS(c.dir), ".")
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, name := range files {
+		info, err := os.Stat(filepath.Join(c.dir, name))
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "%s:%d:%d;", name, info.Size(), info.ModTime().UnixNano())
+	}
+	return b.String(), nil
+}
+
+// queryFiles returns the yaml files in the dir, hidden files like the ..data dir of a
+// ConfigMap are skipped.
+func queryFiles(fsys fs.FS, dir string) ([]string, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, e := range entries {
+		name := e.Name()
+		if strings.HasPrefix(name, ".") || e.IsDir() {
+			continue
+		}
+		if ext := filepath.Ext(name); ext == ".yaml" || ext == ".yml" {
+			files = append(files, filepath.ToSlash(filepath.Join(dir, name)))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func loadFS(engines map[string]map[string]*Query, fsys fs.FS, dir string) error {
+	files, err := queryFiles(fsys, dir)
+	if err != nil {
+		return err
+	}
+	for _, name := range files {
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		f := &file{}
+		if err = yaml.UnmarshalStrict(data, f); err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		if err = f.compile(); err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		if engines[f.Type] == nil {
+			engines[f.Type] = map[string]*Query{}
+		}
+		for i := range f.Queries {
+			engines[f.Type][f.Queries[i].Name] = &f.Queries[i]
+		}
+	}
+	return nil
+}
+
+// compile validates the file and parses the templates of the queries.
+func (f *file) compile() error {
+	if f.Type == "" {
+		return fmt.Errorf("type is required")
+	}
+	for i := range f.Queries {
+		q := &f.Queries[i]
+		if q.Name == "" || q.Query == "" {
+			return fmt.Errorf("queries[%d]: name and query are required", i)
+		}
+		q.Params = mergeParams(f.Params, q.Params)
+		for j := range q.Params {
+			p := &q.Params[j]
+			if p.Type == "" {
+				p.Type = ParamString
+			}
+			if p.Name == "" || p.Name == NamespaceParam {
+				return fmt.Errorf("query %s: invalid param name %q", q.Name, p.Name)
+			}
+			if !supportedParams[p.Type] {
+				return fmt.Errorf("query %s: param %s has unsupported type %q", q.Name, p.Name, p.Type)
+			}
+			if p.Default != "" {
+				if _, err := p.value(p.Default); err != nil {
+					return fmt.Errorf("query %s: invalid default: %v", q.Name, err)
+				}
+			}
+		}
+		tmpl, err := template.New(q.Name).Option("missingkey=error").Parse(q.Query)
+		if err != nil {
+			return fmt.Errorf("query %s: %v", q.Name, err)
+		}
+		q.tmpl = tmpl
+	}
+	return nil
+}
+
+// mergeParams returns the shared params overridden by the ones of the query.
+func mergeParams(shared, own []Param) []Param {
+	ret := make([]Param, 0, len(shared)+len(own))
+	for _, p := range shared {
+		overridden := false
+		for _, o := range own {
+			if o.Name == p.Name {
+				overridden = true
+				break
+			}
+		}
+		if !overridden {
+			ret = append(ret, p)
+		}
+	}
+	return append(ret, own...)
+}
+
+// Render returns the query of the database type with the params filled in.
+func (c *Catalog) Render(dbType, name, namespace string, values map[string]string) (string, error) {
+	c.mu.RLock()
+	q, ok := c.engines[dbType][name]
+	c.mu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("%w: %s of database type %s", api.ErrUnknownQuery, name, dbType)
+	}
+	data := map[string]string{NamespaceParam: namespace}
+	for _, p := range q.Params {
+		v, ok := values[p.Name]
+		if !ok || v == "" {
+			if p.Required {
+				return "", fmt.Errorf("%w: param %s is required by query %s", api.ErrInvalidQuery, p.Name, name)
+			}
+			v = p.Default
+		}
+		if v == "" {
+			data[p.Name] = ""
+			continue
+		}
+		v, err := p.value(v)
+		if err != nil {
+			return "", err
+		}
+		data[p.Name] = v
+	}
+	var b bytes.Buffer
+	if err := q.tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("render query %s: %v", name, err)
+	}
+	return b.String(), nil
+}
+
+// Engines returns the queries of the database type, or of all types if it's empty.
+func (c *Catalog) Engines(dbType string) []Engine {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	ret := make([]Engine, 0, len(c.engines))
+	for t, queries := range c.engines {
+		if dbType != "" && t != dbType {
+			continue
+		}
+		e := Engine{Type: t, Queries: make([]*Query, 0, len(queries))}
+		for _, q := range queries {
+			e.Queries = append(e.Queries, q)
+		}
+		sort.Slice(e.Queries, func(i, j int) bool { return e.Queries[i].Name < e.Queries[j].Name })
+		ret = append(ret, e)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Type < ret[j].Type })
+	return ret
+}
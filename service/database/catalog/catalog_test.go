@@ -0,0 +1,356 @@
+package catalog
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/promql/parser"
+
+	"github.com/labring/sealos/service/database/api"
+)
+
+// sampleValues are valid values of each param type.
+var sampleValues = map[ParamType]string{
+	ParamString:   "value",
+	ParamName:     "my-db",
+	ParamInt:      "3",
+	ParamDuration: "5m",
+}
+
+func TestBuiltin(t *testing.T) {
+	c, err := New("")
+	if err != nil {
+		t.Fatalf("failed to load builtin queries: %v", err)
+	}
+	engines := c.Engines("")
+	var types []string
+	for _, e := range engines {
+		types = append(types, e.Type)
+		if len(e.Queries) == 0 {
+			t.Errorf("expect queries of %s", e.Type)
+		}
+	}
+	if got := strings.Join(types, ","); got != "apecloud-mysql,mongodb,postgresql,redis" {
+		t.Errorf("unexpected database types %s", got)
+	}
+	if got := c.Engines("redis"); len(got) != 1 || got[0].Type != "redis" {
+		t.Errorf("expect only redis queries, got %v", got)
+	}
+	if got := c.Engines("unknown"); len(got) != 0 {
+		t.Errorf("expect no queries of unknown type, got %v", got)
+	}
+}
+
+// TestRenderBuiltin renders every builtin query once and checks the result is valid PromQL
+// scoped to the namespace.
+func TestRenderBuiltin(t *testing.T) {
+	c, err := New("")
+	if err != nil {
+		t.Fatalf("failed to load builtin queries: %v", err)
+	}
+	for _, e := range c.Engines("") {
+		for _, q := range e.Queries {
+			values := map[string]string{}
+			for _, p := range q.Params {
+				values[p.Name] = sampleValues[p.Type]
+			}
+			query, err := c.Render(e.Type, q.Name, "ns-test", values)
+			if err != nil {
+				t.Errorf("%s/%s: %v", e.Type, q.Name, err)
+				continue
+			}
+			if _, err = parser.ParseExpr(query); err != nil {
+				t.Errorf("%s/%s renders invalid query %s: %v", e.Type, q.Name, query, err)
+			}
+			if !strings.Contains(query, `namespace="ns-test"`) {
+				t.Errorf("%s/%s is not scoped to the namespace: %s", e.Type, q.Name, query)
+			}
+		}
+	}
+}
+
+const testQueries = `type: test
+params:
+  - name: app
+    type: name
+    required: true
+queries:
+  - name: typed
+    params:
+      - name: limit
+        type: int
+        default: "5"
+      - name: window
+        type: duration
+        default: 5m
+      - name: label
+    query: 'topk({{ .limit }}, rate(up{namespace="{{ .namespace }}",app="{{ .app }}",label="{{ .label }}"}[{{ .window }}]))'
+  - name: missing
+    query: 'up{namespace="{{ .namespace }}",job="{{ .job }}"}'
+`
+
+func writeQueries(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRender(t *testing.T) {
+	dir := t.TempDir()
+	writeQueries(t, dir, "test.yaml", testQueries)
+	c, err := New(dir)
+	if err != nil {
+		t.Fatalf("failed to load queries: %v", err)
+	}
+	tests := []struct {
+		name    string
+		dbType  string
+		query   string
+		values  map[string]string
+		want    string
+		wantErr error
+		errMsg  string
+	}{
+		{
+			name:   "defaults",
+			dbType: "test", query: "typed",
+			values: map[string]string{"app": "my-db"},
+			want:   `topk(5, rate(up{namespace="ns-test",app="my-db",label=""}[5m]))`,
+		},
+		{
+			name:   "values",
+			dbType: "test", query: "typed",
+			values: map[string]string{"app": "my-db.v1", "limit": "-10", "window": "1h30m", "label": "a"},
+			want:   `topk(-10, rate(up{namespace="ns-test",app="my-db.v1",label="a"}[1h30m]))`,
+		},
+		{
+			name:   "string is escaped",
+			dbType: "test", query: "typed",
+			values: map[string]string{"app": "my-db", "label": `a"}) or up{x="\` + "\n"},
+			want:   `topk(5, rate(up{namespace="ns-test",app="my-db",label="a\"}) or up{x=\"\\\n"}[5m]))`,
+		},
+		{
+			name:   "namespace cannot be set",
+			dbType: "test", query: "typed",
+			values: map[string]string{"app": "my-db", "namespace": "kube-system"},
+			want:   `topk(5, rate(up{namespace="ns-test",app="my-db",label=""}[5m]))`,
+		},
+		{
+			name:   "required param",
+			dbType: "test", query: "typed",
+			values:  map[string]string{"app": ""},
+			wantErr: api.ErrInvalidQuery, errMsg: "param app is required",
+		},
+		{
+			name:   "invalid int",
+			dbType: "test", query: "typed",
+			values:  map[string]string{"app": "my-db", "limit": "5) or vector(1"},
+			wantErr: api.ErrInvalidQuery, errMsg: "param limit must be an integer",
+		},
+		{
+			name:   "float is not an int",
+			dbType: "test", query: "typed",
+			values:  map[string]string{"app": "my-db", "limit": "1.5"},
+			wantErr: api.ErrInvalidQuery, errMsg: "param limit must be an integer",
+		},
+		{
+			name:   "invalid duration",
+			dbType: "test", query: "typed",
+			values:  map[string]string{"app": "my-db", "window": "5m])"},
+			wantErr: api.ErrInvalidQuery, errMsg: "param window must be a duration",
+		},
+		{
+			name:   "duration without unit",
+			dbType: "test", query: "typed",
+			values:  map[string]string{"app": "my-db", "window": "300"},
+			wantErr: api.ErrInvalidQuery, errMsg: "param window must be a duration",
+		},
+		{
+			name:   "name with quote",
+			dbType: "test", query: "typed",
+			values:  map[string]string{"app": `my-db",app!="`},
+			wantErr: api.ErrInvalidQuery, errMsg: "param app must be a lowercase RFC 1123 name",
+		},
+		{
+			name:   "name with regex",
+			dbType: "test", query: "typed",
+			values:  map[string]string{"app": "my-db|other"},
+			wantErr: api.ErrInvalidQuery, errMsg: "param app must be a lowercase RFC 1123 name",
+		},
+		{
+			name:   "uppercase name",
+			dbType: "test", query: "typed",
+			values:  map[string]string{"app": "My-DB"},
+			wantErr: api.ErrInvalidQuery, errMsg: "param app must be a lowercase RFC 1123 name",
+		},
+		{
+			name:   "too long name",
+			dbType: "test", query: "typed",
+			values:  map[string]string{"app": strings.Repeat("a", maxNameLength+1)},
+			wantErr: api.ErrInvalidQuery, errMsg: "param app must be a lowercase RFC 1123 name",
+		},
+		{
+			name:   "missing key",
+			dbType: "test", query: "missing",
+			values: map[string]string{"app": "my-db", "job": "x"},
+			errMsg: `map has no entry for key "job"`,
+		},
+		{
+			name:   "unknown query",
+			dbType: "test", query: "unknown",
+			wantErr: api.ErrUnknownQuery,
+		},
+		{
+			name:   "unknown database type",
+			dbType: "unknown", query: "typed",
+			wantErr: api.ErrUnknownQuery,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.Render(tt.dbType, tt.query, "ns-test", tt.values)
+			if tt.wantErr == nil && tt.errMsg == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if got != tt.want {
+					t.Errorf("expect %s, got %s", tt.want, got)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expect error, got query %s", got)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("expect %v, got %v", tt.wantErr, err)
+			}
+			if !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("expect error containing %q, got %v", tt.errMsg, err)
+			}
+		})
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		errMsg  string
+	}{
+		{name: "no type", content: "queries:\n  - name: a\n    query: up\n", errMsg: "type is required"},
+		{name: "no query", content: "type: test\nqueries:\n  - name: a\n", errMsg: "name and query are required"},
+		{name: "unknown field", content: "type: test\nqueries:\n  - name: a\n    query: up\n    unit: percent\n    color: red\n", errMsg: "field color not found"},
+		{
+			name:    "namespace param",
+			content: "type: test\nparams:\n  - name: namespace\nqueries:\n  - name: a\n    query: up\n",
+			errMsg:  `invalid param name "namespace"`,
+		},
+		{
+			name:    "unsupported param type",
+			content: "type: test\nqueries:\n  - name: a\n    query: up\n    params:\n      - name: b\n        type: float\n",
+			errMsg:  `param b has unsupported type "float"`,
+		},
+		{
+			name:    "invalid default",
+			content: "type: test\nqueries:\n  - name: a\n    query: up\n    params:\n      - name: b\n        type: int\n        default: ten\n",
+			errMsg:  "invalid default",
+		},
+		{
+			name:    "invalid template",
+			content: "type: test\nqueries:\n  - name: a\n    query: 'up{job=\"{{ .job \"}'\n",
+			errMsg:  "query a:",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeQueries(t, dir, "test.yaml", tt.content)
+			_, err := New(dir)
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("expect error containing %q, got %v", tt.errMsg, err)
+			}
+		})
+	}
+}
+
+func TestOverride(t *testing.T) {
+	dir := t.TempDir()
+	writeQueries(t, dir, "mysql.yaml", `type: apecloud-mysql
+queries:
+  - name: cpu
+    query: 'custom_cpu{namespace="{{ .namespace }}"}'
+`)
+	// hidden files like the ..data dir of a ConfigMap and other extensions are skipped
+	writeQueries(t, dir, ".hidden.yaml", "invalid")
+	writeQueries(t, dir, "README.md", "invalid")
+	c, err := New(dir)
+	if err != nil {
+		t.Fatalf("failed to load queries: %v", err)
+	}
+	got, err := c.Render("apecloud-mysql", "cpu", "ns-test", nil)
+	if err != nil || got != `custom_cpu{namespace="ns-test"}` {
+		t.Errorf("expect overridden query, got %s, %v", got, err)
+	}
+	// the other builtin queries are kept
+	if _, err = c.Render("apecloud-mysql", "memory", "ns-test", map[string]string{"app": "my-db"}); err != nil {
+		t.Errorf("expect builtin memory query, got %v", err)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	dir := t.TempDir()
+	writeQueries(t, dir, "test.yaml", "type: test\nqueries:\n  - name: a\n    query: up\n")
+	c, err := New(dir)
+	if err != nil {
+		t.Fatalf("failed to load queries: %v", err)
+	}
+	stop := make(chan struct{})
+	defer close(stop)
+	go c.Watch(10*time.Millisecond, stop)
+
+	waitFor := func(want string) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			if got, _ := c.Render("test", "a", "ns-test", nil); got == want {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		got, err := c.Render("test", "a", "ns-test", nil)
+		t.Fatalf("expect query %s, got %s, %v", want, got, err)
+	}
+
+	// the fingerprint changes with the size of the file
+	writeQueries(t, dir, "test.yaml", "type: test\nqueries:\n  - name: a\n    query: down\n")
+	waitFor("down")
+
+	// invalid files are not loaded, the current queries are kept
+	writeQueries(t, dir, "test.yaml", "type: test\nqueries:\n  - name: a\n")
+	invalid, err := c.dirFingerprint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		c.mu.RLock()
+		fingerprint := c.fingerprint
+		c.mu.RUnlock()
+		if fingerprint == invalid {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expect the invalid files to be checked, got fingerprint %s", fingerprint)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	waitFor("down")
+
+	writeQueries(t, dir, "test.yaml", "type: test\nqueries:\n  - name: a\n    query: sum(up)\n")
+	waitFor("sum(up)")
+}
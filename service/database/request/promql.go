@@ -0,0 +1,130 @@
+package request
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+
+	"github.com/labring/sealos/service/database/api"
+)
+
+// namespaceLabel is set by the server on every vector selector, queries can't match or
+// write it.
+const namespaceLabel = "namespace"
+
+// labelWritingFunctions is the deny list of the functions which can rewrite labels, mapped to
+// the index of the argument naming the label they write. They are rejected if the label is the
+// namespace label, or isn't a plain string literal. No other functions are restricted, every
+// series they read or join is already scoped to the namespace by the matcher on the vector
+// selectors.
+var labelWritingFunctions = map[string]int{
+	"label_replace": 1,
+	"label_join":    1,
+}
+
+// InjectNamespace parses the query and sets an exact namespace matcher on every vector
+// selector, including the ones in range vectors and subqueries, so that the query only
+// reads the series of the namespace.
+func InjectNamespace(query, ns string) (string, error) {
+	expr, err := parser.ParseExpr(stripPlaceholders(query))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", api.ErrInvalidQuery, err)
+	}
+	var inspectErr error
+	parser.Inspect(expr, func(node parser.Node, _ []parser.Node) error {
+		if err := injectNode(node, ns); err != nil {
+			inspectErr = err
+			return err
+		}
+		return nil
+	})
+	if inspectErr != nil {
+		return "", inspectErr
+	}
+	return expr.String(), nil
+}
+
+func injectNode(node parser.Node, ns string) error {
+	switch n := node.(type) {
+	case *parser.VectorSelector:
+		matchers := make([]*labels.Matcher, 0, len(n.LabelMatchers)+1)
+		for _, m := range n.LabelMatchers {
+			if m.Name != namespaceLabel {
+				matchers = append(matchers, m)
+				continue
+			}
+			// matching the own namespace exactly is redundant but harmless
+			if m.Type != labels.MatchEqual || m.Value != ns {
+				return fmt.Errorf("%w: label %s is set by the server and cannot be matched by %s", api.ErrInvalidQuery, namespaceLabel, m)
+			}
+		}
+		matcher, err := labels.NewMatcher(labels.MatchEqual, namespaceLabel, ns)
+		if err != nil {
+			return err
+		}
+		n.LabelMatchers = append(matchers, matcher)
+	case *parser.Call:
+		if i, ok := labelWritingFunctions[n.Func.Name]; ok && i < len(n.Args) {
+			if !writableLabel(n.Args[i]) {
+				return fmt.Errorf("%w: function %s cannot write label %s", api.ErrInvalidQuery, n.Func.Name, n.Args[i])
+			}
+		}
+	case *parser.AggregateExpr:
+		if n.Op == parser.COUNT_VALUES && !writableLabel(n.Param) {
+			return fmt.Errorf("%w: count_values cannot write label %s", api.ErrInvalidQuery, n.Param)
+		}
+	}
+	return nil
+}
+
+// writableLabel returns whether the label written by a function is a string literal other than
+// the namespace label, the parentheses around it are evaluated the same as the literal.
+func writableLabel(expr parser.Expr) bool {
+	for {
+		paren, ok := expr.(*parser.ParenExpr)
+		if !ok {
+			break
+		}
+		expr = paren.Expr
+	}
+	dst, ok := expr.(*parser.StringLiteral)
+	return ok && dst.Val != namespaceLabel
+}
+
+// stripPlaceholders removes the $ placeholders of the namespace matcher from the label
+// matchers, e.g. up{$,job="a"}, which were replaced by the server before.
+func stripPlaceholders(query string) string {
+	var b strings.Builder
+	var quote rune
+	escaped := false
+	runes := []rune(query)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote != 0:
+			// keep everything in strings
+			if escaped {
+				escaped = false
+			} else if r == '\\' && quote != '`' {
+				escaped = true
+			} else if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'' || r == '`':
+			quote = r
+		case r == '$':
+			// drop the placeholder and the comma after it
+			for i+1 < len(runes) && (runes[i+1] == ' ' || runes[i+1] == '\t') {
+				i++
+			}
+			if i+1 < len(runes) && runes[i+1] == ',' {
+				i++
+			}
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
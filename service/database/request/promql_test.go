@@ -0,0 +1,199 @@
+package request
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/labring/sealos/service/database/api"
+)
+
+func TestInjectNamespace(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		ns      string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "no braces",
+			query: `up`,
+			ns:    "ns-a",
+			want:  `up{namespace="ns-a"}`,
+		},
+		{
+			name:  "other matchers are kept",
+			query: `sum(rate(http_requests_total{job="a"}[5m])) by (pod)`,
+			ns:    "ns-a",
+			want:  `sum by (pod) (rate(http_requests_total{job="a",namespace="ns-a"}[5m]))`,
+		},
+		{
+			name:  "nested subqueries",
+			query: `max_over_time(rate(up[5m])[1h:1m]) / max_over_time(min_over_time(down[10m:1m])[1h:5m])`,
+			ns:    "ns-a",
+			want:  `max_over_time(rate(up{namespace="ns-a"}[5m])[1h:1m]) / max_over_time(min_over_time(down{namespace="ns-a"}[10m:1m])[1h:5m])`,
+		},
+		{
+			name:  "metric name matcher",
+			query: `{__name__=~"up|down"} offset 5m`,
+			ns:    "ns-a",
+			want:  `{__name__=~"up|down",namespace="ns-a"} offset 5m`,
+		},
+		{
+			name:  "regex metacharacters in the namespace are matched literally",
+			query: `up`,
+			ns:    `.*|x"y`,
+			want:  `up{namespace=".*|x\"y"}`,
+		},
+		{
+			name:  "own namespace matched exactly",
+			query: `up{namespace="ns-a"}`,
+			ns:    "ns-a",
+			want:  `up{namespace="ns-a"}`,
+		},
+		{
+			name:  "placeholder",
+			query: `up{$, job="a"}`,
+			ns:    "ns-a",
+			want:  `up{job="a",namespace="ns-a"}`,
+		},
+		{
+			name:  "placeholder in string literals is kept",
+			query: `label_replace(up{$,job="a$b"}, "dst", "$1", "src", "(.*)")`,
+			ns:    "ns-a",
+			want:  `label_replace(up{job="a$b",namespace="ns-a"}, "dst", "$1", "src", "(.*)")`,
+		},
+		{
+			name:  "placeholder after escaped quote",
+			query: `up{$,job="a\"$"}`,
+			ns:    "ns-a",
+			want:  `up{job="a\"$",namespace="ns-a"}`,
+		},
+		{
+			name:    "other namespace",
+			query:   `up{namespace="ns-b"}`,
+			ns:      "ns-a",
+			wantErr: true,
+		},
+		{
+			name:    "regex namespace matcher",
+			query:   `up{namespace=~"ns-a"}`,
+			ns:      "ns-a",
+			wantErr: true,
+		},
+		{
+			name:    "negative namespace matcher",
+			query:   `up{namespace!="ns-b"}`,
+			ns:      "ns-a",
+			wantErr: true,
+		},
+		{
+			name:    "namespace matcher in a subquery",
+			query:   `max_over_time(rate(up{namespace=~".+"}[5m])[1h:1m])`,
+			ns:      "ns-a",
+			wantErr: true,
+		},
+		{
+			name:    "label_replace writes namespace",
+			query:   `label_replace(up, "namespace", "ns-b", "", "")`,
+			ns:      "ns-a",
+			wantErr: true,
+		},
+		{
+			name:    "label_join writes namespace",
+			query:   `label_join(up, "namespace", "", "job")`,
+			ns:      "ns-a",
+			wantErr: true,
+		},
+		{
+			name:    "count_values writes namespace",
+			query:   `count_values("namespace", up)`,
+			ns:      "ns-a",
+			wantErr: true,
+		},
+		{
+			name:    "label_replace writes namespace in parentheses",
+			query:   `label_replace(up, (("namespace")), "ns-b", "", "")`,
+			ns:      "ns-a",
+			wantErr: true,
+		},
+		{
+			name:    "label_join writes namespace in parentheses",
+			query:   `label_join(up, ("namespace"), ",", "pod")`,
+			ns:      "ns-a",
+			wantErr: true,
+		},
+		{
+			name:    "count_values writes namespace in parentheses",
+			query:   `count_values(("namespace"), up)`,
+			ns:      "ns-a",
+			wantErr: true,
+		},
+		{
+			name:  "label_join writes another label in parentheses",
+			query: `label_join(up, ("pods"), ",", "pod")`,
+			ns:    "ns-a",
+			want:  `label_join(up{namespace="ns-a"}, ("pods"), ",", "pod")`,
+		},
+		{
+			name:  "count_values writes another label",
+			query: `count_values("value", up)`,
+			ns:    "ns-a",
+			want:  `count_values("value", up{namespace="ns-a"})`,
+		},
+		{
+			name:  "joins only match the series of the namespace",
+			query: `up * on (pod) group_left (node) kube_pod_info`,
+			ns:    "ns-a",
+			want:  `up{namespace="ns-a"} * on (pod) group_left (node) kube_pod_info{namespace="ns-a"}`,
+		},
+		{
+			name:  "label_replace writes another label",
+			query: `label_replace(up, "dst", "$1", "namespace", "(.*)")`,
+			ns:    "ns-a",
+			want:  `label_replace(up{namespace="ns-a"}, "dst", "$1", "namespace", "(.*)")`,
+		},
+		{
+			name:    "invalid query",
+			query:   `up{`,
+			ns:      "ns-a",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := InjectNamespace(tt.query, tt.ns)
+			if tt.wantErr {
+				if !errors.Is(err, api.ErrInvalidQuery) {
+					t.Errorf("InjectNamespace() error = %v, want %v", err, api.ErrInvalidQuery)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("InjectNamespace() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("InjectNamespace() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStripPlaceholders(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{`up{$}`, `up{}`},
+		{`up{$ , job="a"}`, `up{ job="a"}`},
+		{`up{job="$"}`, `up{job="$"}`},
+		{`up{job='$', $}`, `up{job='$', }`},
+		{"up{job=`a\\`, $}", "up{job=`a\\`, }"},
+		{`up{job="a\"$", $}`, `up{job="a\"$", }`},
+	}
+	for _, tt := range tests {
+		if got := stripPlaceholders(tt.query); got != tt.want {
+			t.Errorf("stripPlaceholders(%s) = %s, want %s", tt.query, got, tt.want)
+		}
+	}
+}
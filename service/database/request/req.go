@@ -8,7 +8,6 @@ import (
 	"net/http"
 	"net/url"
 	"os"
-	"strings"
 
 	"github.com/labring/sealos/service/database/api"
 )
@@ -35,8 +34,10 @@ func Request(addr string, params *bytes.Buffer) ([]byte, error) {
 }
 
 func PrometheusPre(query *api.PromRequest) ([]byte, error) {
-	result := strings.ReplaceAll(query.Query, "$", "namespace=~\""+query.NS+"\"")
-	result = strings.ReplaceAll(result, "{", "{namespace=~\""+query.NS+"\",")
+	result, err := InjectNamespace(query.Query, query.NS)
+	if err != nil {
+		return nil, err
+	}
 	log.Println(result)
 
 	formData := url.Values{}
@@ -64,29 +65,13 @@ func PrometheusPre(query *api.PromRequest) ([]byte, error) {
 	return Request(prometheusHost+"/api/v1/query_range", bf)
 }
 
-func GetQuery(query *api.PromRequest) (string, error) {
-	var result string
-	switch query.Type {
-	case "apecloud-mysql":
-		result = api.Mysql[query.Query]
-	case "redis":
-		result = api.Redis[query.Query]
-	case "mongodb":
-		result = api.Mongo[query.Query]
-	case "postgresql":
-		result = api.Pgsql[query.Query]
-	default:
-		fmt.Println(query.Type)
+// PrometheusNew sends the query rendered from the catalog, the namespace matcher is
+// injected again in case the template misses it.
+func PrometheusNew(query *api.PromRequest, promql string) ([]byte, error) {
+	result, err := InjectNamespace(promql, query.NS)
+	if err != nil {
+		return nil, err
 	}
-
-	fmt.Println(query.Cluster)
-	result = strings.ReplaceAll(result, "#", query.NS)
-	result = strings.ReplaceAll(result, "@", query.Cluster)
-	return result, nil
-}
-
-func PrometheusNew(query *api.PromRequest) ([]byte, error) {
-	result, _ := GetQuery(query)
 	log.Println(result)
 
 	formData := url.Values{}
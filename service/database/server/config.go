@@ -3,6 +3,7 @@ package server
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"gopkg.in/yaml.v2"
 )
@@ -13,8 +14,14 @@ type Config struct {
 
 type ServeConfig struct {
 	ListenAddress string `yaml:"addr"`
+	// QueryDir is the dir of the query files added to the builtin ones, e.g. a mounted ConfigMap
+	QueryDir string `yaml:"query_dir"`
+	// QueryReloadInterval is how often the query files are checked for changes
+	QueryReloadInterval time.Duration `yaml:"query_reload_interval"`
 }
 
+const DefaultQueryReloadInterval = 30 * time.Second
+
 func InitConfig(configPath string) (*Config, error) {
 	configData, err := os.ReadFile(configPath)
 	if err != nil {
@@ -24,6 +31,9 @@ func InitConfig(configPath string) (*Config, error) {
 	if err := yaml.Unmarshal(configData, c); err != nil {
 		return nil, fmt.Errorf("could not parse config: %s", err)
 	}
+	if c.Server.QueryReloadInterval == 0 {
+		c.Server.QueryReloadInterval = DefaultQueryReloadInterval
+	}
 
 	return c, nil
 }
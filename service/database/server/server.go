@@ -2,6 +2,7 @@ package server
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -10,16 +11,25 @@ import (
 
 	"github.com/labring/sealos/service/database/api"
 	"github.com/labring/sealos/service/database/auth"
+	"github.com/labring/sealos/service/database/catalog"
 	"github.com/labring/sealos/service/database/request"
 )
 
 type PromServer struct {
-	Config *Config
+	Config  *Config
+	Catalog *catalog.Catalog
 }
 
 func NewPromServer(c *Config) (*PromServer, error) {
+	queries, err := catalog.New(c.Server.QueryDir)
+	if err != nil {
+		return nil, err
+	}
+	// reload the queries when the files change, until the process exits
+	go queries.Watch(c.Server.QueryReloadInterval, nil)
 	ps := &PromServer{
-		Config: c,
+		Config:  c,
+		Catalog: queries,
 	}
 	return ps, nil
 }
@@ -41,7 +51,15 @@ func (ps *PromServer) Request(pr *api.PromRequest) (*api.QueryResult, error) {
 }
 
 func (ps *PromServer) DBReq(pr *api.PromRequest) (*api.QueryResult, error) {
-	body, err := request.PrometheusNew(pr)
+	params := map[string]string{"app": pr.Cluster}
+	for k, v := range pr.Params {
+		params[k] = v
+	}
+	promql, err := ps.Catalog.Render(pr.Type, pr.Query, pr.NS, params)
+	if err != nil {
+		return nil, err
+	}
+	body, err := request.PrometheusNew(pr, promql)
 	if err != nil {
 		return nil, err
 	}
@@ -88,6 +106,11 @@ func (ps *PromServer) ParseRequest(req *http.Request) (*api.PromRequest, error)
 			pr.Type = val[0]
 		case "app":
 			pr.Cluster = val[0]
+		default:
+			if pr.Params == nil {
+				pr.Params = map[string]string{}
+			}
+			pr.Params[key] = val[0]
 		}
 	}
 
@@ -106,6 +129,8 @@ func (ps *PromServer) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 		ps.doReqPre(rw, req)
 	case req.URL.Path == pathPrefix+"/q":
 		ps.doReqNew(rw, req)
+	case req.URL.Path == pathPrefix+"/queries":
+		ps.doQueries(rw, req)
 	default:
 		http.Error(rw, "Not found", http.StatusNotFound)
 		return
@@ -127,6 +152,11 @@ func (ps *PromServer) doReqNew(rw http.ResponseWriter, req *http.Request) {
 	}
 
 	res, err := ps.DBReq(pr)
+	if errors.Is(err, api.ErrInvalidQuery) || errors.Is(err, api.ErrUnknownQuery) {
+		http.Error(rw, fmt.Sprintf("Bad request (%s)", err), http.StatusBadRequest)
+		log.Printf("Bad request (%s)\n", err)
+		return
+	}
 	if err != nil {
 		http.Error(rw, fmt.Sprintf("Query failed (%s)", err), http.StatusInternalServerError)
 		log.Printf("Query failed (%s)\n", err)
@@ -156,6 +186,21 @@ func (ps *PromServer) doReqNew(rw http.ResponseWriter, req *http.Request) {
 	}
 }
 
+// doQueries lists the queries of the catalog, of the database type in the type param if
+// it's set, so that the frontend can discover the available metrics.
+func (ps *PromServer) doQueries(rw http.ResponseWriter, req *http.Request) {
+	result, err := json.Marshal(ps.Catalog.Engines(req.URL.Query().Get("type")))
+	if err != nil {
+		http.Error(rw, "Internal Server Error", http.StatusInternalServerError)
+		log.Printf("Result failed (%s)\n", err)
+		return
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	if _, err = rw.Write(result); err != nil {
+		log.Printf("Write failed (%s)\n", err)
+	}
+}
+
 func (ps *PromServer) doReqPre(rw http.ResponseWriter, req *http.Request) {
 	pr, err := ps.ParseRequest(req)
 	if err != nil {
@@ -167,10 +212,15 @@ func (ps *PromServer) doReqPre(rw http.ResponseWriter, req *http.Request) {
 	if err := ps.Authenticate(pr); err != nil {
 		http.Error(rw, fmt.Sprintf("Authentication failed (%s)", err), http.StatusInternalServerError)
 		log.Printf("Authentication failed (%s)\n", err)
-		log.Printf("Kubeconfig (%s)\n", pr.Pwd)
+		return
 	}
 
 	res, err := ps.Request(pr)
+	if errors.Is(err, api.ErrInvalidQuery) {
+		http.Error(rw, fmt.Sprintf("Bad request (%s)", err), http.StatusBadRequest)
+		log.Printf("Bad request (%s)\n", err)
+		return
+	}
 	if err != nil {
 		http.Error(rw, fmt.Sprintf("Query failed (%s)", err), http.StatusInternalServerError)
 		log.Printf("Query failed (%s)\n", err)
@@ -2,12 +2,15 @@ package auth
 
 import (
 	"context"
+	"crypto/subtle"
 	"net"
 	"os"
 
 	"github.com/cesanta/glog"
+	imagehubv1 "github.com/labring/sealos/controllers/imagehub/api/v1"
 	"github.com/labring/sealos/pkg/client-go/kubernetes"
 	"github.com/labring/sealos/service/hub/api"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
@@ -17,6 +20,8 @@ func init() {
 
 type SealosAuthenticate struct {
 	api.Authenticator
+	// client is the client of the server, it's used to verify robot tokens.
+	client kubernetes.Client
 }
 
 func (a SealosAuthenticate) Authenticate(user string, password api.PasswordString) (bool, api.Labels, kubernetes.Client, error) {
@@ -28,6 +33,11 @@ func (a SealosAuthenticate) Authenticate(user string, password api.PasswordStrin
 		return true, api.Labels{}, nil, nil
 	}
 
+	// robot accounts log in with their token
+	if org, name, ok := imagehubv1.ParseRobotAccount(user); ok {
+		return a.authenticateRobot(org, name, password)
+	}
+
 	// if user/password is specified
 	config, err := clientcmd.RESTConfigFromKubeConfig([]byte(password))
 	if err != nil {
@@ -60,6 +70,32 @@ func (a SealosAuthenticate) Authenticate(user string, password api.PasswordStrin
 	return true, api.Labels{}, client, nil
 }
 
+func (a SealosAuthenticate) authenticateRobot(orgName, name string, token api.PasswordString) (bool, api.Labels, kubernetes.Client, error) {
+	org, err := getOrganization(a.client, orgName)
+	if err != nil {
+		glog.Errorf("Authenticate robot %s of org %s false, get org cr error: %s", name, orgName, err)
+		return false, api.Labels{}, nil, api.ErrWrongPass
+	}
+	for _, r := range org.Spec.Robots {
+		if r.Name != name {
+			continue
+		}
+		if r.Expired(metav1.Now()) {
+			glog.Errorf("Authenticate robot %s of org %s false, token expired at %s", name, orgName, r.ExpiresAt)
+			return false, api.Labels{}, nil, api.ErrWrongPass
+		}
+		if subtle.ConstantTimeCompare([]byte(imagehubv1.HashRobotToken(string(token))), []byte(r.TokenHash)) != 1 {
+			glog.Errorf("Authenticate robot %s of org %s false, wrong token", name, orgName)
+			return false, api.Labels{}, nil, api.ErrWrongPass
+		}
+		glog.Info("Authenticate robot true")
+		// robots authorize with the server client, the policy limits them to their scope
+		return true, api.Labels{}, a.client, nil
+	}
+	glog.Errorf("Authenticate robot %s of org %s false, robot not found", name, orgName)
+	return false, api.Labels{}, nil, api.ErrWrongPass
+}
+
 func GetKubernetesHostFromEnv() string {
 	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
 	if len(host) == 0 || len(port) == 0 {
@@ -71,6 +107,6 @@ func GetKubernetesHostFromEnv() string {
 func (a SealosAuthenticate) Stop() {
 }
 
-func NewSealosAuthn() SealosAuthenticate {
-	return SealosAuthenticate{}
+func NewSealosAuthn(client kubernetes.Client) SealosAuthenticate {
+	return SealosAuthenticate{client: client}
 }
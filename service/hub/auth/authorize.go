@@ -2,11 +2,14 @@ package auth
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/cesanta/glog"
 	imagehubv1 "github.com/labring/sealos/controllers/imagehub/api/v1"
 	"github.com/labring/sealos/pkg/client-go/kubernetes"
 	"github.com/labring/sealos/service/hub/api"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -28,65 +31,67 @@ func (a SealosAuthorize) Authorize(client kubernetes.Client, ai *api.AuthRequest
 		return nil, api.ErrNoMatch
 	}
 
-	var res []string
-
 	// get org using authzClient
-	orgResource := client.KubernetesDynamic().Resource(schema.GroupVersionResource{
-		Group:    "imagehub.sealos.io",
-		Version:  "v1",
-		Resource: "organizations",
-	})
-	unstructOrg, err := orgResource.Get(context.Background(), repoName.GetOrg(), metav1.GetOptions{})
+	org, err := getOrganization(client, repoName.GetOrg())
 	if err != nil {
 		glog.Infof("error when Authorize req: %s for user %s, get org cr from apiserver error: %s ", repoName, ai.Account, err)
 		return nil, api.ErrNoMatch
 	}
-	org := imagehubv1.Organization{}
-	err = runtime.DefaultUnstructuredConverter.FromUnstructured(unstructOrg.UnstructuredContent(), &org)
-	if err != nil {
-		glog.Infof("error when unstruct organization")
+
+	// the repo doesn't exist before the first push, the org roles still apply.
+	repo, err := getRepository(client, repoName.ToMetaName())
+	if err != nil && !apierrors.IsNotFound(err) {
+		glog.Infof("error when Authorize req: %s for user %s, get repo cr from apiserver error: %s", repoName, ai.Account, err)
 		return nil, api.ErrNoMatch
 	}
 
-	// if user is one of the org managers, user can pull and push it.
-	for _, r := range org.Spec.Manager {
-		if r == ai.Account {
-			res = append(res, "pull", "push")
-			break
-		}
-	}
+	d := Evaluate(PolicyInput{
+		Account:  ai.Account,
+		RepoName: repoName,
+		Org:      org,
+		Repo:     repo,
+		Actions:  ai.Actions,
+		Now:      time.Now(),
+	})
+	return d.Actions, nil
+}
 
-	// return if user is one of the org managers
-	if len(res) != 0 {
-		glog.Info("Authorize true")
-		return res, nil
+var (
+	orgGVR = schema.GroupVersionResource{
+		Group:    "imagehub.sealos.io",
+		Version:  "v1",
+		Resource: "organizations",
 	}
-
-	// check repo is public or not
-	// get repo using authzClient
-	repoResource := client.KubernetesDynamic().Resource(schema.GroupVersionResource{
+	repoGVR = schema.GroupVersionResource{
 		Group:    "imagehub.sealos.io",
 		Version:  "v1",
 		Resource: "repositories",
-	})
-	unstructRepo, err := repoResource.Get(context.Background(), repoName.ToMetaName(), metav1.GetOptions{})
-	if err != nil {
-		glog.Infof("error when Authorize req: %s for user %s, get repo cr from apiserver error: %s", repoName, ai.Account, err)
-		return nil, api.ErrNoMatch
 	}
-	repo := imagehubv1.Repository{}
-	err = runtime.DefaultUnstructuredConverter.FromUnstructured(unstructRepo.UnstructuredContent(), &repo)
+)
+
+func getOrganization(client kubernetes.Client, name string) (*imagehubv1.Organization, error) {
+	unstructOrg, err := client.KubernetesDynamic().Resource(orgGVR).Get(context.Background(), name, metav1.GetOptions{})
 	if err != nil {
-		glog.Infof("error when unstruct organization")
-		return nil, api.ErrNoMatch
+		return nil, err
 	}
-
-	// if repo is public, user can pull it anyway.
-	if !repo.Spec.IsPrivate {
-		res = append(res, "pull")
+	org := &imagehubv1.Organization{}
+	if err = runtime.DefaultUnstructuredConverter.FromUnstructured(unstructOrg.UnstructuredContent(), org); err != nil {
+		return nil, fmt.Errorf("unstruct organization %s: %v", name, err)
 	}
+	return org, nil
+}
 
-	return res, nil
+// getRepository returns a nil repository and a not found error if the repository doesn't exist.
+func getRepository(client kubernetes.Client, name string) (*imagehubv1.Repository, error) {
+	unstructRepo, err := client.KubernetesDynamic().Resource(repoGVR).Get(context.Background(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	repo := &imagehubv1.Repository{}
+	if err = runtime.DefaultUnstructuredConverter.FromUnstructured(unstructRepo.UnstructuredContent(), repo); err != nil {
+		return nil, fmt.Errorf("unstruct repository %s: %v", name, err)
+	}
+	return repo, nil
 }
 
 func (a SealosAuthorize) Stop() {
@@ -0,0 +1,154 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cesanta/glog"
+	imagehubv1 "github.com/labring/sealos/controllers/imagehub/api/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	ActionPull   = "pull"
+	ActionPush   = "push"
+	ActionDelete = "delete"
+)
+
+// roleActions are the registry actions granted by each role.
+var roleActions = map[imagehubv1.RepoRole][]string{
+	imagehubv1.RoleReader: {ActionPull},
+	imagehubv1.RoleWriter: {ActionPull, ActionPush},
+	imagehubv1.RoleAdmin:  {ActionPull, ActionPush, ActionDelete},
+}
+
+// PolicyInput is everything the policy needs to decide on a request.
+type PolicyInput struct {
+	// Account is the authenticated account, empty if anonymous.
+	Account  string
+	RepoName imagehubv1.RepoName
+	Org      *imagehubv1.Organization
+	// Repo is nil if the repository doesn't exist yet, e.g. on the first push.
+	Repo *imagehubv1.Repository
+	// Actions are the requested actions.
+	Actions []string
+	Now     time.Time
+}
+
+// Decision is the result of the policy.
+type Decision struct {
+	// Role is the highest role of the account on the repository, empty if none.
+	Role imagehubv1.RepoRole
+	// Actions are the granted actions among the requested ones.
+	Actions []string
+	Reason  string
+}
+
+// Evaluate decides the actions the account can perform on the repository:
+//   - org managers are admins of all repositories of the org;
+//   - org members have their role on all repositories of the org;
+//   - repository members have their role on the repository;
+//   - robots have their role on the repositories they are scoped to until they expire,
+//     they are not granted by manager or member lists;
+//
+// the highest role wins, and everyone can pull public repositories.
+func Evaluate(in PolicyInput) Decision {
+	d := evaluate(in)
+	glog.Infof("Policy decision for %q on %s: requested %v, granted %v (%s)", in.Account, in.RepoName, in.Actions, d.Actions, d.Reason)
+	return d
+}
+
+func evaluate(in PolicyInput) Decision {
+	role, reason := accountRole(in)
+	granted := map[string]bool{}
+	for _, a := range roleActions[role] {
+		granted[a] = true
+	}
+	if in.Repo != nil && !in.Repo.Spec.IsPrivate && !granted[ActionPull] {
+		granted[ActionPull] = true
+		if role == "" {
+			reason = "public repository"
+		} else {
+			reason += ", public repository"
+		}
+	}
+	d := Decision{Role: role, Reason: reason}
+	for _, a := range in.Actions {
+		if granted[a] {
+			d.Actions = append(d.Actions, a)
+		}
+	}
+	return d
+}
+
+// accountRole returns the highest role of the account on the repository.
+func accountRole(in PolicyInput) (imagehubv1.RepoRole, string) {
+	if in.Account == "" || in.Org == nil {
+		return "", "anonymous"
+	}
+	if org, name, ok := imagehubv1.ParseRobotAccount(in.Account); ok {
+		return robotRole(in, org, name)
+	}
+	var role imagehubv1.RepoRole
+	reason := "not a member"
+	grant := func(r imagehubv1.RepoRole, why string) {
+		if r.IsValid() && (role == "" || !role.Includes(r)) {
+			role, reason = r, why
+		}
+	}
+	for _, m := range in.Org.Spec.Manager {
+		if m == in.Account {
+			grant(imagehubv1.RoleAdmin, "org manager")
+		}
+	}
+	for _, m := range in.Org.Spec.Members {
+		if m.Name == in.Account {
+			grant(m.Role, fmt.Sprintf("org member with role %s", m.Role))
+		}
+	}
+	if in.Repo != nil {
+		for _, m := range in.Repo.Spec.Members {
+			if m.Name == in.Account {
+				grant(m.Role, fmt.Sprintf("repository member with role %s", m.Role))
+			}
+		}
+	}
+	return role, reason
+}
+
+func robotRole(in PolicyInput, org, name string) (imagehubv1.RepoRole, string) {
+	if org != in.RepoName.GetOrg() {
+		return "", fmt.Sprintf("robot of org %s", org)
+	}
+	for i := range in.Org.Spec.Robots {
+		r := &in.Org.Spec.Robots[i]
+		if r.Name != name {
+			continue
+		}
+		if r.Expired(metav1.NewTime(in.Now)) {
+			return "", fmt.Sprintf("robot %s expired at %s", name, r.ExpiresAt)
+		}
+		if !r.CanAccess(in.RepoName.GetRepo()) {
+			return "", fmt.Sprintf("robot %s is not scoped to the repository", name)
+		}
+		if !r.Role.IsValid() {
+			return "", fmt.Sprintf("robot %s has invalid role %q", name, r.Role)
+		}
+		return r.Role, fmt.Sprintf("robot with role %s", r.Role)
+	}
+	return "", fmt.Sprintf("robot %s not found", name)
+}
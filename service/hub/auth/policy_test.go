@@ -0,0 +1,194 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	imagehubv1 "github.com/labring/sealos/controllers/imagehub/api/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestEvaluate(t *testing.T) {
+	now := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	expired := metav1.NewTime(now.Add(-time.Hour))
+	valid := metav1.NewTime(now.Add(time.Hour))
+	org := &imagehubv1.Organization{Spec: imagehubv1.OrganizationSpec{
+		Name:    "labring",
+		Manager: []string{"manager"},
+		Members: []imagehubv1.Member{
+			{Name: "reader", Role: imagehubv1.RoleReader},
+			{Name: "writer", Role: imagehubv1.RoleWriter},
+			{Name: "admin", Role: imagehubv1.RoleAdmin},
+			{Name: "upgraded", Role: imagehubv1.RoleReader},
+			{Name: "invalid", Role: "owner"},
+		},
+		Robots: []imagehubv1.Robot{
+			{Name: "ci", Repos: []string{"mysql"}, Role: imagehubv1.RoleWriter},
+			{Name: "all", Repos: []string{"*"}, Role: imagehubv1.RoleReader},
+			{Name: "expired", Repos: []string{"*"}, Role: imagehubv1.RoleAdmin, ExpiresAt: &expired},
+			{Name: "valid", Repos: []string{"*"}, Role: imagehubv1.RoleAdmin, ExpiresAt: &valid},
+			{Name: "invalid", Repos: []string{"*"}, Role: "owner"},
+		},
+	}}
+	private := &imagehubv1.Repository{Spec: imagehubv1.RepositorySpec{
+		Name:      "labring/mysql",
+		IsPrivate: true,
+		Members: []imagehubv1.Member{
+			{Name: "upgraded", Role: imagehubv1.RoleAdmin},
+			{Name: "outsider", Role: imagehubv1.RoleWriter},
+			// repository members don't lower the role of org members
+			{Name: "writer", Role: imagehubv1.RoleReader},
+		},
+	}}
+	public := &imagehubv1.Repository{Spec: imagehubv1.RepositorySpec{Name: "labring/mysql"}}
+	all := []string{ActionPull, ActionPush, ActionDelete}
+
+	tests := []struct {
+		name    string
+		account string
+		repo    *imagehubv1.Repository
+		org     *imagehubv1.Organization
+		role    imagehubv1.RepoRole
+		actions []string
+		reason  string
+	}{
+		{name: "reader", account: "reader", repo: private, role: imagehubv1.RoleReader, actions: []string{ActionPull}},
+		{name: "writer", account: "writer", repo: private, role: imagehubv1.RoleWriter, actions: []string{ActionPull, ActionPush}},
+		{name: "admin", account: "admin", repo: private, role: imagehubv1.RoleAdmin, actions: all},
+		{name: "org manager", account: "manager", repo: private, role: imagehubv1.RoleAdmin, actions: all, reason: "org manager"},
+		{name: "highest role wins", account: "upgraded", repo: private, role: imagehubv1.RoleAdmin, actions: all},
+		{name: "repository member", account: "outsider", repo: private, role: imagehubv1.RoleWriter, actions: []string{ActionPull, ActionPush}},
+		{name: "repository member of a new repository", account: "outsider", repo: nil, reason: "not a member"},
+		{name: "invalid role is ignored", account: "invalid", repo: private, reason: "not a member"},
+		{name: "not a member of a private repository", account: "someone", repo: private, reason: "not a member"},
+		{name: "not a member of a public repository", account: "someone", repo: public, actions: []string{ActionPull}, reason: "public repository"},
+		{name: "writer of a new repository", account: "writer", repo: nil, role: imagehubv1.RoleWriter, actions: []string{ActionPull, ActionPush}},
+
+		{name: "anonymous on a public repository", repo: public, actions: []string{ActionPull}, reason: "public repository"},
+		{name: "anonymous on a private repository", repo: private, reason: "anonymous"},
+		{name: "anonymous on a new repository", repo: nil, reason: "anonymous"},
+		{name: "unknown org", account: "admin", repo: public, org: &imagehubv1.Organization{}, actions: []string{ActionPull}, reason: "public repository"},
+
+		{name: "robot in scope", account: imagehubv1.RobotAccount("labring", "ci"), repo: private, role: imagehubv1.RoleWriter, actions: []string{ActionPull, ActionPush}, reason: "robot with role writer"},
+		{name: "robot of all repositories", account: imagehubv1.RobotAccount("labring", "all"), repo: private, role: imagehubv1.RoleReader, actions: []string{ActionPull}},
+		{name: "robot not expired", account: imagehubv1.RobotAccount("labring", "valid"), repo: private, role: imagehubv1.RoleAdmin, actions: all},
+		{name: "expired robot", account: imagehubv1.RobotAccount("labring", "expired"), repo: private, reason: "robot expired expired at " + expired.String()},
+		{name: "expired robot on a public repository", account: imagehubv1.RobotAccount("labring", "expired"), repo: public, actions: []string{ActionPull}},
+		{name: "robot with invalid role", account: imagehubv1.RobotAccount("labring", "invalid"), repo: private, reason: `robot invalid has invalid role "owner"`},
+		{name: "unknown robot", account: imagehubv1.RobotAccount("labring", "unknown"), repo: private, reason: "robot unknown not found"},
+		{
+			name:    "robot outside its repository scope",
+			account: imagehubv1.RobotAccount("labring", "ci"),
+			repo:    &imagehubv1.Repository{Spec: imagehubv1.RepositorySpec{Name: "labring/redis", IsPrivate: true}},
+			reason:  "robot ci is not scoped to the repository",
+		},
+		{
+			name:    "robot outside its org",
+			account: imagehubv1.RobotAccount("other", "ci"),
+			repo:    private,
+			reason:  "robot of org other",
+		},
+		{
+			// robots are only granted by the robot list, not by a member with the same name
+			name:    "robot is not a member",
+			account: imagehubv1.RobotAccount("labring", "ci"),
+			repo:    private,
+			org: &imagehubv1.Organization{Spec: imagehubv1.OrganizationSpec{
+				Manager: []string{imagehubv1.RobotAccount("labring", "ci")},
+			}},
+			reason: "robot ci not found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := PolicyInput{
+				Account:  tt.account,
+				RepoName: "labring/mysql",
+				Org:      org,
+				Repo:     tt.repo,
+				Actions:  all,
+				Now:      now,
+			}
+			if tt.repo != nil {
+				in.RepoName = tt.repo.Spec.Name
+			}
+			if tt.org != nil {
+				in.Org = tt.org
+			}
+			d := evaluate(in)
+			if d.Role != tt.role {
+				t.Errorf("expect role %q, got %q", tt.role, d.Role)
+			}
+			if !reflect.DeepEqual(d.Actions, tt.actions) {
+				t.Errorf("expect actions %v, got %v", tt.actions, d.Actions)
+			}
+			if tt.reason != "" && d.Reason != tt.reason {
+				t.Errorf("expect reason %q, got %q", tt.reason, d.Reason)
+			}
+		})
+	}
+}
+
+func TestEvaluateRequestedActions(t *testing.T) {
+	in := PolicyInput{
+		Account:  "writer",
+		RepoName: "labring/mysql",
+		Org: &imagehubv1.Organization{Spec: imagehubv1.OrganizationSpec{
+			Members: []imagehubv1.Member{{Name: "writer", Role: imagehubv1.RoleWriter}},
+		}},
+		Repo:    &imagehubv1.Repository{Spec: imagehubv1.RepositorySpec{Name: "labring/mysql", IsPrivate: true}},
+		Actions: []string{ActionDelete, ActionPush},
+		Now:     time.Now(),
+	}
+	// only the requested actions are granted, in the requested order
+	if d := Evaluate(in); !reflect.DeepEqual(d.Actions, []string{ActionPush}) {
+		t.Errorf("expect actions [push], got %v", d.Actions)
+	}
+	in.Actions = nil
+	if d := Evaluate(in); d.Actions != nil {
+		t.Errorf("expect no actions, got %v", d.Actions)
+	}
+}
+
+func TestParseRobotAccount(t *testing.T) {
+	tests := []struct {
+		account string
+		org     string
+		name    string
+		ok      bool
+	}{
+		{account: "robot$labring+ci", org: "labring", name: "ci", ok: true},
+		{account: imagehubv1.RobotAccount("labring", "ci"), org: "labring", name: "ci", ok: true},
+		{account: "robot$labring+ci+extra", org: "labring", name: "ci+extra", ok: true},
+		{account: "labring+ci"},
+		{account: "robot$"},
+		{account: "robot$labring"},
+		{account: "robot$labring+"},
+		{account: "robot$+ci"},
+		{account: "robot$+"},
+		{account: "Robot$labring+ci"},
+		{account: "robotlabring+ci"},
+		{account: ""},
+	}
+	for _, tt := range tests {
+		org, name, ok := imagehubv1.ParseRobotAccount(tt.account)
+		if org != tt.org || name != tt.name || ok != tt.ok {
+			t.Errorf("ParseRobotAccount(%q) = %q, %q, %v, expect %q, %q, %v", tt.account, org, name, ok, tt.org, tt.name, tt.ok)
+		}
+	}
+}
@@ -27,6 +27,14 @@ func (rs *RestartableServer) Serve(c *server.Config) {
 	}
 
 	rs.authServer, rs.hs = as, hs
+	if c.Server.MetricsAddress != "" {
+		go func() {
+			glog.Infof("Serving metrics on %s", c.Server.MetricsAddress)
+			if err := server.ServeMetrics(c.Server.MetricsAddress); err != nil {
+				glog.Errorf("Failed to serve metrics: %s", err)
+			}
+		}()
+	}
 	var listener net.Listener
 	listener, err = net.Listen("tcp", c.Server.ListenAddress)
 	if err != nil {
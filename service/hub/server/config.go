@@ -32,6 +32,12 @@ type ServerConfig struct {
 	ReqLimitersResetInterval time.Duration `yaml:"req_limiters_reset_interval,omitempty"`
 	WhiteIPCidrList          []string      `yaml:"white_ip_cidr_list,omitempty"`
 	WhiteUserList            []string      `yaml:"white_user_list,omitempty"`
+	// LimiterBackend is memory or redis, the redis backend shares the limits between replicas
+	LimiterBackend string `yaml:"limiter_backend,omitempty"`
+	// RedisURL is like redis://:password@host:6379/0, defaults to env REDIS_URL
+	RedisURL string `yaml:"redis_url,omitempty"`
+	// MetricsAddress serves prometheus metrics on /metrics if set, e.g. :9090
+	MetricsAddress string `yaml:"metrics_addr,omitempty"`
 }
 
 type TokenConfig struct {
@@ -51,6 +57,18 @@ func validate(c *Config) error {
 	if c.Server.PathPrefix != "" && !strings.HasPrefix(c.Server.PathPrefix, "/") {
 		return errors.New("server.path_prefix must be an absolute path")
 	}
+	if c.Server.MaxRequestsPerIP < 0 || c.Server.MaxRequestsPerAccount < 0 {
+		return errors.New("server.max_requests_per_ip and server.max_requests_per_account must not be negative")
+	}
+	switch c.Server.LimiterBackend {
+	case LimiterBackendMemory:
+	case LimiterBackendRedis:
+		if c.Server.RedisURL == "" {
+			return errors.New("server.redis_url is required by the redis limiter backend")
+		}
+	default:
+		return fmt.Errorf("unknown server.limiter_backend %q, must be %s or %s", c.Server.LimiterBackend, LimiterBackendMemory, LimiterBackendRedis)
+	}
 	if c.Token.Issuer == "" {
 		return errors.New("token.issuer is required")
 	}
@@ -81,6 +99,10 @@ const (
 	DefaultMaxRequestsPerAccount    = 1000
 	DefaultMaxRequestsPerIP         = 1000
 	DefaultReqLimitersResetInterval = 1 * time.Hour
+
+	LimiterBackendMemory = "memory"
+	LimiterBackendRedis  = "redis"
+	limiterKeyPrefix     = "sealos-hub:ratelimit:"
 )
 
 func LoadConfig(fileName string) (*Config, error) {
@@ -105,6 +127,12 @@ func LoadConfig(fileName string) (*Config, error) {
 	if c.Server.ReqLimitersResetInterval == 0 {
 		c.Server.ReqLimitersResetInterval = DefaultReqLimitersResetInterval
 	}
+	if c.Server.LimiterBackend == "" {
+		c.Server.LimiterBackend = LimiterBackendMemory
+	}
+	if c.Server.RedisURL == "" {
+		c.Server.RedisURL = os.Getenv("REDIS_URL")
+	}
 	if err = validate(c); err != nil {
 		return nil, fmt.Errorf("invalid config: %s", err)
 	}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	limiterKindIP   = "ip"
+	limiterKindUser = "user"
+
+	limiterResultAllowed     = "allowed"
+	limiterResultLimited     = "limited"
+	limiterResultWhitelisted = "whitelisted"
+)
+
+var (
+	metricsRegistry = prometheus.NewRegistry()
+
+	limiterHits = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "sealos_hub",
+		Name:      "rate_limit_requests_total",
+		Help:      "Number of pull/push requests checked by the rate limiter.",
+	}, []string{"kind", "result"})
+)
+
+func init() {
+	metricsRegistry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		limiterHits,
+	)
+}
+
+// ServeMetrics serves prometheus metrics on /metrics of the address, it blocks until
+// the server fails.
+func ServeMetrics(address string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(metricsRegistry, promhttp.HandlerOpts{}))
+	srv := &http.Server{
+		Addr:              address,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
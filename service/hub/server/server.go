@@ -5,10 +5,12 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"math"
 	"net"
 	"net/http"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -29,20 +31,31 @@ type AuthServer struct {
 	config         *Config
 	authenticators api.Authenticator
 	authorizers    api.Authorizer
-	reqLimiter     *utils.Limiter
+	reqLimiter     utils.Limiter
 }
 
 func NewAuthServer(c *Config) (*AuthServer, error) {
+	reqLimiter, err := newLimiter(c)
+	if err != nil {
+		return nil, err
+	}
 	as := &AuthServer{
 		config:         c,
-		authenticators: auth.NewSealosAuthn(),
+		authenticators: auth.NewSealosAuthn(k8sClient),
 		authorizers:    auth.NewSealosAuthz(),
-		// NewLimiter will start a go routine to reset reqLimiter
-		reqLimiter: utils.NewLimiter(c.Server.MaxRequestsPerIP, c.Server.MaxRequestsPerAccount, c.Server.ReqLimitersResetInterval),
+		reqLimiter:     reqLimiter,
 	}
 	return as, nil
 }
 
+func newLimiter(c *Config) (utils.Limiter, error) {
+	if c.Server.LimiterBackend == LimiterBackendRedis {
+		return utils.NewRedisLimiter(c.Server.RedisURL, limiterKeyPrefix, c.Server.MaxRequestsPerIP, c.Server.MaxRequestsPerAccount, c.Server.ReqLimitersResetInterval)
+	}
+	// NewMemoryLimiter will start a go routine to drop idle buckets
+	return utils.NewMemoryLimiter(c.Server.MaxRequestsPerIP, c.Server.MaxRequestsPerAccount, c.Server.ReqLimitersResetInterval), nil
+}
+
 type AuthRequest struct {
 	RemoteConnAddr string
 	RemoteAddr     string
@@ -331,9 +344,9 @@ func (as *AuthServer) doAuth(rw http.ResponseWriter, req *http.Request) {
 	ar.Labels = labels
 
 	// Check if the request is coming from a valid IP and account
-	if !as.allowRequest(ar, (client == nil)) {
-		glog.Infof("Too many pull requests from %s, %s", ar.RemoteIP.String(), ar.Account)
-		http.Error(rw, "Too many pull requests", http.StatusTooManyRequests)
+	if allowed, retryAfter := as.allowRequest(ar, (client == nil)); !allowed {
+		glog.Infof("Too many pull requests from %s, %s, retry after %s", ar.RemoteIP.String(), ar.Account, retryAfter)
+		tooManyRequests(rw, retryAfter)
 		return
 	}
 
@@ -376,27 +389,49 @@ func joseBase64UrlEncode(b []byte) string {
 	return strings.TrimRight(base64.URLEncoding.EncodeToString(b), "=")
 }
 
-// allowRequest checks if the request is coming from a valid IP and account
-func (as *AuthServer) allowRequest(ar *AuthRequest, isAnomaly bool) bool {
+// tooManyRequests rejects the request, the client is told to retry after the limiter allows it
+// again if it's known.
+func tooManyRequests(rw http.ResponseWriter, retryAfter time.Duration) {
+	if retryAfter > 0 {
+		rw.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	}
+	http.Error(rw, "Too many pull requests", http.StatusTooManyRequests)
+}
+
+// allowRequest checks if the request is coming from a valid IP and account,
+// and returns how long to wait before retrying if it's not allowed.
+func (as *AuthServer) allowRequest(ar *AuthRequest, isAnomaly bool) (bool, time.Duration) {
 	if len(ar.Scopes) == 0 {
-		return true
+		return true, 0
 	}
 	for _, cidr := range as.config.Server.WhiteIPCidrList {
 		if utils.IsIPInCIDR(ar.RemoteIP, cidr) {
-			return true
+			limiterHits.WithLabelValues(limiterKindIP, limiterResultWhitelisted).Inc()
+			return true, 0
 		}
 	}
 	for _, user := range as.config.Server.WhiteUserList {
 		if ar.Account == user {
-			return true
+			limiterHits.WithLabelValues(limiterKindUser, limiterResultWhitelisted).Inc()
+			return true, 0
 		}
 	}
 
 	// anomaly user pull/push request, check IP
+	kind := limiterKindIP
+	var allowed bool
+	var retryAfter time.Duration
 	if isAnomaly {
-		return as.reqLimiter.AllowIP(ar.RemoteIP.String())
+		allowed, retryAfter = as.reqLimiter.AllowIP(ar.RemoteIP.String())
+	} else {
+		// nomal user pull/push request, check account name
+		kind = limiterKindUser
+		allowed, retryAfter = as.reqLimiter.AllowUser(ar.User)
 	}
-
-	// nomal user pull/push request, check account name
-	return as.reqLimiter.AllowUser(ar.User)
+	result := limiterResultAllowed
+	if !allowed {
+		result = limiterResultLimited
+	}
+	limiterHits.WithLabelValues(kind, result).Inc()
+	return allowed, retryAfter
 }
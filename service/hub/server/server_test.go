@@ -0,0 +1,111 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// fakeLimiter records the checked ip or user and returns the fixed result
+type fakeLimiter struct {
+	allowed    bool
+	retryAfter time.Duration
+	ip         string
+	user       string
+}
+
+func (l *fakeLimiter) AllowIP(ip string) (bool, time.Duration) {
+	l.ip = ip
+	return l.allowed, l.retryAfter
+}
+
+func (l *fakeLimiter) AllowUser(user string) (bool, time.Duration) {
+	l.user = user
+	return l.allowed, l.retryAfter
+}
+
+func TestAllowRequest(t *testing.T) {
+	scopes := []AuthScope{{Type: "repository", Name: "library/nginx", Actions: []string{"pull"}}}
+	tests := []struct {
+		name       string
+		ar         AuthRequest
+		isAnomaly  bool
+		allowed    bool
+		retryAfter time.Duration
+		ip         string
+		user       string
+	}{
+		{
+			name: "no scopes",
+			ar:   AuthRequest{RemoteIP: net.ParseIP("192.168.0.2"), User: "alice"},
+		},
+		{
+			name: "white ip",
+			ar:   AuthRequest{RemoteIP: net.ParseIP("10.0.0.2"), User: "alice", Scopes: scopes},
+		},
+		{
+			name: "white user",
+			ar:   AuthRequest{RemoteIP: net.ParseIP("192.168.0.2"), User: "admin", Account: "admin", Scopes: scopes},
+		},
+		{
+			name:       "anonymous request is limited by ip",
+			ar:         AuthRequest{RemoteIP: net.ParseIP("192.168.0.2"), Scopes: scopes},
+			isAnomaly:  true,
+			allowed:    false,
+			retryAfter: 1500 * time.Millisecond,
+			ip:         "192.168.0.2",
+		},
+		{
+			name:       "user request is limited by user",
+			ar:         AuthRequest{RemoteIP: net.ParseIP("192.168.0.2"), User: "alice", Account: "alice", Scopes: scopes},
+			allowed:    false,
+			retryAfter: 3 * time.Second,
+			user:       "alice",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := &fakeLimiter{allowed: tt.allowed, retryAfter: tt.retryAfter}
+			as := &AuthServer{
+				config: &Config{Server: ServerConfig{
+					WhiteIPCidrList: []string{"10.0.0.0/8"},
+					WhiteUserList:   []string{"admin"},
+				}},
+				reqLimiter: limiter,
+			}
+			wantAllowed := tt.ip == "" && tt.user == ""
+			allowed, retryAfter := as.allowRequest(&tt.ar, tt.isAnomaly)
+			if allowed != wantAllowed || (!allowed && retryAfter != tt.retryAfter) {
+				t.Errorf("allowRequest() = %v, %s, want %v, %s", allowed, retryAfter, wantAllowed, tt.retryAfter)
+			}
+			if limiter.ip != tt.ip || limiter.user != tt.user {
+				t.Errorf("limited ip %q, user %q, want %q, %q", limiter.ip, limiter.user, tt.ip, tt.user)
+			}
+		})
+	}
+}
+
+func TestTooManyRequests(t *testing.T) {
+	tests := []struct {
+		retryAfter time.Duration
+		want       string
+	}{
+		{0, ""},
+		{time.Second, "1"},
+		{1500 * time.Millisecond, "2"},
+		{10 * time.Millisecond, "1"},
+		{time.Minute, "60"},
+	}
+	for _, tt := range tests {
+		rw := httptest.NewRecorder()
+		tooManyRequests(rw, tt.retryAfter)
+		if rw.Code != http.StatusTooManyRequests {
+			t.Errorf("tooManyRequests(%s) code = %d, want %d", tt.retryAfter, rw.Code, http.StatusTooManyRequests)
+		}
+		if got := rw.Header().Get("Retry-After"); got != tt.want {
+			t.Errorf("tooManyRequests(%s) Retry-After = %q, want %q", tt.retryAfter, got, tt.want)
+		}
+	}
+}
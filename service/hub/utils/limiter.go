@@ -7,67 +7,108 @@ import (
 	"golang.org/x/time/rate"
 )
 
-type Limiter struct {
+// Limiter limits the pull/push requests of IPs and accounts.
+// When a request is not allowed, the returned duration is how long the client should
+// wait before retrying.
+type Limiter interface {
+	AllowIP(ip string) (bool, time.Duration)
+	AllowUser(user string) (bool, time.Duration)
+}
+
+// MemoryLimiter keeps a token bucket for each IP and account in memory, the buckets
+// refill at burst per interval. The limits are per process, so running several
+// replicas multiplies them.
+type MemoryLimiter struct {
 	mu sync.Mutex
 
-	// resetInterval is the interval to reset the limiter
-	resetInterval time.Duration
-	ipBurst       int
-	ipLimiters    map[string]*rate.Limiter
-	userBurst     int
-	userLimiters  map[string]*rate.Limiter
+	// interval is the time for an empty bucket to refill
+	interval     time.Duration
+	ipBurst      int
+	ipLimiters   map[string]*bucket
+	userBurst    int
+	userLimiters map[string]*bucket
+	now          func() time.Time
 }
 
-func NewLimiter(ipBurst int, userBurst int, resetInterval time.Duration) *Limiter {
-	ul := &Limiter{
-		ipBurst:       ipBurst,
-		userBurst:     userBurst,
-		resetInterval: resetInterval,
-		ipLimiters:    make(map[string]*rate.Limiter),
-		userLimiters:  make(map[string]*rate.Limiter),
-	}
-	// start a go routine to reset reqLimiter
+type bucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+func NewMemoryLimiter(ipBurst int, userBurst int, interval time.Duration) *MemoryLimiter {
+	ul := newMemoryLimiter(ipBurst, userBurst, interval)
+	// start a go routine to drop the buckets which have been refilled
 	go func() {
-		ticker := time.NewTicker(ul.resetInterval)
+		ticker := time.NewTicker(ul.interval)
 		defer ticker.Stop()
 		for range ticker.C {
-			ul.mu.Lock()
-			ul.ipLimiters = make(map[string]*rate.Limiter)
-			ul.userLimiters = make(map[string]*rate.Limiter)
-			ul.mu.Unlock()
+			ul.evictIdle()
 		}
 	}()
 	return ul
 }
 
-func (ul *Limiter) AllowUser(user string) bool {
-	return ul.getUserLimiter(user).AllowN(time.Now(), 1)
-}
-
-func (ul *Limiter) AllowIP(ip string) bool {
-	return ul.getIPLimiter(ip).AllowN(time.Now(), 1)
+func newMemoryLimiter(ipBurst int, userBurst int, interval time.Duration) *MemoryLimiter {
+	return &MemoryLimiter{
+		ipBurst:      ipBurst,
+		userBurst:    userBurst,
+		interval:     interval,
+		ipLimiters:   make(map[string]*bucket),
+		userLimiters: make(map[string]*bucket),
+		now:          time.Now,
+	}
 }
 
-func (ul *Limiter) getIPLimiter(ip string) *rate.Limiter {
+func (ul *MemoryLimiter) evictIdle() {
 	ul.mu.Lock()
 	defer ul.mu.Unlock()
+	before := ul.now().Add(-ul.interval)
+	evictIdle(ul.ipLimiters, before)
+	evictIdle(ul.userLimiters, before)
+}
 
-	limiter, ok := ul.ipLimiters[ip]
-	if !ok {
-		limiter = rate.NewLimiter(rate.Every(ul.resetInterval), ul.ipBurst)
-		ul.ipLimiters[ip] = limiter
+// evictIdle drops the buckets unused since before, they are full again and
+// equivalent to new ones.
+func evictIdle(buckets map[string]*bucket, before time.Time) {
+	for k, b := range buckets {
+		if b.lastSeen.Before(before) {
+			delete(buckets, k)
+		}
+	}
+}
+
+func (ul *MemoryLimiter) AllowUser(user string) (bool, time.Duration) {
+	now := ul.now()
+	return allow(ul.getLimiter(ul.userLimiters, user, ul.userBurst, now), now)
+}
+
+func (ul *MemoryLimiter) AllowIP(ip string) (bool, time.Duration) {
+	now := ul.now()
+	return allow(ul.getLimiter(ul.ipLimiters, ip, ul.ipBurst, now), now)
+}
+
+func allow(limiter *rate.Limiter, now time.Time) (bool, time.Duration) {
+	r := limiter.ReserveN(now, 1)
+	if !r.OK() {
+		return false, 0
+	}
+	if delay := r.DelayFrom(now); delay > 0 {
+		// don't take the token, the request is rejected
+		r.CancelAt(now)
+		return false, delay
 	}
-	return limiter
+	return true, 0
 }
 
-func (ul *Limiter) getUserLimiter(user string) *rate.Limiter {
+func (ul *MemoryLimiter) getLimiter(buckets map[string]*bucket, key string, burst int, now time.Time) *rate.Limiter {
 	ul.mu.Lock()
 	defer ul.mu.Unlock()
 
-	limiter, ok := ul.userLimiters[user]
+	b, ok := buckets[key]
 	if !ok {
-		limiter = rate.NewLimiter(rate.Every(ul.resetInterval), ul.userBurst)
-		ul.userLimiters[user] = limiter
+		b = &bucket{limiter: rate.NewLimiter(rate.Limit(float64(burst)/ul.interval.Seconds()), burst)}
+		buckets[key] = b
 	}
-	return limiter
+	b.lastSeen = now
+	return b.limiter
 }
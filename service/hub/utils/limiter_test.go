@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryLimiterAllow(t *testing.T) {
+	now := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	ul := newMemoryLimiter(2, 3, time.Minute)
+	ul.now = func() time.Time { return now }
+
+	// the ip bucket refills a token every 30s
+	for i := 0; i < 2; i++ {
+		if allowed, delay := ul.AllowIP("192.168.0.2"); !allowed || delay != 0 {
+			t.Fatalf("AllowIP() #%d = %v, %s, want true, 0", i, allowed, delay)
+		}
+	}
+	if allowed, delay := ul.AllowIP("192.168.0.2"); allowed || delay != 30*time.Second {
+		t.Errorf("AllowIP() of an empty bucket = %v, %s, want false, 30s", allowed, delay)
+	}
+	// the rejected requests don't take the tokens
+	now = now.Add(10 * time.Second)
+	if allowed, delay := ul.AllowIP("192.168.0.2"); allowed || delay != 20*time.Second {
+		t.Errorf("AllowIP() after 10s = %v, %s, want false, 20s", allowed, delay)
+	}
+	now = now.Add(20 * time.Second)
+	if allowed, delay := ul.AllowIP("192.168.0.2"); !allowed || delay != 0 {
+		t.Errorf("AllowIP() after the delay = %v, %s, want true, 0", allowed, delay)
+	}
+	if allowed, _ := ul.AllowIP("192.168.0.2"); allowed {
+		t.Errorf("AllowIP() takes more tokens than refilled")
+	}
+	// the other ips and the users have their own buckets
+	if allowed, _ := ul.AllowIP("192.168.0.3"); !allowed {
+		t.Errorf("AllowIP() of another ip is limited")
+	}
+	for i := 0; i < 3; i++ {
+		if allowed, _ := ul.AllowUser("192.168.0.2"); !allowed {
+			t.Fatalf("AllowUser() #%d is limited", i)
+		}
+	}
+	if allowed, delay := ul.AllowUser("192.168.0.2"); allowed || delay != 20*time.Second {
+		t.Errorf("AllowUser() of an empty bucket = %v, %s, want false, 20s", allowed, delay)
+	}
+}
+
+func TestMemoryLimiterZeroBurst(t *testing.T) {
+	ul := newMemoryLimiter(0, 0, time.Minute)
+	if allowed, delay := ul.AllowIP("192.168.0.2"); allowed || delay != 0 {
+		t.Errorf("AllowIP() = %v, %s, want false, 0", allowed, delay)
+	}
+}
+
+func TestMemoryLimiterEvictIdle(t *testing.T) {
+	now := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	ul := newMemoryLimiter(1, 1, time.Minute)
+	ul.now = func() time.Time { return now }
+
+	ul.AllowIP("idle")
+	ul.AllowUser("idle")
+	now = now.Add(50 * time.Second)
+	ul.AllowIP("active")
+	ul.AllowUser("active")
+	now = now.Add(30 * time.Second)
+	ul.evictIdle()
+	if _, ok := ul.ipLimiters["idle"]; ok {
+		t.Errorf("idle ip bucket is not evicted")
+	}
+	if _, ok := ul.userLimiters["idle"]; ok {
+		t.Errorf("idle user bucket is not evicted")
+	}
+	if _, ok := ul.ipLimiters["active"]; !ok {
+		t.Errorf("active ip bucket is evicted")
+	}
+	if _, ok := ul.userLimiters["active"]; !ok {
+		t.Errorf("active user bucket is evicted")
+	}
+	// the active bucket is still empty, the evicted one starts full
+	if allowed, _ := ul.AllowIP("active"); allowed {
+		t.Errorf("AllowIP() of the active bucket is allowed before refilled")
+	}
+	if allowed, _ := ul.AllowIP("idle"); !allowed {
+		t.Errorf("AllowIP() of the evicted bucket is limited")
+	}
+}
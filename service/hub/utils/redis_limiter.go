@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cesanta/glog"
+	"github.com/redis/go-redis/v9"
+)
+
+// slidingWindowScript counts the requests of the current and the previous window, and
+// increments the current one only if the weighted count is below the limit, so that
+// rejected requests don't extend the limit.
+// KEYS[1], KEYS[2]: the counters of the current and the previous window
+// ARGV[1]: limit, ARGV[2]: elapsed fraction of the current window, ARGV[3]: ttl in ms
+var slidingWindowScript = redis.NewScript(`
+local cur = tonumber(redis.call("GET", KEYS[1]) or "0")
+local prev = tonumber(redis.call("GET", KEYS[2]) or "0")
+local limit = tonumber(ARGV[1])
+if prev * (1 - tonumber(ARGV[2])) + cur < limit then
+	cur = redis.call("INCR", KEYS[1])
+	redis.call("PEXPIRE", KEYS[1], ARGV[3])
+	return {1, cur, prev}
+end
+return {0, cur, prev}
+`)
+
+// RedisLimiter is a sliding window limiter sharing the counters between all replicas
+// of the server in redis. It allows at most the limit of requests in any window, the
+// count of the previous window is weighted by its overlap with the sliding window.
+type RedisLimiter struct {
+	client    redis.UniversalClient
+	prefix    string
+	window    time.Duration
+	ipLimit   int
+	userLimit int
+	timeout   time.Duration
+}
+
+// NewRedisLimiter creates a RedisLimiter from a redis url like redis://:password@host:6379/0.
+func NewRedisLimiter(url string, prefix string, ipLimit int, userLimit int, window time.Duration) (*RedisLimiter, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redis url: %v", err)
+	}
+	return &RedisLimiter{
+		client:    redis.NewClient(opts),
+		prefix:    prefix,
+		window:    window,
+		ipLimit:   ipLimit,
+		userLimit: userLimit,
+		timeout:   time.Second,
+	}, nil
+}
+
+func (l *RedisLimiter) AllowIP(ip string) (bool, time.Duration) {
+	return l.allow("ip:"+ip, l.ipLimit)
+}
+
+func (l *RedisLimiter) AllowUser(user string) (bool, time.Duration) {
+	return l.allow("user:"+user, l.userLimit)
+}
+
+func (l *RedisLimiter) allow(key string, limit int) (bool, time.Duration) {
+	now := time.Now()
+	index := now.UnixNano() / int64(l.window)
+	elapsed := time.Duration(now.UnixNano() - index*int64(l.window))
+	fraction := float64(elapsed) / float64(l.window)
+
+	ctx, cancel := context.WithTimeout(context.Background(), l.timeout)
+	defer cancel()
+	keys := []string{
+		fmt.Sprintf("%s%s:%d", l.prefix, key, index),
+		fmt.Sprintf("%s%s:%d", l.prefix, key, index-1),
+	}
+	res, err := slidingWindowScript.Run(ctx, l.client, keys, limit, fraction, (2 * l.window).Milliseconds()).Int64Slice()
+	if err != nil || len(res) != 3 {
+		// don't block the registry if redis is unavailable
+		glog.Warningf("Rate limit %s by redis failed, allow the request: %v", key, err)
+		return true, 0
+	}
+	if res[0] == 1 {
+		return true, 0
+	}
+	return false, slidingWindowRetryAfter(l.window, elapsed, limit, res[1], res[2])
+}
+
+// slidingWindowRetryAfter returns the time until the weighted count of the sliding
+// window drops below the limit.
+func slidingWindowRetryAfter(window, elapsed time.Duration, limit int, cur, prev int64) time.Duration {
+	var wait time.Duration
+	if cur >= int64(limit) {
+		// wait for the current window to become the previous one, and then for enough
+		// of it to slide out
+		wait = window - elapsed + time.Duration(float64(window)*(1-float64(limit)/float64(cur)))
+	} else if prev > 0 {
+		wait = time.Duration(float64(window)*(1-float64(int64(limit)-cur)/float64(prev))) - elapsed
+	}
+	if wait < time.Second {
+		wait = time.Second
+	}
+	return wait
+}
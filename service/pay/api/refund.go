@@ -0,0 +1,29 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/labring/sealos/service/pay/helper"
+	"github.com/labring/sealos/service/pay/method"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Refund refunds the full amount of a paid order
+func Refund(c *gin.Context, client *mongo.Client) {
+	request, err := helper.Init(c, client)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("init failed before refund: %v, %v", request, err)})
+		return
+	}
+
+	switch request.PayMethod {
+	case "wechat":
+		method.WechatRefund(c, request, client)
+	case "stripe":
+		method.StripeRefund(c, request, client)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("paymethod is illegal: %v", request.PayMethod)})
+	}
+}
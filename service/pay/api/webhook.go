@@ -0,0 +1,17 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/labring/sealos/service/pay/method"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// StripeWebhook receives the events of stripe, they are authenticated by the signature instead of the app sign
+func StripeWebhook(c *gin.Context, client *mongo.Client) {
+	method.HandleStripeWebhook(c, client)
+}
+
+// WechatWebhook receives the notifications of wechat, they are authenticated by the signature instead of the app sign
+func WechatWebhook(c *gin.Context, client *mongo.Client) {
+	method.HandleWechatWebhook(c, client)
+}
@@ -2,15 +2,21 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/labring/sealos/controllers/pkg/pay"
 	"github.com/labring/sealos/service/pay/helper"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// ErrOrderNotFound is returned when no order has the id of the payment provider,
+// e.g. the payments created by the account controller directly
+var ErrOrderNotFound = errors.New("order not found")
+
 func GetPaymentStatus(client *mongo.Client, orderID string) (string, error) {
 	coll := helper.InitDBAndColl(client, helper.Database, helper.PaymentDetailsColl)
 	filter := bson.D{{Key: "orderID", Value: orderID}}
@@ -107,3 +113,57 @@ func CheckOrderExistOrNot(client *mongo.Client, request *helper.Request) error {
 	// Order and payMethod are valid
 	return nil
 }
+
+func GetPaymentDetails(client *mongo.Client, orderID string) (*helper.PaymentDetails, error) {
+	coll := helper.InitDBAndColl(client, helper.Database, helper.PaymentDetailsColl)
+	filter := bson.D{{Key: "orderID", Value: orderID}}
+	var result helper.PaymentDetails
+	if err := coll.FindOne(context.TODO(), filter).Decode(&result); err != nil {
+		return nil, fmt.Errorf("read data of the collection paymentDetails failed: %v", err)
+	}
+	return &result, nil
+}
+
+// GetOrderByProviderID returns the order with the id of the payment provider, e.g. the sessionID of stripe
+// or the tradeNO of wechat
+func GetOrderByProviderID(client *mongo.Client, payMethod, key, id string) (*helper.OrderDetails, error) {
+	coll := helper.InitDBAndColl(client, helper.Database, helper.OrderDetailsColl)
+	filter := bson.D{
+		{Key: "payMethod", Value: payMethod},
+		{Key: "detailsdata." + key, Value: id},
+	}
+	var result helper.OrderDetails
+	if err := coll.FindOne(context.Background(), filter).Decode(&result); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrOrderNotFound
+		}
+		return nil, fmt.Errorf("failed to query order details: %w", err)
+	}
+	return &result, nil
+}
+
+// ApplyProviderStatus updates the payment status to the one notified by the payment provider,
+// it returns false if the status is not changed.
+func ApplyProviderStatus(client *mongo.Client, orderID, status string) (bool, error) {
+	current, err := GetPaymentStatus(client, orderID)
+	if err != nil {
+		return false, err
+	}
+	if !statusTransitionAllowed(current, status) {
+		return false, nil
+	}
+	if _, err := UpdatePaymentStatus(client, orderID, status); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// statusTransitionAllowed returns whether the payment status can change from current to status.
+// A paid order can only be refunded, and a refunded order is final, so that repeated or out of
+// order notifications don't roll the status back.
+func statusTransitionAllowed(current, status string) bool {
+	if current == status || current == pay.PaymentRefunded {
+		return false
+	}
+	return current != pay.PaymentSuccess || status == pay.PaymentRefunded
+}
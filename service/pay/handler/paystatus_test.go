@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/labring/sealos/controllers/pkg/pay"
+)
+
+func TestStatusTransitionAllowed(t *testing.T) {
+	tests := []struct {
+		current string
+		status  string
+		want    bool
+	}{
+		{pay.PaymentNotPaid, pay.PaymentNotPaid, false},
+		{pay.PaymentNotPaid, pay.PaymentProcessing, true},
+		{pay.PaymentNotPaid, pay.PaymentSuccess, true},
+		{pay.PaymentProcessing, pay.PaymentFailed, true},
+		{pay.PaymentNotPaid, pay.PaymentExpired, true},
+		{pay.PaymentExpired, pay.PaymentSuccess, true},
+		{pay.PaymentSuccess, pay.PaymentSuccess, false},
+		{pay.PaymentSuccess, pay.PaymentExpired, false},
+		{pay.PaymentSuccess, pay.PaymentNotPaid, false},
+		{pay.PaymentSuccess, pay.PaymentRefunded, true},
+		{pay.PaymentRefunded, pay.PaymentSuccess, false},
+		{pay.PaymentRefunded, pay.PaymentNotPaid, false},
+		{pay.PaymentRefunded, pay.PaymentRefunded, false},
+	}
+	for _, tt := range tests {
+		if got := statusTransitionAllowed(tt.current, tt.status); got != tt.want {
+			t.Errorf("statusTransitionAllowed(%s, %s) = %v, want %v", tt.current, tt.status, got, tt.want)
+		}
+	}
+}
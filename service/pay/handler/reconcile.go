@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/labring/sealos/controllers/pkg/pay"
+	"github.com/labring/sealos/service/pay/helper"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// providerIDKeys are the keys of the payment provider ids in the details data of the orders
+var providerIDKeys = map[string]string{
+	helper.Stripe: "sessionID",
+	helper.Wechat: "tradeNO",
+}
+
+// StartReconcile reconciles the orders of the last window every interval until the context is done.
+// Every replica runs it, the records are keyed by the order, so running it repeatedly is harmless.
+func StartReconcile(ctx context.Context, client *mongo.Client, interval, window time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		mismatches, err := Reconcile(client, window)
+		if err != nil {
+			fmt.Println("reconcile orders failed:", err)
+			continue
+		}
+		fmt.Println("reconcile orders finished, mismatches:", mismatches)
+	}
+}
+
+// Reconcile compares the orders created in the last window with the payment providers, and records
+// the ones whose status or amount differ into the reconciliation collection. The orders are not
+// changed, the records are removed once the order matches the provider again.
+// Each order is checked against the payment API of its provider rather than the downloaded daily
+// statements, so the payments missing from the orders collection are not found.
+func Reconcile(client *mongo.Client, window time.Duration) (int, error) {
+	coll := helper.InitDBAndColl(client, helper.Database, helper.OrderDetailsColl)
+	// the pay time is formatted in CST, which sorts in time order
+	since := time.Now().Add(-window).UTC().In(time.FixedZone("CST", 8*60*60)).Format("2006-01-02 15:04:05")
+	filter := bson.D{{Key: "paytime", Value: bson.D{{Key: "$gte", Value: since}}}}
+	cursor, err := coll.Find(context.Background(), filter)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query order details: %w", err)
+	}
+	defer cursor.Close(context.Background())
+
+	mismatches := 0
+	for cursor.Next(context.Background()) {
+		var order helper.OrderDetails
+		if err := cursor.Decode(&order); err != nil {
+			return mismatches, fmt.Errorf("failed to decode order details: %w", err)
+		}
+		record, err := reconcileOrder(client, &order)
+		if err != nil {
+			fmt.Println("reconcile order failed:", order.OrderID, err)
+			continue
+		}
+		if err := saveReconciliation(client, order.OrderID, record); err != nil {
+			return mismatches, err
+		}
+		if record != nil {
+			mismatches++
+		}
+	}
+	return mismatches, cursor.Err()
+}
+
+// reconcileOrder returns the mismatch of the order, or nil if it matches the payment provider
+func reconcileOrder(client *mongo.Client, order *helper.OrderDetails) (*helper.Reconciliation, error) {
+	key, ok := providerIDKeys[order.PayMethod]
+	if !ok {
+		return nil, fmt.Errorf("unsupported payMethod: %s", order.PayMethod)
+	}
+	providerID, ok := order.DetailsData[key].(string)
+	if !ok || providerID == "" {
+		return nil, fmt.Errorf("%s of the order is missing", key)
+	}
+	payment, err := GetPaymentDetails(client, order.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	payHandler, err := pay.NewPayHandler(order.PayMethod)
+	if err != nil {
+		return nil, err
+	}
+	return compareWithProvider(order, payment, payHandler, providerID)
+}
+
+// compareWithProvider compares the payment of the order with the one queried from the payment provider
+func compareWithProvider(order *helper.OrderDetails, payment *helper.PaymentDetails, payHandler pay.Interface, providerID string) (*helper.Reconciliation, error) {
+	providerStatus, providerAmount, err := payHandler.GetPaymentDetails(providerID)
+	if err != nil && providerStatus == "" {
+		// the provider can't be reached, check it next time
+		return nil, fmt.Errorf("get payment details from %s failed: %v", order.PayMethod, err)
+	}
+
+	var reason string
+	switch {
+	case providerStatus == pay.PaymentUnknown:
+		reason = fmt.Sprintf("unknown status of the payment provider: %v", err)
+	case pendingStatus(payment.Status) != pendingStatus(providerStatus):
+		reason = "status mismatch"
+	case providerStatus == pay.PaymentSuccess || providerStatus == pay.PaymentRefunded:
+		if amount, err := strconv.ParseInt(payment.Amount, 10, 64); err != nil || amount != providerAmount {
+			reason = "amount mismatch"
+		}
+	}
+	if reason == "" {
+		return nil, nil
+	}
+	fmt.Printf("order %s mismatches %s: %s, status %s/%s, amount %s/%d\n",
+		order.OrderID, order.PayMethod, reason, payment.Status, providerStatus, payment.Amount, providerAmount)
+	return &helper.Reconciliation{
+		OrderID:        order.OrderID,
+		User:           order.User,
+		PayMethod:      order.PayMethod,
+		AppID:          order.AppID,
+		DBStatus:       payment.Status,
+		ProviderStatus: providerStatus,
+		DBAmount:       payment.Amount,
+		ProviderAmount: providerAmount,
+		Reason:         reason,
+		CheckTime:      time.Now().UTC().In(time.FixedZone("CST", 8*60*60)).Format("2006-01-02 15:04:05"),
+	}, nil
+}
+
+// pendingStatus treats the unpaid statuses as the same, the database records an open stripe session
+// as not paid while the provider reports it as processing
+func pendingStatus(status string) string {
+	if status == pay.PaymentProcessing {
+		return pay.PaymentNotPaid
+	}
+	return status
+}
+
+// saveReconciliation records the mismatch of the order, or removes the record if it's nil
+func saveReconciliation(client *mongo.Client, orderID string, record *helper.Reconciliation) error {
+	coll := helper.InitDBAndColl(client, helper.Database, helper.ReconciliationColl)
+	filter := bson.D{{Key: "orderID", Value: orderID}}
+	if record == nil {
+		if _, err := coll.DeleteOne(context.Background(), filter); err != nil {
+			return fmt.Errorf("delete reconciliation failed: %w", err)
+		}
+		return nil
+	}
+	update := bson.D{{Key: "$set", Value: record}}
+	if _, err := coll.UpdateOne(context.Background(), filter, update, options.Update().SetUpsert(true)); err != nil {
+		return fmt.Errorf("save reconciliation failed: %w", err)
+	}
+	return nil
+}
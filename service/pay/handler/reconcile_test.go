@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/labring/sealos/controllers/pkg/pay"
+	"github.com/labring/sealos/service/pay/helper"
+)
+
+// fakeProvider returns the fixed payment details of the provider
+type fakeProvider struct {
+	pay.Interface
+	status string
+	amount int64
+	err    error
+	id     string
+}
+
+func (f *fakeProvider) GetPaymentDetails(id string) (string, int64, error) {
+	f.id = id
+	return f.status, f.amount, f.err
+}
+
+func TestCompareWithProvider(t *testing.T) {
+	order := &helper.OrderDetails{OrderID: "order", User: "user", PayMethod: helper.Stripe, AppID: 1}
+	tests := []struct {
+		name     string
+		payment  helper.PaymentDetails
+		provider fakeProvider
+		reason   string
+		wantErr  bool
+	}{
+		{
+			name:     "paid",
+			payment:  helper.PaymentDetails{Status: pay.PaymentSuccess, Amount: "100"},
+			provider: fakeProvider{status: pay.PaymentSuccess, amount: 100},
+		},
+		{
+			name:     "open session",
+			payment:  helper.PaymentDetails{Status: pay.PaymentNotPaid, Amount: "100"},
+			provider: fakeProvider{status: pay.PaymentProcessing},
+		},
+		{
+			name:     "paid but not recorded",
+			payment:  helper.PaymentDetails{Status: pay.PaymentNotPaid, Amount: "100"},
+			provider: fakeProvider{status: pay.PaymentSuccess, amount: 100},
+			reason:   "status mismatch",
+		},
+		{
+			name:     "refunded but recorded as paid",
+			payment:  helper.PaymentDetails{Status: pay.PaymentSuccess, Amount: "100"},
+			provider: fakeProvider{status: pay.PaymentRefunded, amount: 100},
+			reason:   "status mismatch",
+		},
+		{
+			name:     "amount differs",
+			payment:  helper.PaymentDetails{Status: pay.PaymentSuccess, Amount: "100"},
+			provider: fakeProvider{status: pay.PaymentSuccess, amount: 90},
+			reason:   "amount mismatch",
+		},
+		{
+			name:     "malformed amount",
+			payment:  helper.PaymentDetails{Status: pay.PaymentRefunded, Amount: "1.00"},
+			provider: fakeProvider{status: pay.PaymentRefunded, amount: 100},
+			reason:   "amount mismatch",
+		},
+		{
+			name:     "unknown status",
+			payment:  helper.PaymentDetails{Status: pay.PaymentNotPaid, Amount: "100"},
+			provider: fakeProvider{status: pay.PaymentUnknown, err: errors.New("unexpected status")},
+			reason:   "unknown status of the payment provider: unexpected status",
+		},
+		{
+			name:     "provider unreachable",
+			payment:  helper.PaymentDetails{Status: pay.PaymentNotPaid, Amount: "100"},
+			provider: fakeProvider{err: errors.New("timeout")},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record, err := compareWithProvider(order, &tt.payment, &tt.provider, "session")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expect error %v, got %v", tt.wantErr, err)
+			}
+			if tt.provider.id != "session" {
+				t.Errorf("expect the provider to be queried with session, got %q", tt.provider.id)
+			}
+			if tt.reason == "" {
+				if record != nil {
+					t.Errorf("expect no mismatch, got %+v", record)
+				}
+				return
+			}
+			if record == nil {
+				t.Fatalf("expect mismatch %q, got none", tt.reason)
+			}
+			if record.Reason != tt.reason {
+				t.Errorf("expect reason %q, got %q", tt.reason, record.Reason)
+			}
+			if record.OrderID != order.OrderID || record.DBStatus != tt.payment.Status ||
+				record.ProviderStatus != tt.provider.status || record.ProviderAmount != tt.provider.amount {
+				t.Errorf("unexpected record %+v", record)
+			}
+		})
+	}
+}
+
+func TestReconcileOrderMissingProviderID(t *testing.T) {
+	tests := []*helper.OrderDetails{
+		{OrderID: "order", PayMethod: "alipay"},
+		{OrderID: "order", PayMethod: helper.Stripe, DetailsData: map[string]interface{}{}},
+		{OrderID: "order", PayMethod: helper.Wechat, DetailsData: map[string]interface{}{"tradeNO": ""}},
+	}
+	for _, order := range tests {
+		// the order is rejected before the database is queried
+		if _, err := reconcileOrder(nil, order); err == nil {
+			t.Errorf("expect error for order %+v, got nil", order)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/labring/sealos/service/pay/helper"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func InsertRefundDetails(client *mongo.Client, orderID, refundID, amount, reason, payMethod string) error {
+	coll := helper.InitDBAndColl(client, helper.Database, helper.RefundDetailsColl)
+	// switched to the Chinese time zone and optimized the format
+	refundTime := time.Now().UTC().In(time.FixedZone("CST", 8*60*60)).Format("2006-01-02 15:04:05")
+	doc := helper.RefundDetails{
+		OrderID:    orderID,
+		RefundID:   refundID,
+		Amount:     amount,
+		Reason:     reason,
+		PayMethod:  payMethod,
+		RefundTime: refundTime,
+	}
+	result, err := coll.InsertOne(context.TODO(), doc)
+	if err != nil {
+		return fmt.Errorf("insert the data of refund details failed: %w", err)
+	}
+	fmt.Println("insert the data of refund details successfully:", result)
+	return nil
+}
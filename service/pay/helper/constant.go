@@ -1,5 +1,7 @@
 package helper
 
+import "time"
+
 // DB
 const (
 	DBURI = "dburi"
@@ -9,6 +11,8 @@ const (
 	PayMethodColl      = "paymethod"
 	PaymentDetailsColl = "paymentdetails"
 	OrderDetailsColl   = "orderdetails"
+	RefundDetailsColl  = "refunddetails"
+	ReconciliationColl = "reconciliation"
 )
 
 // Paymethod
@@ -35,6 +39,16 @@ const (
 	StripeCurrency       = "STRIPE_CURRENCY"
 )
 
+// Reconciliation
+const (
+	// ReconcileInterval is how often the orders are reconciled with the payment providers, 0 disables it
+	ReconcileInterval        = "RECONCILE_INTERVAL"
+	DefaultReconcileInterval = 24 * time.Hour
+	// ReconcileWindow is how far back the orders are reconciled
+	ReconcileWindow        = "RECONCILE_WINDOW"
+	DefaultReconcileWindow = 72 * time.Hour
+)
+
 // Test
 const (
 	LOCALHOST       = "http://localhost:2303"
@@ -46,6 +60,9 @@ const (
 	GetSession      = "/session"
 	GetPayStatus    = "/status"
 	GetBill         = "/bill"
+	Refund          = "/refund"
+	StripeWebhook   = "/webhook/stripe"
+	WechatWebhook   = "/webhook/wechat"
 	TestAppID       = 66683568733697785
 	TestSign        = "597d7f10a27219"
 	TestUser        = "xy"
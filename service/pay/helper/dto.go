@@ -14,4 +14,5 @@ type Request struct {
 	TradeNO       string   `json:"tradeNO,omitempty"`
 	SessionID     string   `json:"sessionID,omitempty"`
 	OrderID       string   `json:"orderID,omitempty"`
+	Reason        string   `json:"reason,omitempty"`
 }
@@ -44,3 +44,26 @@ type PayMethodDetail struct {
 	ExchangeRate  float64  `bson:"exchangeRate"`
 	TaxRate       float64  `bson:"taxRate"`
 }
+
+type RefundDetails struct {
+	OrderID    string `bson:"orderID"`
+	RefundID   string `bson:"refundID"`
+	Amount     string `bson:"amount"`
+	Reason     string `bson:"reason"`
+	PayMethod  string `bson:"payMethod"`
+	RefundTime string `bson:"refundTime"`
+}
+
+// Reconciliation is an order whose status or amount in the database differs from the payment provider
+type Reconciliation struct {
+	OrderID        string `bson:"orderID"`
+	User           string `bson:"user"`
+	PayMethod      string `bson:"payMethod"`
+	AppID          int64  `bson:"appID"`
+	DBStatus       string `bson:"dbStatus"`
+	ProviderStatus string `bson:"providerStatus"`
+	DBAmount       string `bson:"dbAmount"`
+	ProviderAmount int64  `bson:"providerAmount"`
+	Reason         string `bson:"reason"`
+	CheckTime      string `bson:"checkTime"`
+}
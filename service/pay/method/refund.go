@@ -0,0 +1,66 @@
+package method
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/labring/sealos/controllers/pkg/pay"
+	"github.com/labring/sealos/service/pay/handler"
+	"github.com/labring/sealos/service/pay/helper"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// refundOrder refunds the full amount of a paid order, providerID is the id of the order in the payment provider
+func refundOrder(c *gin.Context, request *helper.Request, client *mongo.Client, payMethod, providerID string) {
+	// Firstly, check whether the order exists in the order Details, if not, directly return
+	if err := handler.CheckOrderExistOrNot(client, request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("order does not exist: %v", err)})
+		return
+	}
+	payment, err := handler.GetPaymentDetails(client, request.OrderID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("get payment details failed from db: %v", err)})
+		return
+	}
+	if payment.Status != pay.PaymentSuccess {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("only paid orders can be refunded, payment status is: %s", payment.Status)})
+		return
+	}
+	amount, err := strconv.ParseInt(payment.Amount, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("error amount : %s, %v", payment.Amount, err)})
+		return
+	}
+
+	payHandler, err := pay.NewPayHandler(payMethod)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("get payment handler failed: %v", err)})
+		return
+	}
+	refundID, err := payHandler.Refund(providerID, amount, request.Reason)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("refund %s order failed: %s, %v", payMethod, refundID, err)})
+		return
+	}
+
+	// the refund has been made, a failure below is found by the reconciliation
+	if _, err := handler.UpdatePaymentStatus(client, request.OrderID, pay.PaymentRefunded); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("update payment status failed after refund %s: %v", refundID, err),
+		})
+		return
+	}
+	if err := handler.InsertRefundDetails(client, request.OrderID, refundID, payment.Amount, request.Reason, payMethod); err != nil {
+		fmt.Println("insert refund details failed:", refundID, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "payment has been refunded, database has been updated",
+		"status":   pay.PaymentRefunded,
+		"orderID":  request.OrderID,
+		"refundID": refundID,
+		"amount":   payment.Amount,
+	})
+}
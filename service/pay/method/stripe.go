@@ -1,7 +1,10 @@
 package method
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"strconv"
@@ -129,3 +132,75 @@ func GetStripePaymentStatus(c *gin.Context, request *helper.Request, client *mon
 		handler.UpdateDBIfDiff(c, request.OrderID, client, status, pay.PaymentUnknown)
 	}
 }
+
+func StripeRefund(c *gin.Context, request *helper.Request, client *mongo.Client) {
+	refundOrder(c, request, client, helper.Stripe, request.SessionID)
+}
+
+// HandleStripeWebhook verifies the event sent by stripe and updates the status of the order
+func HandleStripeWebhook(c *gin.Context, client *mongo.Client) {
+	payload, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxWebhookBodySize))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("read stripe event failed: %v", err)})
+		return
+	}
+	event, err := pay.ConstructWebhookEvent(payload, c.GetHeader("Stripe-Signature"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("verify stripe event failed: %v", err)})
+		return
+	}
+
+	var sessionID, status string
+	switch event.Type {
+	case "checkout.session.completed", "checkout.session.async_payment_succeeded",
+		"checkout.session.async_payment_failed", "checkout.session.expired":
+		var session stripe.CheckoutSession
+		if err := json.Unmarshal(event.Data.Raw, &session); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("parse stripe session failed: %v", err)})
+			return
+		}
+		sessionID = session.ID
+		switch {
+		case event.Type == "checkout.session.expired":
+			status = pay.PaymentExpired
+		case event.Type == "checkout.session.async_payment_failed":
+			status = pay.PaymentFailed
+		case session.PaymentStatus == stripe.CheckoutSessionPaymentStatusPaid:
+			status = pay.PaymentSuccess
+		default:
+			// the payment method is asynchronous, wait for the async_payment events
+			status = pay.PaymentProcessing
+		}
+	case "charge.refunded":
+		var charge stripe.Charge
+		if err := json.Unmarshal(event.Data.Raw, &charge); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("parse stripe charge failed: %v", err)})
+			return
+		}
+		// only full refunds change the status of the order
+		if !charge.Refunded || charge.PaymentIntent == nil {
+			c.JSON(http.StatusOK, gin.H{"message": "stripe event is ignored"})
+			return
+		}
+		session, err := pay.GetSessionByPaymentIntent(charge.PaymentIntent.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("get stripe session of the refund failed: %v", err)})
+			return
+		}
+		sessionID, status = session.ID, pay.PaymentRefunded
+	default:
+		c.JSON(http.StatusOK, gin.H{"message": "stripe event is ignored"})
+		return
+	}
+
+	if err := applyWebhookStatus(client, helper.Stripe, "sessionID", sessionID, status); err != nil {
+		if errors.Is(err, handler.ErrOrderNotFound) {
+			c.JSON(http.StatusOK, gin.H{"message": "stripe event is ignored"})
+			return
+		}
+		// stripe retries the event
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "stripe event is handled"})
+}
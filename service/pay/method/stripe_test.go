@@ -0,0 +1,82 @@
+package method
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/labring/sealos/controllers/pkg/pay"
+	"github.com/stripe/stripe-go/v74"
+	"github.com/stripe/stripe-go/v74/webhook"
+)
+
+const testWebhookSecret = "whsec_test"
+
+func stripeEvent(t *testing.T, eventType string, object interface{}) []byte {
+	data, err := json.Marshal(object)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := json.Marshal(map[string]interface{}{
+		"id":          "evt_test",
+		"object":      "event",
+		"type":        eventType,
+		"api_version": stripe.APIVersion,
+		"data":        map[string]json.RawMessage{"object": data},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return payload
+}
+
+func TestHandleStripeWebhook(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv(pay.StripeWebhookSecret, testWebhookSecret)
+
+	ignored := stripeEvent(t, "customer.created", map[string]interface{}{"id": "cus_test"})
+	partialRefund := stripeEvent(t, "charge.refunded", map[string]interface{}{"id": "ch_test", "refunded": false})
+	sign := func(payload []byte, secret string) string {
+		return webhook.GenerateTestSignedPayload(&webhook.UnsignedPayload{
+			Payload:   payload,
+			Secret:    secret,
+			Timestamp: time.Now(),
+		}).Header
+	}
+	tests := []struct {
+		name      string
+		payload   []byte
+		signature string
+		code      int
+		message   string
+	}{
+		{"missing signature", ignored, "", http.StatusBadRequest, "verify stripe event failed"},
+		{"wrong secret", ignored, sign(ignored, "whsec_other"), http.StatusBadRequest, "verify stripe event failed"},
+		{"tampered payload", bytes.Replace(ignored, []byte("cus_test"), []byte("cus_evil"), 1), sign(ignored, testWebhookSecret), http.StatusBadRequest, "verify stripe event failed"},
+		{"ignored event", ignored, sign(ignored, testWebhookSecret), http.StatusOK, "stripe event is ignored"},
+		{"partial refund", partialRefund, sign(partialRefund, testWebhookSecret), http.StatusOK, "stripe event is ignored"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/stripe/webhook", bytes.NewReader(tt.payload))
+			if tt.signature != "" {
+				c.Request.Header.Set("Stripe-Signature", tt.signature)
+			}
+			// the verified events above don't reach the database
+			HandleStripeWebhook(c, nil)
+			if w.Code != tt.code {
+				t.Errorf("expect code %d, got %d: %s", tt.code, w.Code, w.Body.String())
+			}
+			if !strings.Contains(w.Body.String(), tt.message) {
+				t.Errorf("expect body to contain %q, got %s", tt.message, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package method
+
+import (
+	"fmt"
+
+	"github.com/labring/sealos/service/pay/handler"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// maxWebhookBodySize limits the size of the notifications read from the payment providers
+const maxWebhookBodySize = 64 << 10
+
+// applyWebhookStatus updates the status of the order notified by the payment provider,
+// key and id are the key and the value of the provider id in the order details
+func applyWebhookStatus(client *mongo.Client, payMethod, key, id, status string) error {
+	order, err := handler.GetOrderByProviderID(client, payMethod, key, id)
+	if err != nil {
+		return fmt.Errorf("get order of %s %s failed: %w", key, id, err)
+	}
+	changed, err := handler.ApplyProviderStatus(client, order.OrderID, status)
+	if err != nil {
+		return fmt.Errorf("update payment status of order %s failed: %v", order.OrderID, err)
+	}
+	if changed {
+		fmt.Printf("%s notified order %s is %s, database has been updated\n", payMethod, order.OrderID, status)
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package method
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -123,3 +124,54 @@ func GetWechatPaymentStatus(c *gin.Context, request *helper.Request, client *mon
 		handler.UpdateDBIfDiff(c, request.OrderID, client, status, pay.PaymentUnknown)
 	}
 }
+
+func WechatRefund(c *gin.Context, request *helper.Request, client *mongo.Client) {
+	refundOrder(c, request, client, helper.Wechat, request.TradeNO)
+}
+
+// wechatNotifyResource is the decrypted resource of the payment and refund notifications of wechat
+type wechatNotifyResource struct {
+	OutTradeNo   string `json:"out_trade_no"`
+	TradeState   string `json:"trade_state"`
+	RefundStatus string `json:"refund_status"`
+}
+
+// wechatTradeStatus maps the trade states of wechat to the payment status
+var wechatTradeStatus = map[string]string{
+	pay.StatusSuccess: pay.PaymentSuccess,
+	pay.StatusRefund:  pay.PaymentRefunded,
+	pay.StatusClosed:  pay.PaymentExpired,
+	pay.StatusNotPay:  pay.PaymentNotPaid,
+	"PAYERROR":        pay.PaymentFailed,
+}
+
+// HandleWechatWebhook verifies the notification sent by wechat and updates the status of the order,
+// wechat retries the notification unless it's answered with 200.
+func HandleWechatWebhook(c *gin.Context, client *mongo.Client) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxWebhookBodySize)
+	resource := &wechatNotifyResource{}
+	notifyReq, err := pay.ParseNotify(c.Request, resource)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": "FAIL", "message": fmt.Sprintf("verify wechat notification failed: %v", err)})
+		return
+	}
+
+	status, ok := wechatTradeStatus[resource.TradeState]
+	if resource.TradeState == "" && resource.RefundStatus == pay.StatusSuccess {
+		status, ok = pay.PaymentRefunded, true
+	}
+	if !ok || resource.OutTradeNo == "" {
+		fmt.Println("wechat notification is ignored:", notifyReq.EventType, resource.TradeState, resource.RefundStatus)
+		c.JSON(http.StatusOK, gin.H{"code": "SUCCESS", "message": "ignored"})
+		return
+	}
+	if err := applyWebhookStatus(client, helper.Wechat, "tradeNO", resource.OutTradeNo, status); err != nil {
+		if errors.Is(err, handler.ErrOrderNotFound) {
+			c.JSON(http.StatusOK, gin.H{"code": "SUCCESS", "message": "ignored"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"code": "FAIL", "message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": "SUCCESS", "message": "OK"})
+}
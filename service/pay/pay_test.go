@@ -163,6 +163,32 @@ func TestGetBill(t *testing.T) {
 	createPayTest(t, data, http.MethodGet, helper.GetBill)
 }
 
+func TestRefund_Stripe(t *testing.T) {
+	data := map[string]interface{}{
+		"appID":     helper.TestAppID,
+		"sign":      helper.TestSign,
+		"payMethod": "stripe",
+		"orderID":   helper.TestOrderID,
+		"user":      helper.TestUser,
+		"sessionID": helper.TestSessionID,
+		"reason":    "test refund",
+	}
+	createPayTest(t, data, http.MethodPost, helper.Refund)
+}
+
+func TestRefund_Wechat(t *testing.T) {
+	data := map[string]interface{}{
+		"appID":     helper.TestAppID,
+		"sign":      helper.TestSign,
+		"payMethod": "wechat",
+		"orderID":   helper.TestOrderID,
+		"user":      helper.TestUser,
+		"tradeNO":   helper.TestTradeNO,
+		"reason":    "test refund",
+	}
+	createPayTest(t, data, http.MethodPost, helper.Refund)
+}
+
 func createPayTest(t *testing.T, data map[string]interface{}, httpMethod string, url string) {
 	// Create request body data
 	jsonData, err := json.Marshal(data)
@@ -7,9 +7,11 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/labring/sealos/service/pay/api"
+	"github.com/labring/sealos/service/pay/handler"
 	"github.com/labring/sealos/service/pay/helper"
 )
 
@@ -40,6 +42,23 @@ func RegisterPayRouter() {
 		payGroup.GET(helper.GetBill, func(c *gin.Context) {
 			api.GetBill(c, client)
 		})
+		payGroup.POST(helper.Refund, func(c *gin.Context) {
+			api.Refund(c, client)
+		})
+		payGroup.POST(helper.StripeWebhook, func(c *gin.Context) {
+			api.StripeWebhook(c, client)
+		})
+		payGroup.POST(helper.WechatWebhook, func(c *gin.Context) {
+			api.WechatWebhook(c, client)
+		})
+	}
+
+	// Reconcile the orders with the payment providers in the background
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	interval := getDurationEnv(helper.ReconcileInterval, helper.DefaultReconcileInterval)
+	if interval > 0 {
+		go handler.StartReconcile(ctx, client, interval, getDurationEnv(helper.ReconcileWindow, helper.DefaultReconcileWindow))
 	}
 
 	// Create a buffered channel interrupt and use the signal.
@@ -60,6 +79,7 @@ func RegisterPayRouter() {
 	<-interrupt
 
 	fmt.Println("pay service is shutting down")
+	cancel()
 	// disconnect the MongoDB client
 	if err := client.Disconnect(context.Background()); err != nil {
 		log.Fatalf("Error disconnecting client: %v", err)
@@ -68,3 +88,16 @@ func RegisterPayRouter() {
 	// terminate procedure
 	os.Exit(0)
 }
+
+func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return defaultValue
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		fmt.Printf("invalid %s: %v, use the default %s\n", key, err, defaultValue)
+		return defaultValue
+	}
+	return d
+}
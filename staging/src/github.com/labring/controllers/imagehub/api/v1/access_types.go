@@ -0,0 +1,116 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RepoRole is the role of a member on repositories.
+// +kubebuilder:validation:Enum=reader;writer;admin
+type RepoRole string
+
+const (
+	// RoleReader can pull images.
+	RoleReader RepoRole = "reader"
+	// RoleWriter can pull and push images.
+	RoleWriter RepoRole = "writer"
+	// RoleAdmin can pull, push and delete images.
+	RoleAdmin RepoRole = "admin"
+)
+
+var roleLevels = map[RepoRole]int{
+	RoleReader: 1,
+	RoleWriter: 2,
+	RoleAdmin:  3,
+}
+
+// IsValid checks the role is one of reader, writer and admin.
+func (r RepoRole) IsValid() bool {
+	_, ok := roleLevels[r]
+	return ok
+}
+
+// Includes returns whether the role grants all the permissions of other.
+func (r RepoRole) Includes(other RepoRole) bool {
+	return roleLevels[r] >= roleLevels[other]
+}
+
+// Member grants a user a role on the repositories of an organization, or on a single
+// repository if it's set on the repository.
+type Member struct {
+	//+kubebuilder:validation:Required
+	Name string   `json:"name"`
+	Role RepoRole `json:"role"`
+}
+
+// Robot is a machine account of an organization, e.g. for CI. It logs in with the user
+// name robot$<org>+<name> and a token whose sha256 hash is stored in TokenHash.
+type Robot struct {
+	//+kubebuilder:validation:Required
+	Name string `json:"name"`
+	// Repos are the repository names in the organization the robot can access, e.g. "mysql",
+	// "*" means all repositories of the organization.
+	Repos []string `json:"repos,omitempty"`
+	Role  RepoRole `json:"role"`
+	// TokenHash is the hex encoded sha256 hash of the token.
+	TokenHash string `json:"tokenHash"`
+	// ExpiresAt is the time after which the token is no longer accepted, never expires if nil.
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+}
+
+// Expired returns whether the token of the robot is expired at now.
+func (r *Robot) Expired(now metav1.Time) bool {
+	return r.ExpiresAt != nil && !now.Before(r.ExpiresAt)
+}
+
+// CanAccess returns whether the robot is scoped to the repository.
+func (r *Robot) CanAccess(repo string) bool {
+	for _, rp := range r.Repos {
+		if rp == "*" || rp == repo {
+			return true
+		}
+	}
+	return false
+}
+
+const robotAccountPrefix = "robot$"
+
+// RobotAccount returns the account name of a robot of the organization.
+func RobotAccount(org, name string) string {
+	return robotAccountPrefix + org + "+" + name
+}
+
+// ParseRobotAccount parses an account name like robot$<org>+<name>.
+func ParseRobotAccount(account string) (org, name string, ok bool) {
+	if !strings.HasPrefix(account, robotAccountPrefix) {
+		return "", "", false
+	}
+	org, name, ok = strings.Cut(strings.TrimPrefix(account, robotAccountPrefix), "+")
+	if !ok || org == "" || name == "" {
+		return "", "", false
+	}
+	return org, name, true
+}
+
+// HashRobotToken returns the value of Robot.TokenHash for the token.
+func HashRobotToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -36,6 +36,10 @@ type OrganizationSpec struct {
 	Creator string `json:"creator,omitempty"`
 	// Manager can update org and org's repo/image
 	Manager []string `json:"manager,omitempty"`
+	// Members have their role on all repositories of the org
+	Members []Member `json:"members,omitempty"`
+	// Robots are machine accounts scoped to some repositories of the org
+	Robots []Robot `json:"robots,omitempty"`
 }
 
 type OrgName string
@@ -35,6 +35,8 @@ type RepositorySpec struct {
 	Name RepoName `json:"name"` // e.g: "libring/mysql"
 	//+kubebuilder:default:=false
 	IsPrivate bool `json:"isPrivate"`
+	// Members have their role on this repository in addition to the org members
+	Members []Member `json:"members,omitempty"`
 }
 
 type RepoName string
@@ -0,0 +1,115 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+// Cache keeps the results of lookups for a while, a failed lookup is a negative entry which
+// usually expires sooner than a successful one.
+type Cache struct {
+	mu          sync.Mutex
+	ttl         time.Duration
+	negativeTTL time.Duration
+	entries     map[string]entry
+	// evictAt is when Set removes the expired entries next time
+	evictAt time.Time
+	now     func() time.Time
+}
+
+type entry struct {
+	value    interface{}
+	err      error
+	expireAt time.Time
+}
+
+// New returns a cache, entries are not cached if the ttl is not positive.
+func New(ttl, negativeTTL time.Duration) *Cache {
+	return &Cache{
+		ttl:         ttl,
+		negativeTTL: negativeTTL,
+		entries:     make(map[string]entry),
+		now:         time.Now,
+	}
+}
+
+// Get returns the value and the error of the lookup, ok is false if not found or expired.
+func (c *Cache) Get(key string) (value interface{}, err error, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, nil, false
+	}
+	if !c.now().Before(e.expireAt) {
+		delete(c.entries, key)
+		return nil, nil, false
+	}
+	return e.value, e.err, true
+}
+
+// Set saves the result of the lookup, it's a negative entry if err is not nil.
+func (c *Cache) Set(key string, value interface{}, err error) {
+	ttl := c.ttl
+	if err != nil {
+		ttl = c.negativeTTL
+	}
+	if ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	// Get only removes the entries looked up again, so the expired ones are swept once
+	// in a while to keep the images that are never pulled again from piling up.
+	if !now.Before(c.evictAt) {
+		c.evict(now)
+		c.evictAt = now.Add(c.evictInterval())
+	}
+	c.entries[key] = entry{value: value, err: err, expireAt: now.Add(ttl)}
+}
+
+// evictInterval is the longer of the ttls, entries are kept at most twice as long.
+func (c *Cache) evictInterval() time.Duration {
+	if c.negativeTTL > c.ttl {
+		return c.negativeTTL
+	}
+	return c.ttl
+}
+
+// Len returns the number of entries, including the expired ones not evicted yet.
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// Purge removes all the entries.
+func (c *Cache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]entry)
+}
+
+// evict removes the expired entries.
+func (c *Cache) evict(now time.Time) {
+	for key, e := range c.entries {
+		if !now.Before(e.expireAt) {
+			delete(c.entries, key)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	now := time.Now()
+	c := New(time.Minute, 10*time.Second)
+	c.now = func() time.Time { return now }
+
+	c.Set("found", "sealos.hub:5000/library/nginx:1.25", nil)
+	c.Set("missing", nil, errors.New("not found"))
+	if v, err, ok := c.Get("found"); !ok || err != nil || v != "sealos.hub:5000/library/nginx:1.25" {
+		t.Errorf("Get(found) = %v, %v, %v", v, err, ok)
+	}
+	if _, err, ok := c.Get("missing"); !ok || err == nil {
+		t.Errorf("Get(missing) = %v, %v, want a negative entry", err, ok)
+	}
+	if _, _, ok := c.Get("unknown"); ok {
+		t.Errorf("Get(unknown) is cached")
+	}
+
+	now = now.Add(30 * time.Second)
+	if _, _, ok := c.Get("missing"); ok {
+		t.Errorf("negative entry is not expired after its ttl")
+	}
+	if _, _, ok := c.Get("found"); !ok {
+		t.Errorf("entry is expired before its ttl")
+	}
+
+	// the expired entries are swept by the first Set after the longer ttl
+	c.Set("other", "busybox", nil)
+	if c.Len() != 2 {
+		t.Errorf("Len() = %d before the sweep, want 2", c.Len())
+	}
+	now = now.Add(45 * time.Second)
+	c.Set("new", "busybox", nil)
+	if c.Len() != 2 {
+		t.Errorf("Len() = %d after the sweep, want 2", c.Len())
+	}
+	if _, _, ok := c.Get("other"); !ok {
+		t.Errorf("entry is evicted before its ttl")
+	}
+
+	c.Set("found", "nginx", nil)
+	c.Purge()
+	if _, _, ok := c.Get("found"); ok {
+		t.Errorf("entry is found after purging")
+	}
+
+	disabled := New(0, 0)
+	disabled.Set("found", "nginx", nil)
+	if disabled.Len() != 0 {
+		t.Errorf("entry is cached with zero ttl")
+	}
+}
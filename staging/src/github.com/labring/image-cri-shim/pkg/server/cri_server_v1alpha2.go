@@ -29,9 +29,8 @@ import (
 )
 
 type v1alpha2ImageService struct {
-	imageClient       api.ImageServiceClient
-	CRIConfigs        map[string]types.AuthConfig
-	OfflineCRIConfigs map[string]types.AuthConfig
+	imageClient api.ImageServiceClient
+	resolver    *imageResolver
 }
 
 func ToV1Alpha2AuthConfig(c *types.AuthConfig) *api.AuthConfig {
@@ -64,7 +63,7 @@ func (s *v1alpha2ImageService) ImageStatus(ctx context.Context,
 		if id, _ := s.GetImageRefByID(ctx, req.Image.Image); id != "" {
 			req.Image.Image = id
 		} else {
-			req.Image.Image, _, _ = replaceImage(req.Image.Image, "ImageStatus", s.OfflineCRIConfigs)
+			req.Image.Image = s.resolver.ResolveName(req.Image.Image, "ImageStatus")
 		}
 	}
 	rsp, err := s.imageClient.ImageStatus(ctx, req)
@@ -83,13 +82,16 @@ func (s *v1alpha2ImageService) PullImage(ctx context.Context,
 	//2. sealos login remote registry
 	//3. kubernetes secret
 	if req.Image != nil {
-		imageName, ok, auth := replaceImage(req.Image.Image, "PullImage", s.OfflineCRIConfigs)
-		if ok {
+		imageName, auth, err := s.resolver.Resolve(req.Image.Image, "PullImage")
+		if err != nil {
+			return nil, err
+		}
+		if auth != nil {
 			req.Auth = ToV1Alpha2AuthConfig(auth)
 		} else {
 			if req.Auth == nil {
 				ref, _ := name.ParseReference(imageName)
-				if v, ok := s.CRIConfigs[ref.Context().RegistryStr()]; ok {
+				if v, ok := s.resolver.criAuthConfig(ref.Context().RegistryStr()); ok {
 					req.Auth = ToV1Alpha2AuthConfig(&v)
 				}
 			}
@@ -112,7 +114,7 @@ func (s *v1alpha2ImageService) RemoveImage(ctx context.Context,
 		if id, _ := s.GetImageRefByID(ctx, req.Image.Image); id != "" {
 			req.Image.Image = id
 		} else {
-			req.Image.Image, _, _ = replaceImage(req.Image.Image, "RemoveImage", s.OfflineCRIConfigs)
+			req.Image.Image = s.resolver.ResolveName(req.Image.Image, "RemoveImage")
 		}
 	}
 	rsp, err := s.imageClient.RemoveImage(ctx, req)
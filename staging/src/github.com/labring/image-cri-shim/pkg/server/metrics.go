@@ -0,0 +1,110 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/labring/sealos/pkg/utils/logger"
+)
+
+const (
+	metricsNamespace = "image_cri_shim"
+
+	cacheResultHit         = "hit"
+	cacheResultNegativeHit = "negative_hit"
+	cacheResultMiss        = "miss"
+
+	lookupResultSuccess = "success"
+	lookupResultFailure = "failure"
+)
+
+var (
+	metricsRegistry = prometheus.NewRegistry()
+
+	cacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "cache_requests_total",
+		Help:      "Number of manifest lookups served by the cache, by hit, negative_hit or miss.",
+	}, []string{"result"})
+
+	cacheEntries = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "cache_entries",
+		Help:      "Number of entries in the manifest cache.",
+	})
+
+	manifestLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "manifest_lookups_total",
+		Help:      "Number of manifest lookups sent to the registries, by success or failure.",
+	}, []string{"result"})
+
+	manifestLookupDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "manifest_lookup_duration_seconds",
+		Help:      "Latency of manifest lookups sent to the registries.",
+		Buckets:   prometheus.DefBuckets,
+	})
+
+	configReloads = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "config_reloads_total",
+		Help:      "Number of config reloads, by success or failure.",
+	}, []string{"result"})
+)
+
+func init() {
+	metricsRegistry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		cacheRequests,
+		cacheEntries,
+		manifestLookups,
+		manifestLookupDuration,
+		configReloads,
+	)
+}
+
+// RecordReload counts the result of a config reload.
+func RecordReload(err error) {
+	if err != nil {
+		configReloads.WithLabelValues(lookupResultFailure).Inc()
+		return
+	}
+	configReloads.WithLabelValues(lookupResultSuccess).Inc()
+}
+
+// ServeMetrics serves the prometheus metrics on /metrics of the address until it fails.
+func ServeMetrics(address string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(metricsRegistry, promhttp.HandlerOpts{}))
+	srv := &http.Server{
+		Addr:              address,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	logger.Info("serving metrics on %s", address)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -29,6 +29,8 @@ import (
 	k8sv1api "k8s.io/cri-api/pkg/apis/runtime/v1"
 	k8sv1alpha2api "k8s.io/cri-api/pkg/apis/runtime/v1alpha2"
 
+	"github.com/labring/image-cri-shim/pkg/types"
+
 	"github.com/labring/sealos/pkg/utils/logger"
 	netutil "github.com/labring/sealos/pkg/utils/net"
 )
@@ -46,6 +48,11 @@ type Options struct {
 	//CRIConfigs is cri config for auth
 	CRIConfigs        map[string]dockertype.AuthConfig
 	OfflineCRIConfigs map[string]dockertype.AuthConfig
+	// Rules decides how the images are pulled
+	Rules *types.ImageRules
+	// CacheTTL and CacheNegativeTTL are the ttl of manifest lookups, not cached if zero.
+	CacheTTL         time.Duration
+	CacheNegativeTTL time.Duration
 }
 
 type Server interface {
@@ -58,6 +65,9 @@ type Server interface {
 	Start() error
 
 	Stop()
+
+	// Reload updates the auth, rules and cache of the image service.
+	Reload(opts Options)
 }
 
 type server struct {
@@ -65,6 +75,7 @@ type server struct {
 	imageV1Alpha2Client k8sv1alpha2api.ImageServiceClient
 	imageV1Client       k8sv1api.ImageServiceClient
 	options             Options
+	resolver            *imageResolver
 	listener            net.Listener // socket our gRPC server listens on
 }
 
@@ -81,15 +92,13 @@ func (s *server) RegisterImageService(conn *grpc.ClientConn) error {
 	}
 
 	k8sv1api.RegisterImageServiceServer(s.server, &v1ImageService{
-		imageClient:       s.imageV1Client,
-		CRIConfigs:        s.options.CRIConfigs,
-		OfflineCRIConfigs: s.options.OfflineCRIConfigs,
+		imageClient: s.imageV1Client,
+		resolver:    s.resolver,
 	})
 
 	k8sv1alpha2api.RegisterImageServiceServer(s.server, &v1alpha2ImageService{
-		imageClient:       s.imageV1Alpha2Client,
-		CRIConfigs:        s.options.CRIConfigs,
-		OfflineCRIConfigs: s.options.OfflineCRIConfigs,
+		imageClient: s.imageV1Alpha2Client,
+		resolver:    s.resolver,
 	})
 
 	return nil
@@ -200,13 +209,24 @@ func (s *server) Stop() {
 	s.server.Stop()
 }
 
+// Reload updates the auth, rules and cache of the image service, the sockets are not changed.
+func (s *server) Reload(opts Options) {
+	s.options.CRIConfigs = opts.CRIConfigs
+	s.options.OfflineCRIConfigs = opts.OfflineCRIConfigs
+	s.options.Rules = opts.Rules
+	s.options.CacheTTL = opts.CacheTTL
+	s.options.CacheNegativeTTL = opts.CacheNegativeTTL
+	s.resolver.update(s.options)
+}
+
 func NewServer(options Options) (Server, error) {
 	if !filepath.IsAbs(options.Socket) {
 		return nil, fmt.Errorf("invalid socked")
 	}
 
 	s := &server{
-		options: options,
+		options:  options,
+		resolver: newImageResolver(options),
 	}
 	return s, nil
 }
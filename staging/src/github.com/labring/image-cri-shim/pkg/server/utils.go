@@ -17,10 +17,20 @@ limitations under the License.
 package server
 
 import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
 	"github.com/labring/sreg/pkg/registry/crane"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/docker/docker/api/types"
 
+	"github.com/labring/image-cri-shim/pkg/cache"
+	shimtypes "github.com/labring/image-cri-shim/pkg/types"
+
 	"github.com/labring/sealos/pkg/utils/logger"
 )
 
@@ -71,8 +81,90 @@ import (
 //		im.imageRecords[image.ID].pinned = image.Pinned
 //	}
 
+// imageResolver resolves the images against the registries following the rules, the results
+// of manifest lookups are cached. It can be updated when the config is reloaded.
+type imageResolver struct {
+	mu          sync.RWMutex
+	criAuth     map[string]types.AuthConfig
+	offlineAuth map[string]types.AuthConfig
+	rules       *shimtypes.ImageRules
+	cache       *cache.Cache
+}
+
+func newImageResolver(opts Options) *imageResolver {
+	r := &imageResolver{}
+	r.update(opts)
+	return r
+}
+
+// update replaces the auth, rules and cache of the resolver, the cached results are dropped
+// since they may be resolved with the stale auth.
+func (r *imageResolver) update(opts Options) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.criAuth = opts.CRIConfigs
+	r.offlineAuth = opts.OfflineCRIConfigs
+	r.rules = opts.Rules
+	r.cache = cache.New(opts.CacheTTL, opts.CacheNegativeTTL)
+	cacheEntries.Set(0)
+}
+
+func (r *imageResolver) snapshot() (criAuth, offlineAuth map[string]types.AuthConfig, rules *shimtypes.ImageRules, c *cache.Cache) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.criAuth, r.offlineAuth, r.rules, r.cache
+}
+
+// criAuthConfig returns the auth of the registry in the config.
+func (r *imageResolver) criAuthConfig(registry string) (types.AuthConfig, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	v, ok := r.criAuth[registry]
+	return v, ok
+}
+
+type manifestResult struct {
+	image string
+	cfg   *types.AuthConfig
+}
+
+// lookupManifest finds the image in the registries of the auth, the result is cached.
+func lookupManifest(c *cache.Cache, image string, authConfig map[string]types.AuthConfig) (string, *types.AuthConfig, error) {
+	key := cacheKey(image, authConfig)
+	if v, err, ok := c.Get(key); ok {
+		if err != nil {
+			cacheRequests.WithLabelValues(cacheResultNegativeHit).Inc()
+			return image, nil, err
+		}
+		cacheRequests.WithLabelValues(cacheResultHit).Inc()
+		result := v.(manifestResult)
+		return result.image, result.cfg, nil
+	}
+	cacheRequests.WithLabelValues(cacheResultMiss).Inc()
+	start := time.Now()
+	newImage, _, cfg, err := crane.GetImageManifestFromAuth(image, authConfig)
+	manifestLookupDuration.Observe(time.Since(start).Seconds())
+	if err != nil {
+		manifestLookups.WithLabelValues(lookupResultFailure).Inc()
+	} else {
+		manifestLookups.WithLabelValues(lookupResultSuccess).Inc()
+	}
+	c.Set(key, manifestResult{image: newImage, cfg: cfg}, err)
+	cacheEntries.Set(float64(c.Len()))
+	return newImage, cfg, err
+}
+
+func cacheKey(image string, authConfig map[string]types.AuthConfig) string {
+	registries := make([]string, 0, len(authConfig))
+	for domain, cfg := range authConfig {
+		registries = append(registries, domain+"="+cfg.ServerAddress+"@"+cfg.Username)
+	}
+	sort.Strings(registries)
+	return image + "|" + strings.Join(registries, ",")
+}
+
 // replaceImage replaces the image name to a new valid image name with the private registry.
-func replaceImage(image, action string, authConfig map[string]types.AuthConfig) (newImage string, isReplace bool, cfg *types.AuthConfig) {
+func replaceImage(c *cache.Cache, image, action string, authConfig map[string]types.AuthConfig) (newImage string, isReplace bool, cfg *types.AuthConfig) {
 	// TODO we can change the image name of req, and make the cri pull the image we need.
 	// for example:
 	// req.Image.Image = "sealos.hub:5000/library/nginx:1.1.1"
@@ -81,7 +173,7 @@ func replaceImage(image, action string, authConfig map[string]types.AuthConfig)
 	// but kubelet sometimes will invoke imageService.RemoveImage() or something else. The req.Image.Image will the original name.
 	// so we'd better tag "sealos.hub:5000/library/nginx:1.1.1" with original name "req.Image.Image" After "rsp, err := (*s.imageService).PullImage(ctx, req)".
 	//for image id] this is mistake, we should replace the image name, not the image id.
-	newImage, _, cfg, err := crane.GetImageManifestFromAuth(image, authConfig)
+	newImage, cfg, err := lookupManifest(c, image, authConfig)
 	if err != nil {
 		logger.Warn("get image %s manifest error %s", newImage, err.Error())
 		logger.Debug("image %s not found in registry, skipping", image)
@@ -90,3 +182,63 @@ func replaceImage(image, action string, authConfig map[string]types.AuthConfig)
 	logger.Info("image: %s, newImage: %s, action: %s", image, newImage, action)
 	return newImage, true, cfg
 }
+
+// Resolve decides the image to pull, the image in the offline registry is always preferred,
+// otherwise the first matching rule takes effect. A denied pull returns a PermissionDenied error, and
+// a NotFound error is returned if none of the candidates of the rule has the image and pulling from
+// the source is not allowed.
+func (r *imageResolver) Resolve(image, action string) (string, *types.AuthConfig, error) {
+	criAuth, offlineAuth, rules, c := r.snapshot()
+	if newImage, ok, cfg := replaceImage(c, image, action, offlineAuth); ok {
+		return newImage, cfg, nil
+	}
+	d := rules.Decide(image)
+	if !d.Matched() {
+		if rules.Len() > 0 {
+			logger.Info("image: %s, action: %s, no rule matched, using the original image", image, action)
+		}
+		return image, nil, nil
+	}
+	if d.Deny {
+		logger.Warn("image: %s, action: %s, denied by rule %s", image, action, d.Rule)
+		return "", nil, status.Errorf(codes.PermissionDenied, "image %s is denied by rule %s of image-cri-shim", image, d.Rule)
+	}
+	tried := make([]string, 0, len(d.Candidates))
+	for _, candidate := range d.Candidates {
+		newImage, cfg, err := lookupManifest(c, candidate.Image, candidateAuth(candidate, criAuth))
+		if err != nil {
+			logger.Info("image: %s, action: %s, candidate %s of rule %s is not available: %v", image, action, candidate.Image, d.Rule, err)
+			tried = append(tried, candidate.Image)
+			continue
+		}
+		logger.Info("image: %s, newImage: %s, action: %s, rule: %s", image, newImage, action, d.Rule)
+		return newImage, cfg, nil
+	}
+	if d.AllowSource {
+		logger.Info("image: %s, action: %s, using the original image by rule %s", image, action, d.Rule)
+		return image, nil, nil
+	}
+	logger.Warn("image: %s, action: %s, not found in any candidate of rule %s", image, action, d.Rule)
+	return "", nil, status.Errorf(codes.NotFound, "image %s is not found in %s by rule %s of image-cri-shim", image, strings.Join(tried, ", "), d.Rule)
+}
+
+// ResolveName returns the image pulled by Resolve, the original image is returned if it fails.
+func (r *imageResolver) ResolveName(image, action string) string {
+	newImage, _, err := r.Resolve(image, action)
+	if err != nil {
+		return image
+	}
+	return newImage
+}
+
+// candidateAuth returns the auth of the candidate keyed by its registry domain, the registries of
+// the config are used if matched.
+func candidateAuth(c shimtypes.Candidate, criAuth map[string]types.AuthConfig) map[string]types.AuthConfig {
+	domain := crane.GetRegistryDomain(c.Registry)
+	auth, ok := criAuth[crane.NormalizeRegistry(domain)]
+	if !ok || strings.Contains(c.Registry, "://") {
+		// the scheme of the mirror decides whether it is insecure
+		auth.ServerAddress = c.Registry
+	}
+	return map[string]types.AuthConfig{domain: auth}
+}
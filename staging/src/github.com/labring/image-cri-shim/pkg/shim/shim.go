@@ -42,6 +42,8 @@ type Shim interface {
 	Start() error
 	// Stop stops the shim.
 	Stop()
+	// Reload applies the auth, rules and cache of the config to the running shim.
+	Reload(cfg *types.Config, auth *types.ShimAuthConfig) error
 }
 
 // shim is the implementation of Shim.
@@ -69,13 +71,14 @@ func NewShim(cfg *types.Config, auth *types.ShimAuthConfig) (Shim, error) {
 	r.client = clt
 
 	srvopts := server.Options{
-		Timeout:           cfg.Timeout.Duration,
-		Socket:            cfg.ImageShimSocket,
-		User:              -1,
-		Group:             -1,
-		Mode:              0660,
-		CRIConfigs:        auth.CRIConfigs,
-		OfflineCRIConfigs: auth.OfflineCRIConfigs,
+		Timeout: cfg.Timeout.Duration,
+		Socket:  cfg.ImageShimSocket,
+		User:    -1,
+		Group:   -1,
+		Mode:    0660,
+	}
+	if err = resolverOptions(&srvopts, cfg, auth); err != nil {
+		return nil, err
 	}
 	srv, err := server.NewServer(srvopts)
 	if err != nil {
@@ -116,6 +119,39 @@ func (r *shim) Stop() {
 	r.server.Stop()
 }
 
+// Reload applies the auth, rules and cache of the config, the sockets and the timeout
+// require a restart to change.
+func (r *shim) Reload(cfg *types.Config, auth *types.ShimAuthConfig) error {
+	r.Lock()
+	defer r.Unlock()
+	if cfg.ImageShimSocket != r.cfg.ImageShimSocket || cfg.RuntimeSocket != r.cfg.RuntimeSocket {
+		logger.Warn("changes of the sockets are ignored until image-cri-shim is restarted")
+	}
+	opts := server.Options{}
+	if err := resolverOptions(&opts, cfg, auth); err != nil {
+		return err
+	}
+	r.server.Reload(opts)
+	r.cfg = cfg
+	return nil
+}
+
+// resolverOptions sets the options of the server which can be reloaded.
+func resolverOptions(opts *server.Options, cfg *types.Config, auth *types.ShimAuthConfig) error {
+	rules, err := types.NewImageRules(cfg.Rules)
+	if err != nil {
+		return shimError("invalid image rules: %v", err)
+	}
+	opts.CRIConfigs = auth.CRIConfigs
+	opts.OfflineCRIConfigs = auth.OfflineCRIConfigs
+	opts.Rules = rules
+	if !cfg.Cache.Disable {
+		opts.CacheTTL = cfg.Cache.TTL.Duration
+		opts.CacheNegativeTTL = cfg.Cache.NegativeTTL.Duration
+	}
+	return nil
+}
+
 func (r *shim) dialNotify(socket string, uid int, gid int, mode os.FileMode, err error) {
 	if err != nil {
 		logger.Error("failed to determine permissions/ownership of client socket %q: %v",
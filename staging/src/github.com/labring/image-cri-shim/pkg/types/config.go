@@ -38,6 +38,9 @@ const (
 	// SealosShimSock is the CRI socket the shim listens on.
 	SealosShimSock            = "/var/run/image-cri-shim.sock"
 	DefaultImageCRIShimConfig = "/etc/image-cri-shim.yaml"
+
+	defaultCacheTTL         = 10 * time.Minute
+	defaultCacheNegativeTTL = 30 * time.Second
 )
 
 type Registry struct {
@@ -54,6 +57,20 @@ type Config struct {
 	Timeout         metav1.Duration `json:"timeout"`
 	Auth            string          `json:"auth"`
 	Registries      []Registry      `json:"registries"`
+	Rules           []ImageRule     `json:"rules,omitempty"`
+	Cache           CacheConfig     `json:"cache,omitempty"`
+	// Metrics is the address to serve prometheus metrics on, disabled if empty.
+	Metrics string `json:"metrics,omitempty"`
+}
+
+// CacheConfig is the cache of the images resolved against the registries.
+type CacheConfig struct {
+	// TTL of the images found in the registries, defaults to 10m.
+	TTL metav1.Duration `json:"ttl,omitempty"`
+	// NegativeTTL of the images not found, defaults to 30s.
+	NegativeTTL metav1.Duration `json:"negativeTTL,omitempty"`
+	// Disable disables the cache.
+	Disable bool `json:"disable,omitempty"`
 }
 
 type ShimAuthConfig struct {
@@ -84,6 +101,13 @@ func (c *Config) PreProcess() (*ShimAuthConfig, error) {
 	logger.Info("Debug: %v", c.Debug)
 	logger.CfgConsoleLogger(c.Debug, false)
 	logger.Info("Timeout: %v", c.Timeout)
+	if c.Cache.TTL.Duration == 0 {
+		c.Cache.TTL = metav1.Duration{Duration: defaultCacheTTL}
+	}
+	if c.Cache.NegativeTTL.Duration == 0 {
+		c.Cache.NegativeTTL = metav1.Duration{Duration: defaultCacheNegativeTTL}
+	}
+	logger.Info("Cache: %+v", c.Cache)
 	shimAuth := new(ShimAuthConfig)
 
 	splitNameAndPasswd := func(auth string) (string, string) {
@@ -133,6 +157,10 @@ func (c *Config) PreProcess() (*ShimAuthConfig, error) {
 	if c.Address == "" {
 		return nil, errors.New("registry addr is empty")
 	}
+	if _, err = NewImageRules(c.Rules); err != nil {
+		return nil, err
+	}
+	logger.Info("Rules: %d", len(c.Rules))
 	if c.RuntimeSocket == "" {
 		socket, err := cri.DetectCRISocket()
 		if err != nil {
@@ -0,0 +1,174 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	registry2 "github.com/labring/sreg/pkg/registry/crane"
+)
+
+const defaultRegistry = "docker.io"
+
+// ImageRule decides how an image is pulled, the rules are evaluated in order and the first
+// matching one takes effect. A rule without deny, rewrite and mirrors allows the image to be
+// pulled as it is.
+type ImageRule struct {
+	Name string `json:"name,omitempty"`
+	// Registry is a glob pattern matching the registry of the image, e.g. *.docker.io,
+	// docker hub images are normalized to docker.io.
+	Registry string `json:"registry,omitempty"`
+	// Image is a regular expression matching the full image name, e.g. ^docker.io/library/(.*)$
+	Image string `json:"image,omitempty"`
+	// Deny blocks the pulls of matching images.
+	Deny bool `json:"deny,omitempty"`
+	// Rewrite is the replacement of Image, capture groups can be referenced as $1.
+	Rewrite string `json:"rewrite,omitempty"`
+	// Mirrors are the registries tried in order after the rewritten image, the registry of
+	// the image is replaced by the mirror.
+	Mirrors []string `json:"mirrors,omitempty"`
+	// AllowSource pulls the image from its original registry if neither the rewritten image
+	// nor any mirror has it, otherwise the pull fails.
+	AllowSource bool `json:"allowSource,omitempty"`
+}
+
+type compiledRule struct {
+	ImageRule
+	image *regexp.Regexp
+}
+
+// ImageRules is the compiled rules of the config.
+type ImageRules struct {
+	rules []*compiledRule
+}
+
+// NewImageRules validates and compiles the rules.
+func NewImageRules(rules []ImageRule) (*ImageRules, error) {
+	ret := &ImageRules{}
+	for i, rule := range rules {
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rules[%d]", i)
+		}
+		if rule.Registry == "" && rule.Image == "" {
+			return nil, fmt.Errorf("rule %s: one of registry and image is required", rule.Name)
+		}
+		if rule.Deny && (rule.Rewrite != "" || len(rule.Mirrors) > 0) {
+			return nil, fmt.Errorf("rule %s: deny cannot be used with rewrite or mirrors", rule.Name)
+		}
+		if rule.Rewrite != "" && rule.Image == "" {
+			return nil, fmt.Errorf("rule %s: rewrite requires image", rule.Name)
+		}
+		if rule.Registry != "" {
+			if _, err := path.Match(rule.Registry, ""); err != nil {
+				return nil, fmt.Errorf("rule %s: invalid registry pattern %s: %v", rule.Name, rule.Registry, err)
+			}
+		}
+		cr := &compiledRule{ImageRule: rule}
+		if rule.Image != "" {
+			re, err := regexp.Compile(rule.Image)
+			if err != nil {
+				return nil, fmt.Errorf("rule %s: invalid image pattern %s: %v", rule.Name, rule.Image, err)
+			}
+			cr.image = re
+		}
+		ret.rules = append(ret.rules, cr)
+	}
+	return ret, nil
+}
+
+// Len returns the number of the rules.
+func (r *ImageRules) Len() int {
+	if r == nil {
+		return 0
+	}
+	return len(r.rules)
+}
+
+// Candidate is an image to try for a pull, along with the registry address it's pulled from.
+type Candidate struct {
+	Image    string
+	Registry string
+}
+
+// Decision is the result of the rules for an image.
+type Decision struct {
+	// Rule is the name of the matching rule, empty if no rule matches.
+	Rule string
+	Deny bool
+	// Candidates are tried in order, the first one which exists is pulled.
+	Candidates  []Candidate
+	AllowSource bool
+}
+
+// Matched returns whether any rule matches the image.
+func (d Decision) Matched() bool {
+	return d.Rule != ""
+}
+
+// Decide evaluates the rules for the image.
+func (r *ImageRules) Decide(image string) Decision {
+	if r == nil {
+		return Decision{}
+	}
+	registry, repo := SplitImage(image)
+	full := registry + "/" + repo
+	for _, rule := range r.rules {
+		if rule.Registry != "" {
+			if ok, _ := path.Match(rule.Registry, registry); !ok {
+				continue
+			}
+		}
+		if rule.image != nil && !rule.image.MatchString(full) {
+			continue
+		}
+		d := Decision{Rule: rule.Name, Deny: rule.Deny, AllowSource: rule.AllowSource}
+		if rule.Rewrite != "" {
+			rewritten := rule.image.ReplaceAllString(full, rule.Rewrite)
+			rewrittenRegistry, _ := SplitImage(rewritten)
+			d.Candidates = append(d.Candidates, Candidate{Image: rewritten, Registry: rewrittenRegistry})
+		}
+		for _, mirror := range rule.Mirrors {
+			domain := registry2.GetRegistryDomain(mirror)
+			d.Candidates = append(d.Candidates, Candidate{Image: domain + "/" + repo, Registry: mirror})
+		}
+		if len(d.Candidates) == 0 && !d.Deny {
+			// an allow rule
+			d.AllowSource = true
+		}
+		return d
+	}
+	return Decision{}
+}
+
+// SplitImage splits the image into the registry and the repository with tag or digest, docker
+// hub images are normalized to docker.io and the library namespace is added if missing.
+func SplitImage(image string) (registry, repo string) {
+	parts := strings.SplitN(image, "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		registry, repo = parts[0], parts[1]
+	} else {
+		registry, repo = defaultRegistry, image
+	}
+	if registry2.NormalizeRegistry(registry) == registry2.NormalizeRegistry(defaultRegistry) {
+		registry = defaultRegistry
+		if !strings.Contains(repo, "/") {
+			repo = "library/" + repo
+		}
+	}
+	return registry, repo
+}
@@ -0,0 +1,117 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitImage(t *testing.T) {
+	tests := []struct {
+		image    string
+		registry string
+		repo     string
+	}{
+		{"nginx", "docker.io", "library/nginx"},
+		{"nginx:1.25", "docker.io", "library/nginx:1.25"},
+		{"labring/lvscare:v4.3.0", "docker.io", "labring/lvscare:v4.3.0"},
+		{"index.docker.io/labring/lvscare:v4.3.0", "docker.io", "labring/lvscare:v4.3.0"},
+		{"sealos.hub:5000/library/nginx:1.25", "sealos.hub:5000", "library/nginx:1.25"},
+		{"localhost/pause:3.9", "localhost", "pause:3.9"},
+		{"registry.k8s.io/pause:3.9", "registry.k8s.io", "pause:3.9"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			registry, repo := SplitImage(tt.image)
+			if registry != tt.registry || repo != tt.repo {
+				t.Errorf("SplitImage() = %s, %s, want %s, %s", registry, repo, tt.registry, tt.repo)
+			}
+		})
+	}
+}
+
+func TestNewImageRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   []ImageRule
+		wantErr bool
+	}{
+		{"empty", nil, false},
+		{"registry glob", []ImageRule{{Registry: "*.example.com", Deny: true}}, false},
+		{"no match", []ImageRule{{Deny: true}}, true},
+		{"deny with mirrors", []ImageRule{{Registry: "*", Deny: true, Mirrors: []string{"mirror.io"}}}, true},
+		{"rewrite without image", []ImageRule{{Registry: "*", Rewrite: "mirror.io/$1"}}, true},
+		{"invalid glob", []ImageRule{{Registry: "[", Deny: true}}, true},
+		{"invalid regex", []ImageRule{{Image: "(", Deny: true}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewImageRules(tt.rules); (err != nil) != tt.wantErr {
+				t.Errorf("NewImageRules() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestImageRules_Decide(t *testing.T) {
+	rules, err := NewImageRules([]ImageRule{
+		{Name: "allow-hub", Registry: "sealos.hub:5000"},
+		{Name: "k8s", Image: `^registry\.k8s\.io/(.*)$`, Rewrite: "mirror.example.com/k8s/$1", Mirrors: []string{"http://192.168.64.1:5000"}},
+		{Name: "dockerhub", Registry: "docker.io", Mirrors: []string{"http://192.168.64.1:5000", "https://mirror.example.com"}, AllowSource: true},
+		{Registry: "*", Deny: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		image string
+		want  Decision
+	}{
+		{
+			image: "sealos.hub:5000/library/nginx:1.25",
+			want:  Decision{Rule: "allow-hub", AllowSource: true},
+		},
+		{
+			image: "registry.k8s.io/pause:3.9",
+			want: Decision{Rule: "k8s", Candidates: []Candidate{
+				{Image: "mirror.example.com/k8s/pause:3.9", Registry: "mirror.example.com"},
+				{Image: "192.168.64.1:5000/pause:3.9", Registry: "http://192.168.64.1:5000"},
+			}},
+		},
+		{
+			image: "nginx:1.25",
+			want: Decision{Rule: "dockerhub", AllowSource: true, Candidates: []Candidate{
+				{Image: "192.168.64.1:5000/library/nginx:1.25", Registry: "http://192.168.64.1:5000"},
+				{Image: "mirror.example.com/library/nginx:1.25", Registry: "https://mirror.example.com"},
+			}},
+		},
+		{
+			image: "quay.io/coreos/etcd:v3.5.9",
+			want:  Decision{Rule: "rules[3]", Deny: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			if got := rules.Decide(tt.image); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Decide() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+	var empty *ImageRules
+	if got := empty.Decide("nginx"); got.Matched() {
+		t.Errorf("Decide() of nil rules = %+v, want no match", got)
+	}
+}
@@ -32,6 +32,10 @@ type stopper interface {
 	Stop()
 }
 
+type statusGetter interface {
+	Status() []VirtualServerStatus
+}
+
 type Ruler interface {
 	Setup() error
 	Cleanup() error
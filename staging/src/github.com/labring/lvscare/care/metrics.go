@@ -0,0 +1,60 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package care
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+const (
+	metricsNamespace = appName
+
+	backendActionAdd    = "add"
+	backendActionRemove = "remove"
+)
+
+var (
+	metricsRegistry = prometheus.NewRegistry()
+
+	probeDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "probe_duration_seconds",
+		Help:      "Latency of health probes to real servers.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"virtual_server", "real_server"})
+
+	probeFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "probe_failures_total",
+		Help:      "Number of failed health probes to real servers.",
+	}, []string{"virtual_server", "real_server"})
+
+	backendChanges = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "backend_changes_total",
+		Help:      "Number of real servers added to or removed from IPVS.",
+	}, []string{"virtual_server", "real_server", "action"})
+)
+
+func init() {
+	metricsRegistry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		probeDuration,
+		probeFailures,
+		backendChanges,
+	)
+}
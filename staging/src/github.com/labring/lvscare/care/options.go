@@ -52,7 +52,7 @@ type options struct {
 
 func (o *options) RegisterFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&o.VirtualServer, "vs", "", "virtual server address, for example 169.254.0.1:6443")
-	fs.StringSliceVar(&o.RealServer, "rs", []string{}, "real server address like 192.168.0.2:6443")
+	fs.StringSliceVar(&o.RealServer, "rs", []string{}, "real server address like 192.168.0.2:6443, an optional weight for wrr/wlc scheduler can be appended like 192.168.0.2:6443@3")
 	fs.StringVar(&o.scheduler, "scheduler", "rr", "proxier scheduler")
 	fs.StringVarP(&o.IfaceName, "iface", "i", appName, "name of dummy interface to created, same behavior as kube-proxy")
 	fs.StringVar(&o.Logger, "logger", "INFO", "logger level: DEBG/INFO")
@@ -85,6 +85,11 @@ func (o *options) ValidateAndSetDefaults() error {
 	default:
 		return fmt.Errorf(`invalid flag "scheduler=%s"`, o.scheduler)
 	}
+	for _, rs := range o.RealServer {
+		if _, _, err := parseRealServer(rs); err != nil {
+			return fmt.Errorf(`invalid flag "rs=%s": %v`, rs, err)
+		}
+	}
 	if o.TargetIP == nil && o.Mode == routeMode {
 		hf := &hosts.HostFile{Path: constants.DefaultHostsPath}
 		if ip, ok := hf.HasDomain(constants.DefaultLvscareDomain); ok {
@@ -0,0 +1,141 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package care
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// outlierDetector ejects a real server after Fall consecutive probe failures, and brings it
+// back after Rise consecutive successes once the ejection backoff has passed. The backoff
+// doubles every time the real server is ejected again within MaxBackoff after it is back,
+// so that a flapping real server doesn't oscillate in and out of IPVS.
+type outlierDetector struct {
+	Rise       int
+	Fall       int
+	Backoff    durationOrSecondValue
+	MaxBackoff durationOrSecondValue
+
+	mu     sync.Mutex
+	states map[string]*outlierState
+}
+
+type outlierState struct {
+	successes int
+	failures  int
+	ejected   bool
+	// times of ejection since the real server is considered stable
+	ejections    int
+	ejectedUntil time.Time
+	restoredAt   time.Time
+}
+
+func newOutlierDetector() *outlierDetector {
+	return &outlierDetector{states: make(map[string]*outlierState)}
+}
+
+func (d *outlierDetector) RegisterFlags(fs *pflag.FlagSet) {
+	fs.IntVar(&d.Rise, "health-rise", 1, "consecutive successful probes required to bring an ejected real server back")
+	fs.IntVar(&d.Fall, "health-fall", 1, "consecutive failed probes required to eject a real server")
+	fs.Var(&d.Backoff, "ejection-backoff", "minimum time a real server stays ejected, doubled on every ejection in a row, 0 to disable")
+	fs.Var(&d.MaxBackoff, "max-ejection-backoff", "maximum time a real server stays ejected, defaults to 10 times of ejection-backoff")
+}
+
+func (d *outlierDetector) ValidateAndSetDefaults() error {
+	if d.Rise < 1 {
+		return fmt.Errorf(`invalid flag "health-rise=%d", must be greater than 0`, d.Rise)
+	}
+	if d.Fall < 1 {
+		return fmt.Errorf(`invalid flag "health-fall=%d", must be greater than 0`, d.Fall)
+	}
+	if d.Backoff < 0 || d.MaxBackoff < 0 {
+		return fmt.Errorf(`invalid flag "ejection-backoff" or "max-ejection-backoff", must not be negative`)
+	}
+	if d.MaxBackoff == 0 {
+		d.MaxBackoff = 10 * d.Backoff
+	}
+	if d.MaxBackoff < d.Backoff {
+		return fmt.Errorf(`invalid flag "max-ejection-backoff=%s", must not be less than ejection-backoff`, d.MaxBackoff.String())
+	}
+	return nil
+}
+
+// Observe records the probe result of the real server and returns whether it is ejected.
+func (d *outlierDetector) Observe(rs string, healthy bool, now time.Time) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	state, ok := d.states[rs]
+	if !ok {
+		state = &outlierState{}
+		d.states[rs] = state
+	}
+	if !healthy {
+		state.successes = 0
+		state.failures++
+		if !state.ejected && state.failures >= d.Fall {
+			d.eject(state, now)
+		}
+		return state.ejected
+	}
+	state.failures = 0
+	state.successes++
+	if state.ejected && state.successes >= d.Rise && !now.Before(state.ejectedUntil) {
+		state.ejected = false
+		state.restoredAt = now
+	}
+	return state.ejected
+}
+
+func (d *outlierDetector) eject(state *outlierState, now time.Time) {
+	// the real server has been stable for long enough, start over the backoff
+	if !state.restoredAt.IsZero() && now.Sub(state.restoredAt) > time.Duration(d.MaxBackoff) {
+		state.ejections = 0
+	}
+	state.ejected = true
+	state.ejections++
+	state.ejectedUntil = now.Add(d.backoff(state.ejections))
+}
+
+func (d *outlierDetector) backoff(ejections int) time.Duration {
+	backoff := time.Duration(d.Backoff)
+	for i := 1; i < ejections && backoff < time.Duration(d.MaxBackoff); i++ {
+		backoff *= 2
+	}
+	if backoff > time.Duration(d.MaxBackoff) {
+		backoff = time.Duration(d.MaxBackoff)
+	}
+	return backoff
+}
+
+// Forget drops the state of the real server.
+func (d *outlierDetector) Forget(rs string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.states, rs)
+}
+
+// State returns the consecutive failures of the real server and whether it is ejected.
+func (d *outlierDetector) State(rs string) (failures int, ejected bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if state, ok := d.states[rs]; ok {
+		return state.failures, state.ejected
+	}
+	return 0, false
+}
@@ -0,0 +1,181 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package care
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOutlierDetectorObserve(t *testing.T) {
+	type step struct {
+		at      time.Duration
+		healthy bool
+		ejected bool
+	}
+	tests := []struct {
+		name  string
+		rise  int
+		fall  int
+		steps []step
+	}{
+		{
+			name: "eject after fall failures",
+			rise: 1, fall: 3,
+			steps: []step{
+				{0, false, false},
+				{time.Second, false, false},
+				{2 * time.Second, true, false},
+				{3 * time.Second, false, false},
+				{4 * time.Second, false, false},
+				{5 * time.Second, false, true},
+			},
+		},
+		{
+			name: "stay ejected until the backoff has passed",
+			rise: 1, fall: 1,
+			steps: []step{
+				{0, false, true},
+				{5 * time.Second, true, true},
+				{9 * time.Second, true, true},
+				{10 * time.Second, true, false},
+			},
+		},
+		{
+			name: "recover after rise successes",
+			rise: 3, fall: 1,
+			steps: []step{
+				{0, false, true},
+				{10 * time.Second, true, true},
+				{11 * time.Second, true, true},
+				{12 * time.Second, false, true},
+				{22 * time.Second, true, true},
+				{23 * time.Second, true, true},
+				{24 * time.Second, true, false},
+			},
+		},
+		{
+			name: "double the backoff when ejected again",
+			rise: 1, fall: 1,
+			steps: []step{
+				// ejected for 10s
+				{0, false, true},
+				{10 * time.Second, true, false},
+				// ejected for 20s
+				{11 * time.Second, false, true},
+				{30 * time.Second, true, true},
+				{31 * time.Second, true, false},
+				// ejected for 40s
+				{32 * time.Second, false, true},
+				{71 * time.Second, true, true},
+				{72 * time.Second, true, false},
+				// capped at 40s
+				{73 * time.Second, false, true},
+				{112 * time.Second, true, true},
+				{113 * time.Second, true, false},
+			},
+		},
+		{
+			name: "start over the backoff once stable",
+			rise: 1, fall: 1,
+			steps: []step{
+				{0, false, true},
+				{10 * time.Second, true, false},
+				{11 * time.Second, false, true},
+				{31 * time.Second, true, false},
+				// stable for longer than the max backoff
+				{72 * time.Second, false, true},
+				{82 * time.Second, true, false},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newOutlierDetector()
+			d.Rise, d.Fall = tt.rise, tt.fall
+			d.Backoff = durationOrSecondValue(10 * time.Second)
+			d.MaxBackoff = durationOrSecondValue(40 * time.Second)
+			start := time.Now()
+			for i, s := range tt.steps {
+				if got := d.Observe("192.168.0.2:6443", s.healthy, start.Add(s.at)); got != s.ejected {
+					t.Fatalf("step %d at %s: ejected = %v, want %v", i, s.at, got, s.ejected)
+				}
+			}
+		})
+	}
+}
+
+func TestOutlierDetectorBackoff(t *testing.T) {
+	d := &outlierDetector{
+		Backoff:    durationOrSecondValue(3 * time.Second),
+		MaxBackoff: durationOrSecondValue(20 * time.Second),
+	}
+	want := []time.Duration{3 * time.Second, 6 * time.Second, 12 * time.Second, 20 * time.Second, 20 * time.Second}
+	for i, w := range want {
+		if got := d.backoff(i + 1); got != w {
+			t.Errorf("backoff(%d) = %s, want %s", i+1, got, w)
+		}
+	}
+	d.Backoff, d.MaxBackoff = 0, 0
+	if got := d.backoff(5); got != 0 {
+		t.Errorf("backoff(5) = %s, want 0 if disabled", got)
+	}
+}
+
+func TestOutlierDetectorStateAndForget(t *testing.T) {
+	d := newOutlierDetector()
+	d.Rise, d.Fall = 1, 2
+	now := time.Now()
+	d.Observe("192.168.0.2:6443", false, now)
+	if failures, ejected := d.State("192.168.0.2:6443"); failures != 1 || ejected {
+		t.Errorf("State() = %d, %v, want 1, false", failures, ejected)
+	}
+	d.Observe("192.168.0.2:6443", false, now)
+	if failures, ejected := d.State("192.168.0.2:6443"); failures != 2 || !ejected {
+		t.Errorf("State() = %d, %v, want 2, true", failures, ejected)
+	}
+	d.Forget("192.168.0.2:6443")
+	if failures, ejected := d.State("192.168.0.2:6443"); failures != 0 || ejected {
+		t.Errorf("State() after Forget = %d, %v, want 0, false", failures, ejected)
+	}
+}
+
+func TestOutlierDetectorValidateAndSetDefaults(t *testing.T) {
+	tests := []struct {
+		name       string
+		d          *outlierDetector
+		wantErr    bool
+		maxBackoff time.Duration
+	}{
+		{"default max backoff", &outlierDetector{Rise: 1, Fall: 1, Backoff: durationOrSecondValue(time.Second)}, false, 10 * time.Second},
+		{"max backoff", &outlierDetector{Rise: 2, Fall: 3, Backoff: durationOrSecondValue(time.Second), MaxBackoff: durationOrSecondValue(time.Minute)}, false, time.Minute},
+		{"no backoff", &outlierDetector{Rise: 1, Fall: 1}, false, 0},
+		{"zero rise", &outlierDetector{Rise: 0, Fall: 1}, true, 0},
+		{"zero fall", &outlierDetector{Rise: 1, Fall: 0}, true, 0},
+		{"negative backoff", &outlierDetector{Rise: 1, Fall: 1, Backoff: durationOrSecondValue(-time.Second)}, true, 0},
+		{"max backoff less than backoff", &outlierDetector{Rise: 1, Fall: 1, Backoff: durationOrSecondValue(time.Minute), MaxBackoff: durationOrSecondValue(time.Second)}, true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.d.ValidateAndSetDefaults()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateAndSetDefaults() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && time.Duration(tt.d.MaxBackoff) != tt.maxBackoff {
+				t.Errorf("MaxBackoff = %s, want %s", tt.d.MaxBackoff.String(), tt.maxBackoff)
+			}
+		})
+	}
+}
@@ -27,6 +27,10 @@ import (
 	"time"
 
 	"github.com/spf13/pflag"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"k8s.io/apimachinery/pkg/util/sets"
 )
 
@@ -34,6 +38,55 @@ type Prober interface {
 	Probe(string, string) error
 }
 
+const (
+	probeTypeHTTP = "http"
+	probeTypeTCP  = "tcp"
+	probeTypeGRPC = "grpc"
+)
+
+var probeTypes = []string{probeTypeHTTP, probeTypeTCP, probeTypeGRPC}
+
+// typedProber delegates to the prober of --health-probe-type, flags of all the probers
+// are registered while only the chosen one is validated and used.
+type typedProber struct {
+	ProbeType string
+	probers   map[string]Prober
+}
+
+func newTypedProber() *typedProber {
+	return &typedProber{
+		probers: map[string]Prober{
+			probeTypeHTTP: &httpProber{},
+			probeTypeTCP:  &tcpProber{},
+			probeTypeGRPC: &grpcProber{},
+		},
+	}
+}
+
+func (p *typedProber) RegisterFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&p.ProbeType, "health-probe-type", probeTypeHTTP, fmt.Sprintf("type of health prober: %s", strings.Join(probeTypes, "/")))
+	for _, t := range probeTypes {
+		if registerer, ok := p.probers[t].(flagRegisterer); ok {
+			registerer.RegisterFlags(fs)
+		}
+	}
+}
+
+func (p *typedProber) ValidateAndSetDefaults() error {
+	prober, ok := p.probers[p.ProbeType]
+	if !ok {
+		return fmt.Errorf(`invalid flag "health-probe-type=%s"`, p.ProbeType)
+	}
+	if validator, ok := prober.(flagValidator); ok {
+		return validator.ValidateAndSetDefaults()
+	}
+	return nil
+}
+
+func (p *typedProber) Probe(host, port string) error {
+	return p.probers[p.ProbeType].Probe(host, port)
+}
+
 type httpProber struct {
 	HealthPath         string
 	HealthScheme       string
@@ -122,3 +175,58 @@ func (p *httpProber) Probe(host, port string) error {
 	}
 	return nil
 }
+
+// tcpProber considers the real server healthy if a TCP connection can be established.
+type tcpProber struct {
+	timeout time.Duration
+}
+
+func (p *tcpProber) RegisterFlags(fs *pflag.FlagSet) {
+	fs.DurationVar(&p.timeout, "health-tcp-timeout", 5*time.Second, "tcp probe timeout")
+}
+
+func (p *tcpProber) Probe(host, port string) error {
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(host, port), p.timeout)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// grpcProber checks the real server with the standard gRPC health checking protocol grpc.health.v1.
+type grpcProber struct {
+	Service            string
+	TLS                bool
+	InsecureSkipVerify bool
+	timeout            time.Duration
+}
+
+func (p *grpcProber) RegisterFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&p.Service, "health-grpc-service", "", "service name to check, empty for the overall health of the server")
+	fs.BoolVar(&p.TLS, "health-grpc-tls", false, "use TLS for grpc probe")
+	fs.BoolVar(&p.InsecureSkipVerify, "health-grpc-insecure-skip-verify", true, "skip verify the certificate of server when TLS is enabled")
+	fs.DurationVar(&p.timeout, "health-grpc-timeout", 10*time.Second, "grpc probe timeout")
+}
+
+func (p *grpcProber) Probe(host, port string) error {
+	creds := insecure.NewCredentials()
+	if p.TLS {
+		// nosemgrep
+		creds = credentials.NewTLS(&tls.Config{InsecureSkipVerify: p.InsecureSkipVerify})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, net.JoinHostPort(host, port), grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: p.Service})
+	if err != nil {
+		return err
+	}
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("unexpected serving status %s", resp.GetStatus())
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package care
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestGRPCProber(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("serving", healthpb.HealthCheckResponse_SERVING)
+	healthServer.SetServingStatus("not-serving", healthpb.HealthCheckResponse_NOT_SERVING)
+	srv := grpc.NewServer()
+	healthpb.RegisterHealthServer(srv, healthServer)
+	go func() { _ = srv.Serve(lis) }()
+	defer srv.Stop()
+	host, port, err := net.SplitHostPort(lis.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		prober  grpcProber
+		port    string
+		wantErr bool
+	}{
+		{name: "overall health", prober: grpcProber{}, port: port},
+		{name: "serving service", prober: grpcProber{Service: "serving"}, port: port},
+		{name: "not serving service", prober: grpcProber{Service: "not-serving"}, port: port, wantErr: true},
+		{name: "unknown service", prober: grpcProber{Service: "unknown"}, port: port, wantErr: true},
+		{name: "tls to plaintext server", prober: grpcProber{TLS: true, InsecureSkipVerify: true}, port: port, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.prober.timeout = 5 * time.Second
+			if err := tt.prober.Probe(host, tt.port); (err != nil) != tt.wantErr {
+				t.Errorf("Probe() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	healthServer.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	prober := &grpcProber{timeout: 5 * time.Second}
+	if err := prober.Probe(host, port); err == nil {
+		t.Errorf("Probe() of a not serving server returns nil")
+	}
+	srv.Stop()
+	prober.timeout = time.Second
+	if err := prober.Probe(host, port); err == nil {
+		t.Errorf("Probe() of a stopped server returns nil")
+	}
+}
@@ -17,8 +17,11 @@ package care
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -45,13 +48,20 @@ func (ep *endpoint) String() string {
 	return net.JoinHostPort(ep.IP, strconv.Itoa(int(ep.Port)))
 }
 
-func NewProxier(scheduler string, interval time.Duration, prober Prober, syncFn func() error) Proxier {
+type realServer struct {
+	endpoint
+	Weight int
+}
+
+func NewProxier(scheduler string, interval time.Duration, prober Prober, detector *outlierDetector, syncFn func() error) Proxier {
 	return &realProxier{
 		scheduler:  scheduler,
 		ipvsHandle: ipvs.New(),
 		syncFn:     syncFn,
-		serviceMap: make(map[endpoint]map[string]endpoint),
+		serviceMap: make(map[endpoint]map[string]realServer),
+		probes:     make(map[string]probeResult),
 		prober:     prober,
+		detector:   detector,
 		ticker:     time.NewTicker(interval),
 		tryCh:      make(chan struct{}, 1),
 		errCh:      make(chan error, 1),
@@ -64,8 +74,11 @@ type realProxier struct {
 	syncFn     func() error
 
 	// for prober
-	serviceMap map[endpoint]map[string]endpoint
+	mu         sync.RWMutex
+	serviceMap map[endpoint]map[string]realServer
+	probes     map[string]probeResult
 	prober     Prober
+	detector   *outlierDetector
 	ticker     *time.Ticker
 	tryCh      chan struct{}
 	errCh      chan error
@@ -98,8 +111,10 @@ func (p *realProxier) EnsureVirtualServer(vs string) error {
 	if err != nil {
 		return err
 	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	if _, ok := p.serviceMap[ep]; !ok {
-		p.serviceMap[ep] = make(map[string]endpoint)
+		p.serviceMap[ep] = make(map[string]realServer)
 	}
 	return nil
 }
@@ -117,6 +132,8 @@ func (p *realProxier) DeleteVirtualServer(vs string) error {
 			return err
 		}
 	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	delete(p.serviceMap, ep)
 	return nil
 }
@@ -139,7 +156,7 @@ func (p *realProxier) getServersByEndpoint(vs, rs endpoint) (*ipvs.VirtualServer
 	if err != nil {
 		return nil, nil, err
 	}
-	rSrv, err := p.getRealServer(vSrv, p.buildRealServer(&rs))
+	rSrv, err := p.getRealServer(vSrv, p.buildRealServer(&rs, 1))
 	if err != nil {
 		return nil, nil, err
 	}
@@ -151,7 +168,7 @@ func (p *realProxier) EnsureRealServer(vs, rs string) error {
 	if err != nil {
 		return err
 	}
-	rsEp, err := parseEndpoint(rs)
+	rsEp, weight, err := parseRealServer(rs)
 	if err != nil {
 		return err
 	}
@@ -161,17 +178,28 @@ func (p *realProxier) EnsureRealServer(vs, rs string) error {
 	}
 	defer func() {
 		if err == nil {
-			p.serviceMap[vsEp][rsEp.String()] = rsEp
+			p.mu.Lock()
+			p.serviceMap[vsEp][rsEp.String()] = realServer{endpoint: rsEp, Weight: weight}
+			p.mu.Unlock()
 		}
 	}()
 	if rSrv != nil {
+		// weight 0 means the real server is draining, leave it to the health check
+		if rSrv.Weight != 0 && rSrv.Weight != weight {
+			rSrv.Weight = weight
+			if err = p.ipvsHandle.UpdateRealServer(vSrv, rSrv); err != nil {
+				logger.Error("Failed to update real server weight: %v", err)
+				return err
+			}
+		}
 		return nil
 	}
-	rSrv = p.buildRealServer(&rsEp)
+	rSrv = p.buildRealServer(&rsEp, weight)
 	if err = p.ipvsHandle.AddRealServer(vSrv, rSrv); err != nil {
 		logger.Error("Failed to add real server: %v", err)
 		return err
 	}
+	backendChanges.WithLabelValues(vsEp.String(), rsEp.String(), backendActionAdd).Inc()
 	return nil
 }
 
@@ -180,7 +208,7 @@ func (p *realProxier) DeleteRealServer(vs, rs string) error {
 	if err != nil {
 		return err
 	}
-	rsEp, err := parseEndpoint(rs)
+	rsEp, _, err := parseRealServer(rs)
 	if err != nil {
 		return err
 	}
@@ -188,6 +216,7 @@ func (p *realProxier) DeleteRealServer(vs, rs string) error {
 	if err != nil {
 		return err
 	}
+	p.detector.Forget(rsEp.String())
 	if rSrv == nil {
 		return nil
 	}
@@ -195,6 +224,7 @@ func (p *realProxier) DeleteRealServer(vs, rs string) error {
 		logger.Error("Failed to delete real server: %v", err)
 		return err
 	}
+	backendChanges.WithLabelValues(vsEp.String(), rsEp.String(), backendActionRemove).Inc()
 	return nil
 }
 
@@ -240,16 +270,23 @@ func (p *realProxier) Stop() {
 	close(p.errCh)
 }
 
-func (p *realProxier) checkRealServer(wg *sync.WaitGroup, vSrv *ipvs.VirtualServer, rs endpoint) {
+func (p *realProxier) checkRealServer(wg *sync.WaitGroup, vs endpoint, vSrv *ipvs.VirtualServer, rs realServer) {
 	defer wg.Done()
+	start := time.Now()
 	probeErr := p.prober.Probe(rs.IP, strconv.Itoa(int(rs.Port)))
-	rSrv, err := p.getRealServer(vSrv, p.buildRealServer(&rs))
+	probeDuration.WithLabelValues(vs.String(), rs.String()).Observe(time.Since(start).Seconds())
+	if probeErr != nil {
+		logger.Debug("probe error: %v", probeErr)
+		probeFailures.WithLabelValues(vs.String(), rs.String()).Inc()
+	}
+	p.recordProbe(rs.String(), start, probeErr)
+	ejected := p.detector.Observe(rs.String(), probeErr == nil, time.Now())
+	rSrv, err := p.getRealServer(vSrv, p.buildRealServer(&rs.endpoint, rs.Weight))
 	if err != nil {
 		logger.Warn("Failed to get real server: %v", err)
 		return
 	}
-	if probeErr != nil {
-		logger.Debug("probe error: %v", probeErr)
+	if ejected {
 		if rSrv != nil {
 			if rSrv.Weight != 0 {
 				logger.Debug("Trying to update wight to 0 for graceful termination")
@@ -262,14 +299,19 @@ func (p *realProxier) checkRealServer(wg *sync.WaitGroup, vSrv *ipvs.VirtualServ
 			logger.Debug("Trying to delete real server")
 			if err = p.ipvsHandle.DeleteRealServer(vSrv, rSrv); err != nil {
 				logger.Warn("Failed to delete real server: %v", err)
+				return
 			}
+			backendChanges.WithLabelValues(vs.String(), rs.String(), backendActionRemove).Inc()
 		}
 		return
 	}
+	if probeErr != nil {
+		logger.Debug("Real server %s is not ejected until %d consecutive failures", rs.String(), p.detector.Fall)
+	}
 	if rSrv != nil {
 		if rSrv.Weight == 0 {
-			logger.Debug("Trying to update wight to 1 to receive traffic")
-			rSrv.Weight = 1
+			logger.Debug("Trying to update wight to %d to receive traffic", rs.Weight)
+			rSrv.Weight = rs.Weight
 			if err = p.ipvsHandle.UpdateRealServer(vSrv, rSrv); err != nil {
 				logger.Warn("Failed to update real server wight: %v", err)
 			}
@@ -277,27 +319,45 @@ func (p *realProxier) checkRealServer(wg *sync.WaitGroup, vSrv *ipvs.VirtualServ
 		return
 	}
 	logger.Debug("Trying to add real server back")
-	if err = p.ipvsHandle.AddRealServer(vSrv, p.buildRealServer(&rs)); err != nil {
+	if err = p.ipvsHandle.AddRealServer(vSrv, p.buildRealServer(&rs.endpoint, rs.Weight)); err != nil {
 		logger.Warn("Failed to add real server back: %v", err)
+		return
 	}
+	backendChanges.WithLabelValues(vs.String(), rs.String(), backendActionAdd).Inc()
 }
 
 func (p *realProxier) runCheck() {
 	wg := &sync.WaitGroup{}
-	for vs, rsMap := range p.serviceMap {
+	for vs, rsList := range p.snapshot() {
 		vSrv, err := p.ensureVirtualServer(p.buildVirtualServer(&vs))
 		if err != nil {
 			logger.Error("Failed to get or create IPVS service: %v", err)
 			continue
 		}
-		for _, rs := range rsMap {
+		for _, rs := range rsList {
 			wg.Add(1)
-			go p.checkRealServer(wg, vSrv, rs)
+			go p.checkRealServer(wg, vs, vSrv, rs)
 		}
 	}
 	wg.Wait()
 }
 
+// snapshot returns a copy of the service map sorted by the address of real servers.
+func (p *realProxier) snapshot() map[endpoint][]realServer {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	ret := make(map[endpoint][]realServer, len(p.serviceMap))
+	for vs, rsMap := range p.serviceMap {
+		rsList := make([]realServer, 0, len(rsMap))
+		for _, rs := range rsMap {
+			rsList = append(rsList, rs)
+		}
+		sort.Slice(rsList, func(i, j int) bool { return rsList[i].String() < rsList[j].String() })
+		ret[vs] = rsList
+	}
+	return ret
+}
+
 func (p *realProxier) buildVirtualServer(ep *endpoint) *ipvs.VirtualServer {
 	return &ipvs.VirtualServer{
 		Address:   net.ParseIP(ep.IP),
@@ -309,11 +369,11 @@ func (p *realProxier) buildVirtualServer(ep *endpoint) *ipvs.VirtualServer {
 	}
 }
 
-func (p *realProxier) buildRealServer(ep *endpoint) *ipvs.RealServer {
+func (p *realProxier) buildRealServer(ep *endpoint, weight int) *ipvs.RealServer {
 	return &ipvs.RealServer{
 		Address: net.ParseIP(ep.IP),
 		Port:    ep.Port,
-		Weight:  1,
+		Weight:  weight,
 	}
 }
 
@@ -339,3 +399,22 @@ func parseEndpoint(hostport string) (endpoint, error) {
 		Port: port,
 	}, nil
 }
+
+// parseRealServer parses the real server address with an optional weight, e.g. 192.168.0.2:6443@3,
+// the weight defaults to 1.
+func parseRealServer(s string) (endpoint, int, error) {
+	weight := 1
+	if i := strings.LastIndex(s, "@"); i >= 0 {
+		w, err := strconv.Atoi(s[i+1:])
+		if err != nil || w < 1 {
+			return endpoint{}, 0, fmt.Errorf("invalid weight of real server %s, must be a positive integer", s)
+		}
+		weight = w
+		s = s[:i]
+	}
+	ep, err := parseEndpoint(s)
+	if err != nil {
+		return endpoint{}, 0, err
+	}
+	return ep, weight, nil
+}
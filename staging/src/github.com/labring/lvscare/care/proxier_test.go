@@ -0,0 +1,187 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package care
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	ipvstest "k8s.io/kubernetes/pkg/util/ipvs/testing"
+)
+
+func TestParseRealServer(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    endpoint
+		weight  int
+		wantErr bool
+	}{
+		{in: "192.168.0.2:6443", want: endpoint{IP: "192.168.0.2", Port: 6443}, weight: 1},
+		{in: "192.168.0.2:6443@3", want: endpoint{IP: "192.168.0.2", Port: 6443}, weight: 3},
+		{in: "[fd00::2]:6443@2", want: endpoint{IP: "fd00::2", Port: 6443}, weight: 2},
+		{in: "192.168.0.2:6443@0", wantErr: true},
+		{in: "192.168.0.2:6443@-1", wantErr: true},
+		{in: "192.168.0.2:6443@", wantErr: true},
+		{in: "192.168.0.2:6443@x", wantErr: true},
+		{in: "192.168.0.2:6443@1@2", wantErr: true},
+		{in: "192.168.0.2", wantErr: true},
+		{in: "192.168.0.2:65536", wantErr: true},
+		{in: "192.168.0.2:https", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		ep, weight, err := parseRealServer(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseRealServer(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if err == nil && (ep != tt.want || weight != tt.weight) {
+			t.Errorf("parseRealServer(%q) = %v, %d, want %v, %d", tt.in, ep, weight, tt.want, tt.weight)
+		}
+	}
+}
+
+// fakeProber fails the probes to the hosts in errs
+type fakeProber struct {
+	errs map[string]error
+}
+
+func (p *fakeProber) Probe(host, port string) error {
+	return p.errs[net.JoinHostPort(host, port)]
+}
+
+func newFakeProxier(prober Prober, detector *outlierDetector) *realProxier {
+	return &realProxier{
+		scheduler:  "rr",
+		ipvsHandle: ipvstest.NewFake(),
+		serviceMap: make(map[endpoint]map[string]realServer),
+		probes:     make(map[string]probeResult),
+		prober:     prober,
+		detector:   detector,
+	}
+}
+
+// check probes the real servers one by one, the fake IPVS is not safe for concurrent use
+func check(t *testing.T, p *realProxier) {
+	for vs, rsList := range p.snapshot() {
+		vSrv, err := p.ensureVirtualServer(p.buildVirtualServer(&vs))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, rs := range rsList {
+			wg := &sync.WaitGroup{}
+			wg.Add(1)
+			p.checkRealServer(wg, vs, vSrv, rs)
+		}
+	}
+}
+
+func appliedWeight(t *testing.T, p *realProxier, vs, rs string) (int, bool) {
+	vsEp, err := parseEndpoint(vs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsEp, err := parseEndpoint(rs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rSrv, err := p.getRealServer(p.buildVirtualServer(&vsEp), p.buildRealServer(&rsEp, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rSrv == nil {
+		return 0, false
+	}
+	return rSrv.Weight, true
+}
+
+func TestCheckRealServer(t *testing.T) {
+	const (
+		vs      = "10.103.97.2:6443"
+		faulty  = "192.168.0.2:6443"
+		healthy = "192.168.0.3:6443"
+	)
+	prober := &fakeProber{errs: map[string]error{}}
+	detector := newOutlierDetector()
+	detector.Rise, detector.Fall = 1, 2
+	p := newFakeProxier(prober, detector)
+	if err := p.EnsureVirtualServer(vs); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.EnsureRealServer(vs, faulty+"@3"); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.EnsureRealServer(vs, healthy); err != nil {
+		t.Fatal(err)
+	}
+	added := backendChanges.WithLabelValues(vs, faulty, backendActionAdd)
+	removed := backendChanges.WithLabelValues(vs, faulty, backendActionRemove)
+	failures := probeFailures.WithLabelValues(vs, faulty)
+	if got := testutil.ToFloat64(added); got != 1 {
+		t.Errorf("backend added = %v, want 1", got)
+	}
+
+	prober.errs[faulty] = errors.New("connection refused")
+	steps := []struct {
+		name     string
+		weight   int
+		inIPVS   bool
+		ejected  bool
+		failures float64
+		removed  float64
+		added    float64
+	}{
+		{"below the threshold", 3, true, false, 1, 0, 1},
+		{"drain once ejected", 0, true, true, 2, 0, 1},
+		{"remove once drained", 0, false, true, 3, 1, 1},
+	}
+	for _, s := range steps {
+		check(t, p)
+		weight, inIPVS := appliedWeight(t, p, vs, faulty)
+		if weight != s.weight || inIPVS != s.inIPVS {
+			t.Errorf("%s: applied weight = %d, %v, want %d, %v", s.name, weight, inIPVS, s.weight, s.inIPVS)
+		}
+		if _, ejected := detector.State(faulty); ejected != s.ejected {
+			t.Errorf("%s: ejected = %v, want %v", s.name, ejected, s.ejected)
+		}
+		if got := testutil.ToFloat64(failures); got != s.failures {
+			t.Errorf("%s: probe failures = %v, want %v", s.name, got, s.failures)
+		}
+		if got := testutil.ToFloat64(removed); got != s.removed {
+			t.Errorf("%s: backend removed = %v, want %v", s.name, got, s.removed)
+		}
+		if got := testutil.ToFloat64(added); got != s.added {
+			t.Errorf("%s: backend added = %v, want %v", s.name, got, s.added)
+		}
+	}
+	if weight, inIPVS := appliedWeight(t, p, vs, healthy); weight != 1 || !inIPVS {
+		t.Errorf("healthy real server: applied weight = %d, %v, want 1, true", weight, inIPVS)
+	}
+
+	delete(prober.errs, faulty)
+	check(t, p)
+	if weight, inIPVS := appliedWeight(t, p, vs, faulty); weight != 3 || !inIPVS {
+		t.Errorf("recovered: applied weight = %d, %v, want 3, true", weight, inIPVS)
+	}
+	if got := testutil.ToFloat64(added); got != 2 {
+		t.Errorf("recovered: backend added = %v, want 2", got)
+	}
+	if got := testutil.CollectAndCount(probeDuration, "lvscare_probe_duration_seconds"); got < 2 {
+		t.Errorf("probe duration series = %d, want at least 2", got)
+	}
+}
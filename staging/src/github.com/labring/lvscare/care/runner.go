@@ -27,13 +27,17 @@ import (
 )
 
 var LVS = &runner{
-	options: &options{},
-	prober:  &httpProber{},
+	options:  &options{},
+	prober:   newTypedProber(),
+	detector: newOutlierDetector(),
+	server:   &statusServer{},
 }
 
 type runner struct {
 	*options
-	prober Prober
+	prober   Prober
+	detector *outlierDetector
+	server   *statusServer
 
 	proxier      Proxier
 	ruler        Ruler
@@ -78,6 +82,17 @@ func (r *runner) Run() (err error) {
 	go func() {
 		errCh <- r.proxier.RunLoop(ctx)
 	}()
+	if getter, ok := r.proxier.(statusGetter); ok && r.server.Address != "" && !r.options.RunOnce {
+		go func() {
+			if err := r.server.Run(ctx, getter); err != nil {
+				logger.Error("failed to serve metrics and status: %v", err)
+				select {
+				case errCh <- err:
+				default:
+				}
+			}
+		}()
+	}
 	// fire at once, no need to check error here
 	_ = r.proxier.TryRun()
 	// ensure ipvs
@@ -125,7 +140,7 @@ func (r *runner) cleanup() error {
 }
 
 func (r *runner) RegisterCommandFlags(cmd *cobra.Command) {
-	for _, iter := range []interface{}{r.options, r.prober} {
+	for _, iter := range []interface{}{r.options, r.prober, r.detector, r.server} {
 		if registerer, ok := iter.(flagRegisterer); ok {
 			registerer.RegisterFlags(cmd.Flags())
 		}
@@ -138,14 +153,14 @@ func (r *runner) RegisterCommandFlags(cmd *cobra.Command) {
 }
 
 func (r *runner) ValidateAndSetDefaults() error {
-	for _, iter := range []interface{}{r.options, r.prober} {
+	for _, iter := range []interface{}{r.options, r.prober, r.detector, r.server} {
 		if validator, ok := iter.(flagValidator); ok {
 			if err := validator.ValidateAndSetDefaults(); err != nil {
 				return err
 			}
 		}
 	}
-	r.proxier = NewProxier(r.options.scheduler, time.Duration(r.options.Interval), r.prober, r.periodicRun)
+	r.proxier = NewProxier(r.options.scheduler, time.Duration(r.options.Interval), r.prober, r.detector, r.periodicRun)
 	virtualIP, _, err := splitHostPort(r.options.VirtualServer)
 	if err != nil {
 		return err
@@ -0,0 +1,152 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package care
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/spf13/pflag"
+
+	"github.com/labring/sealos/pkg/utils/logger"
+)
+
+type probeResult struct {
+	Time  time.Time
+	Error string
+}
+
+type VirtualServerStatus struct {
+	Address     string             `json:"address"`
+	Scheduler   string             `json:"scheduler"`
+	RealServers []RealServerStatus `json:"realServers"`
+}
+
+type RealServerStatus struct {
+	Address string `json:"address"`
+	Weight  int    `json:"weight"`
+	// Healthy is false if the last probe failed
+	Healthy bool `json:"healthy"`
+	// Ejected means the real server is taken out of IPVS by the outlier detection
+	Ejected             bool `json:"ejected"`
+	ConsecutiveFailures int  `json:"consecutiveFailures"`
+	// InIPVS and CurrentWeight are read from the IPVS rules
+	InIPVS         bool      `json:"inIPVS"`
+	CurrentWeight  int       `json:"currentWeight"`
+	LastProbeTime  time.Time `json:"lastProbeTime,omitempty"`
+	LastProbeError string    `json:"lastProbeError,omitempty"`
+}
+
+func (p *realProxier) recordProbe(rs string, t time.Time, err error) {
+	result := probeResult{Time: t}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.probes[rs] = result
+}
+
+// Status returns the virtual servers with the health of their real servers.
+func (p *realProxier) Status() []VirtualServerStatus {
+	ret := make([]VirtualServerStatus, 0)
+	for vs, rsList := range p.snapshot() {
+		vSrv := p.buildVirtualServer(&vs)
+		applied := map[string]int{}
+		if list, err := p.ipvsHandle.GetRealServers(vSrv); err == nil {
+			for _, rSrv := range list {
+				applied[net.JoinHostPort(rSrv.Address.String(), strconv.Itoa(int(rSrv.Port)))] = rSrv.Weight
+			}
+		}
+		vsStatus := VirtualServerStatus{Address: vs.String(), Scheduler: p.scheduler, RealServers: make([]RealServerStatus, 0, len(rsList))}
+		for _, rs := range rsList {
+			failures, ejected := p.detector.State(rs.String())
+			p.mu.RLock()
+			probe, probed := p.probes[rs.String()]
+			p.mu.RUnlock()
+			weight, inIPVS := applied[rs.String()]
+			vsStatus.RealServers = append(vsStatus.RealServers, RealServerStatus{
+				Address:             rs.String(),
+				Weight:              rs.Weight,
+				Healthy:             probed && probe.Error == "",
+				Ejected:             ejected,
+				ConsecutiveFailures: failures,
+				InIPVS:              inIPVS,
+				CurrentWeight:       weight,
+				LastProbeTime:       probe.Time,
+				LastProbeError:      probe.Error,
+			})
+		}
+		ret = append(ret, vsStatus)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Address < ret[j].Address })
+	return ret
+}
+
+// statusServer serves the prometheus metrics and the status of the proxier, it's
+// disabled unless the listen address is set.
+type statusServer struct {
+	Address string
+}
+
+func (s *statusServer) RegisterFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&s.Address, "metrics-address", "", "address to serve prometheus metrics on /metrics and the status of real servers on /status, for example :9090, disabled if empty")
+}
+
+func (s *statusServer) ValidateAndSetDefaults() error {
+	if s.Address == "" {
+		return nil
+	}
+	_, _, err := net.SplitHostPort(s.Address)
+	return err
+}
+
+func (s *statusServer) handler(getter statusGetter) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(metricsRegistry, promhttp.HandlerOpts{}))
+	mux.HandleFunc("/status", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(getter.Status()); err != nil {
+			logger.Warn("failed to write status: %v", err)
+		}
+	})
+	return mux
+}
+
+func (s *statusServer) Run(ctx context.Context, getter statusGetter) error {
+	srv := &http.Server{
+		Addr:              s.Address,
+		Handler:           s.handler(getter),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+	logger.Info("serving metrics and status on %s", s.Address)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
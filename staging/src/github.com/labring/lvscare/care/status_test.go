@@ -0,0 +1,109 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package care
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestStatus(t *testing.T) {
+	const (
+		vs      = "10.103.97.3:6443"
+		faulty  = "192.168.0.4:6443"
+		healthy = "192.168.0.5:6443"
+	)
+	prober := &fakeProber{errs: map[string]error{faulty: errors.New("connection refused")}}
+	detector := newOutlierDetector()
+	detector.Rise, detector.Fall = 1, 1
+	p := newFakeProxier(prober, detector)
+	if err := p.EnsureVirtualServer(vs); err != nil {
+		t.Fatal(err)
+	}
+	for _, rs := range []string{faulty + "@2", healthy} {
+		if err := p.EnsureRealServer(vs, rs); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// drain and then remove the faulty real server
+	check(t, p)
+	check(t, p)
+
+	status := p.Status()
+	if len(status) != 1 || status[0].Address != vs || status[0].Scheduler != "rr" || len(status[0].RealServers) != 2 {
+		t.Fatalf("unexpected status %+v", status)
+	}
+	got := status[0].RealServers[0]
+	if got.Address != faulty || got.Weight != 2 || got.Healthy || !got.Ejected || got.ConsecutiveFailures != 2 ||
+		got.InIPVS || got.CurrentWeight != 0 || got.LastProbeTime.IsZero() || got.LastProbeError != "connection refused" {
+		t.Errorf("unexpected status of the faulty real server %+v", got)
+	}
+	got = status[0].RealServers[1]
+	if got.Address != healthy || got.Weight != 1 || !got.Healthy || got.Ejected || got.ConsecutiveFailures != 0 ||
+		!got.InIPVS || got.CurrentWeight != 1 || got.LastProbeTime.IsZero() || got.LastProbeError != "" {
+		t.Errorf("unexpected status of the healthy real server %+v", got)
+	}
+
+	handler := (&statusServer{}).handler(p)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/status", nil))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("GET /status = %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	var served []VirtualServerStatus
+	if err := json.Unmarshal(w.Body.Bytes(), &served); err != nil {
+		t.Fatal(err)
+	}
+	if len(served) != 1 || len(served[0].RealServers) != 2 || !served[0].RealServers[0].Ejected {
+		t.Errorf("unexpected served status %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /metrics = %d", w.Code)
+	}
+	for _, want := range []string{
+		`lvscare_probe_failures_total{real_server="192.168.0.4:6443",virtual_server="10.103.97.3:6443"} 2`,
+		`lvscare_backend_changes_total{action="remove",real_server="192.168.0.4:6443",virtual_server="10.103.97.3:6443"} 1`,
+		`lvscare_probe_duration_seconds_count{real_server="192.168.0.5:6443",virtual_server="10.103.97.3:6443"} 2`,
+	} {
+		if !strings.Contains(w.Body.String(), want) {
+			t.Errorf("metrics don't contain %s", want)
+		}
+	}
+}
+
+func TestStatusServerValidateAndSetDefaults(t *testing.T) {
+	tests := []struct {
+		address string
+		wantErr bool
+	}{
+		{"", false},
+		{":9090", false},
+		{"127.0.0.1:9090", false},
+		{"9090", true},
+	}
+	for _, tt := range tests {
+		s := &statusServer{Address: tt.address}
+		if err := s.ValidateAndSetDefaults(); (err != nil) != tt.wantErr {
+			t.Errorf("ValidateAndSetDefaults(%q) error = %v, wantErr %v", tt.address, err, tt.wantErr)
+		}
+	}
+}
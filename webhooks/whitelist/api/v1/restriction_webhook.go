@@ -0,0 +1,202 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const (
+	// mastersGroup is the group of the cluster admins, they may create any resource.
+	mastersGroup = "system:masters"
+	// DecisionAnnotation is the audit annotation reporting which whitelist allowed the request or
+	// which restrictions denied it.
+	DecisionAnnotation = "decision"
+)
+
+var restrictionlog = logf.Log.WithName("restriction-resource")
+
+//+kubebuilder:webhook:path=/validate-v1-restricted-resources,mutating=false,failurePolicy=fail,sideEffects=None,groups="",resources=pods;pods/ephemeralcontainers;services,verbs=create;update,versions=v1,name=vrestriction.whitelist.sealos.io,admissionReviewVersions=v1
+//+kubebuilder:rbac:groups=resource.sealos.io,resources=whitelists,verbs=get;list;watch
+// +kubebuilder:object:generate=false
+
+// RestrictionValidator denies the pods and services using the restricted settings, unless a
+// Whitelist allows the namespace, user or group of the request.
+type RestrictionValidator struct {
+	Client  client.Client
+	decoder *admission.Decoder
+}
+
+// InjectDecoder implements admission.DecoderInjector.
+func (v *RestrictionValidator) InjectDecoder(d *admission.Decoder) error {
+	v.decoder = d
+	return nil
+}
+
+func (v *RestrictionValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	for _, g := range req.UserInfo.Groups {
+		if g == mastersGroup {
+			return admission.Allowed("cluster admin")
+		}
+	}
+
+	restrictions, err := v.restrictions(req)
+	if err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if len(restrictions) == 0 {
+		return admission.Allowed("")
+	}
+
+	whitelists := &WhitelistList{}
+	if err := v.Client.List(ctx, whitelists); err != nil {
+		restrictionlog.Error(err, "list whitelists failed")
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	var allowed, denied []string
+	for _, res := range restrictions {
+		if reason, ok := allowedBy(whitelists.Items, res, req); ok {
+			allowed = append(allowed, reason)
+		} else {
+			denied = append(denied, string(res))
+		}
+	}
+
+	var resp admission.Response
+	var decision string
+	if len(denied) > 0 {
+		decision = fmt.Sprintf("%s is not whitelisted for user %s in namespace %s",
+			strings.Join(denied, ", "), req.UserInfo.Username, req.Namespace)
+		resp = admission.Denied(decision)
+	} else {
+		decision = strings.Join(allowed, "; ")
+		resp = admission.Allowed(decision)
+	}
+	resp.AuditAnnotations = map[string]string{DecisionAnnotation: decision}
+	restrictionlog.Info("check restrictions", "kind", req.Kind.Kind, "namespace", req.Namespace, "name", req.Name,
+		"user", req.UserInfo.Username, "operation", req.Operation, "allowed", resp.Allowed, "decision", decision)
+	return resp
+}
+
+// allowedBy returns which whitelist allows the restriction for the request.
+func allowedBy(whitelists []Whitelist, res Restriction, req admission.Request) (string, bool) {
+	for i := range whitelists {
+		if s, ok := whitelists[i].Allows(res, req.Namespace, req.UserInfo.Username, req.UserInfo.Groups); ok {
+			return fmt.Sprintf("%s is allowed by whitelist %s for %s", res, whitelists[i].Name, s), true
+		}
+	}
+	return "", false
+}
+
+// restrictions returns the restrictions the object of the request uses. On update only the ones
+// the old object doesn't use are returned, so that the objects created before a whitelist is
+// removed can still be updated, e.g. to remove their finalizers.
+func (v *RestrictionValidator) restrictions(req admission.Request) ([]Restriction, error) {
+	if req.Kind.Group != "" || req.Kind.Version != "v1" {
+		return nil, nil
+	}
+	var current, old []Restriction
+	switch req.Kind.Kind {
+	case "Pod":
+		pod := &corev1.Pod{}
+		if err := v.decoder.DecodeRaw(req.Object, pod); err != nil {
+			return nil, err
+		}
+		current = podRestrictions(pod)
+		if req.Operation == admissionv1.Update {
+			oldPod := &corev1.Pod{}
+			if err := v.decoder.DecodeRaw(req.OldObject, oldPod); err != nil {
+				return nil, err
+			}
+			old = podRestrictions(oldPod)
+		}
+	case "Service":
+		svc := &corev1.Service{}
+		if err := v.decoder.DecodeRaw(req.Object, svc); err != nil {
+			return nil, err
+		}
+		current = serviceRestrictions(svc)
+		if req.Operation == admissionv1.Update {
+			oldSvc := &corev1.Service{}
+			if err := v.decoder.DecodeRaw(req.OldObject, oldSvc); err != nil {
+				return nil, err
+			}
+			old = serviceRestrictions(oldSvc)
+		}
+	default:
+		return nil, nil
+	}
+	var ret []Restriction
+	for _, res := range current {
+		if !containsRestriction(old, res) {
+			ret = append(ret, res)
+		}
+	}
+	return ret, nil
+}
+
+func podRestrictions(pod *corev1.Pod) []Restriction {
+	var ret []Restriction
+	for _, vol := range pod.Spec.Volumes {
+		if vol.HostPath != nil {
+			ret = append(ret, RestrictionHostPathVolume)
+			break
+		}
+	}
+	containers := append(append([]corev1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...)
+	for _, c := range containers {
+		if c.SecurityContext != nil && c.SecurityContext.Privileged != nil && *c.SecurityContext.Privileged {
+			ret = append(ret, RestrictionPrivilegedPod)
+			return ret
+		}
+	}
+	for _, c := range pod.Spec.EphemeralContainers {
+		if c.SecurityContext != nil && c.SecurityContext.Privileged != nil && *c.SecurityContext.Privileged {
+			ret = append(ret, RestrictionPrivilegedPod)
+			return ret
+		}
+	}
+	return ret
+}
+
+func serviceRestrictions(svc *corev1.Service) []Restriction {
+	switch svc.Spec.Type {
+	case corev1.ServiceTypeNodePort:
+		return []Restriction{RestrictionNodePortService}
+	case corev1.ServiceTypeLoadBalancer:
+		return []Restriction{RestrictionLoadBalancerService}
+	}
+	return nil
+}
+
+func containsRestriction(restrictions []Restriction, res Restriction) bool {
+	for _, r := range restrictions {
+		if r == res {
+			return true
+		}
+	}
+	return false
+}
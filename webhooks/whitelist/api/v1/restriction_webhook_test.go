@@ -0,0 +1,223 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func newRestrictionValidator(t *testing.T, whitelists ...Whitelist) *RestrictionValidator {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	objs := make([]runtime.Object, 0, len(whitelists))
+	for i := range whitelists {
+		objs = append(objs, &whitelists[i])
+	}
+	decoder, err := admission.NewDecoder(scheme)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := &RestrictionValidator{Client: fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(objs...).Build()}
+	if err := v.InjectDecoder(decoder); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func newRequest(t *testing.T, op admissionv1.Operation, obj, old runtime.Object, kind, user string, groups ...string) admission.Request {
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+		Kind:      metav1.GroupVersionKind{Version: "v1", Kind: kind},
+		Namespace: "ns-test",
+		Name:      "test",
+		Operation: op,
+		UserInfo:  authenticationv1.UserInfo{Username: user, Groups: groups},
+		Object:    runtime.RawExtension{Raw: raw},
+	}}
+	if old != nil {
+		raw, err := json.Marshal(old)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.OldObject = runtime.RawExtension{Raw: raw}
+	}
+	return req
+}
+
+func service(typ corev1.ServiceType) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "ns-test"},
+		Spec:       corev1.ServiceSpec{Type: typ},
+	}
+}
+
+func privilegedPod() *corev1.Pod {
+	privileged := true
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "ns-test"},
+		Spec: corev1.PodSpec{
+			Volumes: []corev1.Volume{{Name: "host", VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/"}}}},
+			Containers: []corev1.Container{{
+				Name:            "test",
+				SecurityContext: &corev1.SecurityContext{Privileged: &privileged},
+			}},
+		},
+	}
+}
+
+// debuggedPod returns the pod with a privileged ephemeral container, as sent on the
+// ephemeralcontainers subresource by kubectl debug.
+func debuggedPod() *corev1.Pod {
+	privileged := true
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "ns-test"}}
+	pod.Spec.EphemeralContainers = []corev1.EphemeralContainer{{
+		EphemeralContainerCommon: corev1.EphemeralContainerCommon{
+			Name:            "debugger",
+			SecurityContext: &corev1.SecurityContext{Privileged: &privileged},
+		},
+	}}
+	return pod
+}
+
+func ephemeralContainersRequest(t *testing.T, user string, groups ...string) admission.Request {
+	old := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "ns-test"}}
+	req := newRequest(t, admissionv1.Update, debuggedPod(), old, "Pod", user, groups...)
+	req.SubResource = "ephemeralcontainers"
+	return req
+}
+
+func whitelist(name string, restrictions []Restriction, subjects ...Subject) Whitelist {
+	return Whitelist{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       WhitelistSpec{Subjects: subjects, Restrictions: restrictions},
+	}
+}
+
+func TestRestrictionValidator(t *testing.T) {
+	v := newRestrictionValidator(t,
+		whitelist("nodeport", []Restriction{RestrictionNodePortService}, Subject{Kind: SubjectKindNamespace, Name: "ns-test"}),
+		whitelist("hostpath", []Restriction{RestrictionHostPathVolume}, Subject{Kind: SubjectKindUser, Name: "admin"}),
+		whitelist("privileged", []Restriction{RestrictionPrivilegedPod}, Subject{Kind: SubjectKindGroup, Name: "ops"}),
+	)
+	tests := []struct {
+		name    string
+		req     admission.Request
+		allowed bool
+		message string
+	}{
+		{
+			name:    "cluster ip service",
+			req:     newRequest(t, admissionv1.Create, service(corev1.ServiceTypeClusterIP), nil, "Service", "user"),
+			allowed: true,
+		},
+		{
+			name:    "node port service whitelisted by namespace",
+			req:     newRequest(t, admissionv1.Create, service(corev1.ServiceTypeNodePort), nil, "Service", "user"),
+			allowed: true,
+			message: "NodePortService is allowed by whitelist nodeport for Namespace/ns-test",
+		},
+		{
+			name:    "load balancer service not whitelisted",
+			req:     newRequest(t, admissionv1.Create, service(corev1.ServiceTypeLoadBalancer), nil, "Service", "user"),
+			message: "LoadBalancerService is not whitelisted for user user in namespace ns-test",
+		},
+		{
+			name:    "load balancer service unchanged on update",
+			req:     newRequest(t, admissionv1.Update, service(corev1.ServiceTypeLoadBalancer), service(corev1.ServiceTypeLoadBalancer), "Service", "user"),
+			allowed: true,
+		},
+		{
+			name:    "privileged pod whitelisted by user and group",
+			req:     newRequest(t, admissionv1.Create, privilegedPod(), nil, "Pod", "admin", "ops"),
+			allowed: true,
+			message: "HostPathVolume is allowed by whitelist hostpath for User/admin; PrivilegedPod is allowed by whitelist privileged for Group/ops",
+		},
+		{
+			name:    "privileged pod partly whitelisted",
+			req:     newRequest(t, admissionv1.Create, privilegedPod(), nil, "Pod", "admin"),
+			message: "PrivilegedPod is not whitelisted for user admin in namespace ns-test",
+		},
+		{
+			name:    "privileged ephemeral container not whitelisted",
+			req:     ephemeralContainersRequest(t, "user"),
+			message: "PrivilegedPod is not whitelisted for user user in namespace ns-test",
+		},
+		{
+			name:    "privileged ephemeral container whitelisted by group",
+			req:     ephemeralContainersRequest(t, "user", "ops"),
+			allowed: true,
+			message: "PrivilegedPod is allowed by whitelist privileged for Group/ops",
+		},
+		{
+			name:    "cluster admin",
+			req:     newRequest(t, admissionv1.Create, privilegedPod(), nil, "Pod", "kubernetes-admin", mastersGroup),
+			allowed: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := v.Handle(context.Background(), tt.req)
+			if resp.Allowed != tt.allowed {
+				t.Fatalf("allowed = %v, want %v: %v", resp.Allowed, tt.allowed, resp.Result)
+			}
+			if tt.message == "" {
+				return
+			}
+			if resp.Result == nil || !strings.Contains(string(resp.Result.Reason), tt.message) {
+				t.Errorf("result = %v, want %q", resp.Result, tt.message)
+			}
+			if resp.AuditAnnotations[DecisionAnnotation] != tt.message {
+				t.Errorf("audit annotation = %q, want %q", resp.AuditAnnotations[DecisionAnnotation], tt.message)
+			}
+		})
+	}
+}
+
+func TestWhitelistValidate(t *testing.T) {
+	valid := whitelist("valid", []Restriction{RestrictionPrivilegedPod}, Subject{Kind: SubjectKindNamespace, Name: SubjectAll})
+	if err := valid.ValidateCreate(); err != nil {
+		t.Errorf("validate %s: %v", valid.Name, err)
+	}
+	duplicate := whitelist("duplicate", []Restriction{RestrictionPrivilegedPod, RestrictionPrivilegedPod}, Subject{Kind: SubjectKindUser, Name: "admin"})
+	if err := duplicate.ValidateCreate(); err == nil {
+		t.Errorf("validate %s: want error", duplicate.Name)
+	}
+	unknown := whitelist("unknown", []Restriction{RestrictionPrivilegedPod}, Subject{Kind: "ServiceAccount", Name: "admin"})
+	if err := unknown.ValidateCreate(); err == nil {
+		t.Errorf("validate %s: want error", unknown.Name)
+	}
+}
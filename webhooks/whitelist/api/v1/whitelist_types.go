@@ -20,28 +20,74 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-// EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
-// NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
+// SubjectKind is the kind of the subjects of a Whitelist.
+// +kubebuilder:validation:Enum=Namespace;User;Group
+type SubjectKind string
+
+const (
+	// SubjectKindNamespace matches the requests for the resources in the namespace, whoever makes them.
+	// The pods of workloads are created by the controllers of the cluster, so only namespace subjects
+	// whitelist them.
+	SubjectKindNamespace SubjectKind = "Namespace"
+	// SubjectKindUser matches the requests of the user, e.g. system:serviceaccount:ns-admin:admin.
+	SubjectKindUser SubjectKind = "User"
+	// SubjectKindGroup matches the requests of the users in the group.
+	SubjectKindGroup SubjectKind = "Group"
+)
+
+// SubjectAll is the name of a subject matching all of its kind.
+const SubjectAll = "*"
+
+// Subject is who a Whitelist applies to.
+type Subject struct {
+	Kind SubjectKind `json:"kind"`
+	// Name of the namespace, user or group, * matches all of them.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+}
+
+func (s Subject) String() string {
+	return string(s.Kind) + "/" + s.Name
+}
+
+// Restriction is a kind of resources or settings only the whitelisted subjects may create.
+// +kubebuilder:validation:Enum=NodePortService;LoadBalancerService;HostPathVolume;PrivilegedPod
+type Restriction string
+
+const (
+	// RestrictionNodePortService is a Service of type NodePort.
+	RestrictionNodePortService Restriction = "NodePortService"
+	// RestrictionLoadBalancerService is a Service of type LoadBalancer.
+	RestrictionLoadBalancerService Restriction = "LoadBalancerService"
+	// RestrictionHostPathVolume is a Pod with a hostPath volume.
+	RestrictionHostPathVolume Restriction = "HostPathVolume"
+	// RestrictionPrivilegedPod is a Pod with a privileged container.
+	RestrictionPrivilegedPod Restriction = "PrivilegedPod"
+)
 
 // WhitelistSpec defines the desired state of Whitelist
 type WhitelistSpec struct {
-	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
-	// Important: Run "make" to regenerate code after modifying this file
-
-	// Foo is an example field of Whitelist. Edit whitelist_types.go to remove/update
-	Foo string `json:"foo,omitempty"`
+	// Subjects are who the Whitelist applies to, a request is whitelisted when any of them matches.
+	// +kubebuilder:validation:MinItems=1
+	Subjects []Subject `json:"subjects"`
+	// Restrictions are what the subjects are allowed to create.
+	// +kubebuilder:validation:MinItems=1
+	Restrictions []Restriction `json:"restrictions"`
 }
 
 // WhitelistStatus defines the observed state of Whitelist
 type WhitelistStatus struct {
-	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
-	// Important: Run "make" to regenerate code after modifying this file
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:resource:scope=Cluster
+//+kubebuilder:printcolumn:name="Subjects",type=string,JSONPath=".spec.subjects[*].name"
+//+kubebuilder:printcolumn:name="Restrictions",type=string,JSONPath=".spec.restrictions"
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=".metadata.creationTimestamp"
 
-// Whitelist is the Schema for the whitelists API
+// Whitelist allows the subjects to create the restricted resources, all the restricted
+// resources which aren't whitelisted are denied by the webhook.
 type Whitelist struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
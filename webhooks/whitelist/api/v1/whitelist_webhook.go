@@ -17,6 +17,8 @@ limitations under the License.
 package v1
 
 import (
+	"fmt"
+
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
@@ -32,20 +34,6 @@ func (r *Whitelist) SetupWebhookWithManager(mgr ctrl.Manager) error {
 		Complete()
 }
 
-// TODO(user): EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
-
-//+kubebuilder:webhook:path=/mutate-resource-sealos-io-v1-whitelist,mutating=true,failurePolicy=fail,sideEffects=None,groups=resource.sealos.io,resources=whitelists,verbs=create;update,versions=v1,name=mwhitelist.kb.io,admissionReviewVersions=v1
-
-var _ webhook.Defaulter = &Whitelist{}
-
-// Default implements webhook.Defaulter so a webhook will be registered for the type
-func (r *Whitelist) Default() {
-	whitelistlog.Info("default", "name", r.Name)
-
-	// TODO(user): fill in your defaulting logic.
-}
-
-// TODO(user): change verbs to "verbs=create;update;delete" if you want to enable deletion validation.
 //+kubebuilder:webhook:path=/validate-resource-sealos-io-v1-whitelist,mutating=false,failurePolicy=fail,sideEffects=None,groups=resource.sealos.io,resources=whitelists,verbs=create;update,versions=v1,name=vwhitelist.kb.io,admissionReviewVersions=v1
 
 var _ webhook.Validator = &Whitelist{}
@@ -53,23 +41,82 @@ var _ webhook.Validator = &Whitelist{}
 // ValidateCreate implements webhook.Validator so a webhook will be registered for the type
 func (r *Whitelist) ValidateCreate() error {
 	whitelistlog.Info("validate create", "name", r.Name)
-
-	// TODO(user): fill in your validation logic upon object creation.
-	return nil
+	return r.validate()
 }
 
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
 func (r *Whitelist) ValidateUpdate(_ runtime.Object) error {
 	whitelistlog.Info("validate update", "name", r.Name)
-
-	// TODO(user): fill in your validation logic upon object update.
-	return nil
+	return r.validate()
 }
 
 // ValidateDelete implements webhook.Validator so a webhook will be registered for the type
 func (r *Whitelist) ValidateDelete() error {
 	whitelistlog.Info("validate delete", "name", r.Name)
+	return nil
+}
 
-	// TODO(user): fill in your validation logic upon object deletion.
+func (r *Whitelist) validate() error {
+	subjects := make(map[Subject]bool, len(r.Spec.Subjects))
+	for _, s := range r.Spec.Subjects {
+		switch s.Kind {
+		case SubjectKindNamespace, SubjectKindUser, SubjectKindGroup:
+		default:
+			return fmt.Errorf("unknown subject kind %q", s.Kind)
+		}
+		if s.Name == "" {
+			return fmt.Errorf("subject %s has no name", s.Kind)
+		}
+		if subjects[s] {
+			return fmt.Errorf("duplicate subject %s", s)
+		}
+		subjects[s] = true
+	}
+	restrictions := make(map[Restriction]bool, len(r.Spec.Restrictions))
+	for _, res := range r.Spec.Restrictions {
+		switch res {
+		case RestrictionNodePortService, RestrictionLoadBalancerService, RestrictionHostPathVolume, RestrictionPrivilegedPod:
+		default:
+			return fmt.Errorf("unknown restriction %q", res)
+		}
+		if restrictions[res] {
+			return fmt.Errorf("duplicate restriction %s", res)
+		}
+		restrictions[res] = true
+	}
 	return nil
 }
+
+// Allows returns the subject of the whitelist which allows the user to create the restricted
+// resource in the namespace, if any.
+func (r *Whitelist) Allows(restriction Restriction, namespace, user string, groups []string) (Subject, bool) {
+	allowed := false
+	for _, res := range r.Spec.Restrictions {
+		if res == restriction {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return Subject{}, false
+	}
+	for _, s := range r.Spec.Subjects {
+		switch s.Kind {
+		case SubjectKindNamespace:
+			if namespace != "" && (s.Name == SubjectAll || s.Name == namespace) {
+				return s, true
+			}
+		case SubjectKindUser:
+			if s.Name == SubjectAll || s.Name == user {
+				return s, true
+			}
+		case SubjectKindGroup:
+			for _, g := range groups {
+				if s.Name == SubjectAll || s.Name == g {
+					return s, true
+				}
+			}
+		}
+	}
+	return Subject{}, false
+}
@@ -30,6 +30,7 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	resourcev1 "sealos.io/whitelist/api/v1"
 	"sealos.io/whitelist/controllers"
@@ -100,6 +101,7 @@ func main() {
 		setupLog.Error(err, "unable to create webhook", "webhook", "Whitelist")
 		os.Exit(1)
 	}
+	mgr.GetWebhookServer().Register("/validate-v1-restricted-resources", &webhook.Admission{Handler: &resourcev1.RestrictionValidator{Client: mgr.GetClient()}})
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {